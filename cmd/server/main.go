@@ -54,7 +54,7 @@ var (
 
 	// shared with tester
 	configPath     = flag.String("config", "", "configuration path (defaults to searching for config.yaml)")
-	persistBackend = flag.String("persist-backend", "", "Cache persistence backend (disk, mysql, cloudsql)")
+	persistBackend = flag.String("persist-backend", "", "Cache persistence backend (disk, mysql, cloudsql, postgres, redis, sqlite, memory)")
 	persistPath    = flag.String("persist-path", "", "Where to persist cache to (automatic)")
 
 	reposOverride   = flag.String("repos", "", "Override configured repos with this repository (comma separated)")
@@ -72,6 +72,15 @@ var (
 	maxRefresh = flag.Duration("max-refresh", 60*time.Minute, "Maximum time between collection runs")
 	minRefresh = flag.Duration("min-refresh", 60*time.Second, "Minimum time between collection runs")
 	warnAge    = flag.Duration("warn-age", 90*time.Minute, "Warn when the results are older than this")
+
+	accessHistory      = flag.Int("access-history", 2, "How many recent views of each collection to weigh its popularity by")
+	popularityHalfLife = flag.Duration("popularity-half-life", 0, "Halve the weight of a collection view for every this long since it happened (0 to weigh views equally)")
+
+	persistFuzz     = flag.Float64("persist-fuzz", 1.0, "Fraction of --max-refresh to randomly delay cache persistence by (0 to disable)")
+	persistRetries  = flag.Int("persist-retries", 3, "How many times to retry a failed cache persist, with exponential backoff (-1 for none)")
+	persistFallback = flag.String("persist-fallback", "", "Path to snapshot the cache to while the persistence backend is failing, restored at startup (optional)")
+
+	renderCacheSize = flag.Int("render-cache-size", 0, "How many deduplicated and grouped collection views to cache between refreshes (0 to disable)")
 )
 
 func main() {
@@ -103,6 +112,15 @@ func main() {
 		klog.Exitf("persist initialize for %s: %v", c, err)
 	}
 
+	if *persistFallback != "" {
+		found, err := persist.RestoreSnapshot(c, *persistFallback)
+		if err != nil {
+			klog.Errorf("restore snapshot: %v", err)
+		} else if found {
+			klog.Infof("restored fallback snapshot from %s", *persistFallback)
+		}
+	}
+
 	var debugNums []int
 	for _, n := range strings.Split(*numbers, ",") {
 		i, err := strconv.Atoi(n)
@@ -139,12 +157,25 @@ func main() {
 		sn = calculateSiteName(ts)
 	}
 
-	u := updater.New(updater.Config{
-		Party:       tp,
-		MinRefresh:  *minRefresh,
-		MaxRefresh:  *maxRefresh,
-		PersistFunc: c.Cleanup,
-	})
+	uc := updater.Config{
+		Party:          tp,
+		MinRefresh:     *minRefresh,
+		MaxRefresh:     *maxRefresh,
+		PersistFunc:    c.Cleanup,
+		PersistFuzz:    *persistFuzz,
+		PersistRetries: *persistRetries,
+		AccessHistory:  *accessHistory,
+	}
+	if *persistFuzz == 0 {
+		uc.DisablePersistFuzz = true
+	}
+	if *popularityHalfLife > 0 {
+		uc.Decay = updater.ExponentialDecay(*popularityHalfLife)
+	}
+	if *persistFallback != "" {
+		uc.PersistFallback = func() error { return persist.WriteSnapshot(c, *persistFallback) }
+	}
+	u := updater.New(uc)
 
 	if *dryRun {
 		klog.Infof("Updating ...")
@@ -165,6 +196,11 @@ func main() {
 		os.Exit(1)
 	}()
 
+	// Hot collections are loaded before serving, so that their first page loads do not block
+	if err := u.Warm(ctx); err != nil {
+		klog.Errorf("warm: %v", err)
+	}
+
 	go func() {
 		if err := u.Loop(ctx); err == nil {
 			klog.Exitf("loop failed: %v", err)
@@ -177,6 +213,8 @@ func main() {
 		Party:         tp,
 		WarnAge:       *warnAge,
 		Name:          sn,
+
+		RenderCacheSize: *renderCacheSize,
 	})
 
 	http.Handle("/third_party/", http.StripPrefix("/third_party/", http.FileServer(http.Dir(findPath(*thirdPartyDir)))))
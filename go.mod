@@ -4,9 +4,11 @@ go 1.14
 
 require (
 	github.com/GoogleCloudPlatform/cloudsql-proxy v0.0.0-20200501161113-5e9e23d7cb91
+	github.com/alicebob/miniredis/v2 v2.14.1
 	github.com/davecgh/go-spew v1.1.1
 	github.com/dustin/go-humanize v1.0.0
 	github.com/fatih/color v1.9.0 // indirect
+	github.com/go-redis/redis/v7 v7.4.0
 	github.com/go-sql-driver/mysql v1.5.0
 	github.com/golang/protobuf v1.4.2 // indirect
 	github.com/google/go-github/v31 v31.0.0
@@ -15,6 +17,7 @@ require (
 	github.com/imjasonmiller/godice v0.1.2
 	github.com/jmoiron/sqlx v1.2.0
 	github.com/lib/pq v1.3.0
+	github.com/mattn/go-sqlite3 v1.14.4
 	github.com/patrickmn/go-cache v2.1.0+incompatible
 	github.com/stretchr/testify v1.4.0
 	github.com/xanzy/go-gitlab v0.36.0
@@ -0,0 +1,157 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package calendar measures how much business time has elapsed between two points in time
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Calendar describes which hours of the week are business hours
+type Calendar struct {
+	loc  *time.Location
+	days map[time.Weekday]bool
+	// offsets from midnight
+	start time.Duration
+	end   time.Duration
+}
+
+// Default returns a calendar of Monday through Friday, all day, in UTC
+func Default() *Calendar {
+	c, _ := New("", nil, "", "")
+	return c
+}
+
+// New returns a calendar. Empty values default to Monday through Friday, 00:00 to 24:00, in UTC
+func New(timezone string, days []string, start string, end string) (*Calendar, error) {
+	c := &Calendar{
+		loc:   time.UTC,
+		days:  map[time.Weekday]bool{},
+		start: 0,
+		end:   24 * time.Hour,
+	}
+
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("timezone: %w", err)
+		}
+		c.loc = loc
+	}
+
+	if len(days) == 0 {
+		days = []string{"mon", "tue", "wed", "thu", "fri"}
+	}
+
+	for _, d := range days {
+		if len(d) < 3 {
+			return nil, fmt.Errorf("unknown day: %q", d)
+		}
+		wd, ok := weekdays[strings.ToLower(d)[0:3]]
+		if !ok {
+			return nil, fmt.Errorf("unknown day: %q", d)
+		}
+		c.days[wd] = true
+	}
+
+	var err error
+	if start != "" {
+		c.start, err = parseClock(start)
+		if err != nil {
+			return nil, fmt.Errorf("start: %w", err)
+		}
+	}
+
+	if end != "" {
+		c.end, err = parseClock(end)
+		if err != nil {
+			return nil, fmt.Errorf("end: %w", err)
+		}
+	}
+
+	if c.end <= c.start {
+		return nil, fmt.Errorf("end (%s) must be after start (%s)", end, start)
+	}
+	return c, nil
+}
+
+// parseClock parses a time of day such as 09:30 into an offset from midnight
+func parseClock(s string) (time.Duration, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil {
+		return 0, fmt.Errorf("%q is not in HH:MM form", s)
+	}
+	if h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, fmt.Errorf("%q is not a valid time of day", s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// Elapsed returns how much business time has elapsed between from and to
+func (c *Calendar) Elapsed(from time.Time, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+
+	from = from.In(c.loc)
+	to = to.In(c.loc)
+
+	var total time.Duration
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, c.loc)
+	for day.Before(to) {
+		if c.days[day.Weekday()] {
+			open := day.Add(c.start)
+			close := day.Add(c.end)
+			if open.Before(from) {
+				open = from
+			}
+			if close.After(to) {
+				close = to
+			}
+			if close.After(open) {
+				total += close.Sub(open)
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return total
+}
+
+// Open returns whether t is within business hours, as seen from the calendar's timezone
+func (c *Calendar) Open(t time.Time) bool {
+	t = t.In(c.loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.loc)
+	if !c.days[day.Weekday()] {
+		return false
+	}
+	return !t.Before(day.Add(c.start)) && t.Before(day.Add(c.end))
+}
+
+// Since returns how much business time has elapsed since t
+func (c *Calendar) Since(t time.Time) time.Duration {
+	return c.Elapsed(t, time.Now())
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestElapsed(t *testing.T) {
+	office, err := New("America/New_York", []string{"Monday", "tue", "wed", "thu", "fri"}, "09:00", "17:00")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	// Friday 2020-06-05
+	fri := func(h int) time.Time { return time.Date(2020, 6, 5, h, 0, 0, 0, ny) }
+	utcFri := time.Date(2020, 6, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		cal  *Calendar
+		from time.Time
+		to   time.Time
+		want time.Duration
+	}{
+		{"same day", office, fri(10), fri(12), 2 * time.Hour},
+		{"before opening", office, fri(6), fri(8), 0},
+		{"over the weekend", office, fri(16), fri(16).AddDate(0, 0, 3), 8 * time.Hour},
+		{"whole weekend", office, fri(18), fri(8).AddDate(0, 0, 3), 0},
+		{"full week", office, fri(9), fri(9).AddDate(0, 0, 7), 40 * time.Hour},
+		{"reversed", office, fri(12), fri(10), 0},
+		{"default weekend", Default(), utcFri.AddDate(0, 0, 1), utcFri.AddDate(0, 0, 3), 0},
+		{"default weekdays", Default(), utcFri.AddDate(0, 0, 3), utcFri.AddDate(0, 0, 5), 48 * time.Hour},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.cal.Elapsed(tc.from, tc.to)
+			if got != tc.want {
+				t.Errorf("Elapsed(%s, %s) = %s, want %s", tc.from, tc.to, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		days     []string
+		start    string
+		end      string
+	}{
+		{"bad timezone", "Mars/Olympus_Mons", nil, "", ""},
+		{"bad day", "", []string{"funday"}, "", ""},
+		{"bad clock", "", nil, "9am", ""},
+		{"end before start", "", nil, "17:00", "09:00"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := New(tc.timezone, tc.days, tc.start, tc.end); err == nil {
+				t.Errorf("New(%q, %v, %q, %q) succeeded, want error", tc.timezone, tc.days, tc.start, tc.end)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	office, err := New("America/New_York", nil, "09:00", "17:00")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	// Friday 2020-06-05
+	fri := func(h int) time.Time { return time.Date(2020, 6, 5, h, 0, 0, 0, ny) }
+
+	tests := []struct {
+		name string
+		cal  *Calendar
+		t    time.Time
+		want bool
+	}{
+		{"working", office, fri(10), true},
+		{"at opening", office, fri(9), true},
+		{"at closing", office, fri(17), false},
+		{"overnight", office, fri(3), false},
+		{"weekend", office, fri(10).AddDate(0, 0, 1), false},
+		{"other timezone", office, time.Date(2020, 6, 5, 14, 0, 0, 0, time.UTC), true},
+		{"default", Default(), fri(3), true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.cal.Open(tc.t); got != tc.want {
+				t.Errorf("Open(%s) = %v, want %v", tc.t, got, tc.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ const (
 	OpenState   = "open"
 	OpenedState = "opened"
 	ClosedState = "closed"
+	AllState    = "all"
 
 	UpdatedSortOption   = "updated"
 	UpdatedAtSortOption = "updated_at"
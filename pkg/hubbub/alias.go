@@ -0,0 +1,102 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/triage-party/pkg/provider"
+)
+
+// ParseRepoAliases validates a map of previous repository names to current ones, such as "org/old" to "org/new",
+// returning it keyed by the lower-case previous name.
+func ParseRepoAliases(aliases map[string]string) (map[string]string, error) {
+	parsed := map[string]string{}
+	for from, to := range aliases {
+		if err := validRepoName(from); err != nil {
+			return nil, err
+		}
+		if err := validRepoName(to); err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(from, to) {
+			return nil, fmt.Errorf("%q is an alias of itself", from)
+		}
+		parsed[strings.ToLower(from)] = to
+	}
+
+	if err := aliasCycle(parsed); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// aliasCycle returns an error if following aliases from any name leads back to a name already followed
+func aliasCycle(parsed map[string]string) error {
+	froms := []string{}
+	for from := range parsed {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+
+	for _, from := range froms {
+		path := []string{from}
+		seen := map[string]bool{from: true}
+		for name := from; ; {
+			to, ok := parsed[name]
+			if !ok {
+				break
+			}
+			name = strings.ToLower(to)
+			path = append(path, to)
+			if seen[name] {
+				return fmt.Errorf("repo aliases form a cycle: %s", strings.Join(path, " -> "))
+			}
+			seen[name] = true
+		}
+	}
+	return nil
+}
+
+func validRepoName(s string) error {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("%q is not of the form org/project", s)
+	}
+	return nil
+}
+
+// canonicalName returns the current name of a repository, following aliases for repositories which were renamed or
+// moved, so that both names share cache keys and references.
+func (h *Engine) canonicalName(org string, project string) (string, string) {
+	// ParseRepoAliases rejects cycles, but stay bounded regardless
+	for i := 0; i <= len(h.repoAliases); i++ {
+		to, ok := h.repoAliases[strings.ToLower(org+"/"+project)]
+		if !ok {
+			break
+		}
+		parts := strings.SplitN(to, "/", 2)
+		org, project = parts[0], parts[1]
+	}
+	return org, project
+}
+
+// canonicalRepo returns a repository under its current name
+func (h *Engine) canonicalRepo(r provider.Repo) provider.Repo {
+	r.Organization, r.Project = h.canonicalName(r.Organization, r.Project)
+	return r
+}
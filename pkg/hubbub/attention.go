@@ -0,0 +1,70 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/triage-party/pkg/tag"
+)
+
+// recencyHalfLife is how long it takes for the recency component of an attention score to halve
+const recencyHalfLife = 7 * 24 * time.Hour
+
+// AttentionWeights are how much each input contributes to an attention score
+type AttentionWeights struct {
+	// Hold is added per day that the item has been waiting on a project member
+	Hold float64
+	// Reactions is added per reaction per month
+	Reactions float64
+	// Recency is added in full for an item updated just now, halving for every week since
+	Recency float64
+	// RecvQ is added if the item is tagged as recv-q
+	RecvQ float64
+}
+
+// DefaultAttentionWeights are used if no weights are configured
+var DefaultAttentionWeights = AttentionWeights{
+	Hold:      1,
+	Reactions: 1,
+	Recency:   7,
+	RecvQ:     14,
+}
+
+// AttentionScore returns how urgently an item needs attention from a project member: higher is more urgent
+func AttentionScore(co *Conversation, w AttentionWeights) float64 {
+	score := w.Hold * co.CurrentHoldTime.Hours() / 24
+	score += w.Reactions * co.ReactionsPerMonth
+
+	if !co.Updated.IsZero() {
+		since := time.Since(co.Updated)
+		if since < 0 {
+			since = 0
+		}
+		score += w.Recency * math.Pow(0.5, float64(since)/float64(recencyHalfLife))
+	}
+
+	if co.Tags[tag.RecvQ] {
+		score += w.RecvQ
+	}
+	return score
+}
+
+// SortByAttention sorts conversations by descending attention score, keeping ties in their existing order
+func SortByAttention(cs []*Conversation) {
+	sort.SliceStable(cs, func(i, j int) bool { return cs[i].AttentionScore > cs[j].AttentionScore })
+}
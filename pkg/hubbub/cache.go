@@ -3,20 +3,141 @@ package hubbub
 import (
 	"fmt"
 	"github.com/google/triage-party/pkg/provider"
+	"time"
+
+	"k8s.io/klog/v2"
 )
 
+// MaxPerPage is the largest page size which the GitHub API allows
+const MaxPerPage = 100
+
+// NegativeTTL is how long an empty result is cached for an item which the provider reported as not found
+const NegativeTTL = time.Hour
+
+// MergeableStateTTL is how long the mergeable state of a PR is cached. It changes without the PR being updated, such
+// as when its base branch moves, or once GitHub finishes computing it.
+const MergeableStateTTL = 15 * time.Minute
+
+// cachedThing returns the cached thing for a key if it was created after t, treating negative results older than
+// NegativeTTL as a miss
+func (h *Engine) cachedThing(key string, t time.Time) *provider.Thing {
+	x := h.cache.GetNewerThan(key, t)
+	if x != nil && x.Negative && time.Since(x.Created) > NegativeTTL {
+		klog.V(1).Infof("%s negative result from %s has expired", key, x.Created)
+		return nil
+	}
+	return x
+}
+
+// cacheNegative caches an empty result for sp.SearchKey if err is the provider reporting it as not found, such as
+// for a deleted item, so that it is not requested again on every refresh. It returns false for any other error,
+// which should be returned as usual.
+func (h *Engine) cacheNegative(sp provider.SearchParams, err error) bool {
+	if !provider.IsNotFound(err) {
+		return false
+	}
+
+	klog.Warningf("%s was not found, caching an empty result for %s: %v", sp.SearchKey, NegativeTTL, err)
+	if err := h.cache.Set(sp.SearchKey, &provider.Thing{Negative: true}); err != nil {
+		klog.Errorf("set %q failed: %v", sp.SearchKey, err)
+	}
+	return true
+}
+
 // issueSearchKey is the cache key used for issues
 func issueSearchKey(sp provider.SearchParams) string {
 	if sp.UpdateAge > 0 {
-		return fmt.Sprintf("%s-%s-%s-issues-within-%.1fh", sp.Repo.Organization, sp.Repo.Project, sp.State, sp.UpdateAge.Hours())
+		return fmt.Sprintf("%s-%s-%s-issues-within-%.1fh%s", sp.Repo.Organization, sp.Repo.Project, sp.State, sp.UpdateAge.Hours(), pageSuffix(sp))
 	}
-	return fmt.Sprintf("%s-%s-%s-issues", sp.Repo.Organization, sp.Repo.Project, sp.State)
+	return fmt.Sprintf("%s-%s-%s-issues%s", sp.Repo.Organization, sp.Repo.Project, sp.State, pageSuffix(sp))
 }
 
 // prSearchKey is the cache key used for prs
 func prSearchKey(sp provider.SearchParams) string {
 	if sp.UpdateAge > 0 {
-		return fmt.Sprintf("%s-%s-%s-prs-within-%.1fh", sp.Repo.Organization, sp.Repo.Project, sp.State, sp.UpdateAge.Hours())
+		return fmt.Sprintf("%s-%s-%s-prs-within-%.1fh%s", sp.Repo.Organization, sp.Repo.Project, sp.State, sp.UpdateAge.Hours(), pageSuffix(sp))
+	}
+	return fmt.Sprintf("%s-%s-%s-prs%s", sp.Repo.Organization, sp.Repo.Project, sp.State, pageSuffix(sp))
+}
+
+// pageSuffix distinguishes the cache keys of listings capped by MaxPages, as they hold fewer items. The page size
+// alone does not change what is listed, so uncapped listings share a key.
+func pageSuffix(sp provider.SearchParams) string {
+	if sp.MaxPages <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("-first-%dx%d", sp.MaxPages, perPage(sp))
+}
+
+// perPage returns the page size to list items with
+func perPage(sp provider.SearchParams) int {
+	if sp.PerPage > 0 && sp.PerPage < MaxPerPage {
+		return sp.PerPage
+	}
+	return MaxPerPage
+}
+
+// lastPage returns whether no more pages should be listed after page, which counts from 1
+func lastPage(sp provider.SearchParams, resp *provider.Response, page int) bool {
+	if resp.NextPage == 0 {
+		return true
+	}
+	if sp.MaxPages > 0 && page >= sp.MaxPages {
+		klog.Infof("%s: stopping after %d pages of %d items (max_pages)", sp.SearchKey, page, perPage(sp))
+		return true
+	}
+	return false
+}
+
+// itemKeys are the cache keys used for data about a single issue or PR, including the capped timeline and comment
+// listings, and the check state of its head commit if known
+func itemKeys(org string, project string, num int, timelineLimit int, commentLimit int, headSHA string) []string {
+	prefix := fmt.Sprintf("%s-%s-%d", org, project, num)
+	keys := []string{}
+	for _, suffix := range []string{"issue-comments", "timeline", "pr", "pr-comments", "pr-reviews", "reactions", "pr-reactions"} {
+		keys = append(keys, fmt.Sprintf("%s-%s", prefix, suffix))
+	}
+	if timelineLimit > 0 {
+		keys = append(keys, fmt.Sprintf("%s-timeline-last-%d", prefix, timelineLimit))
+	}
+	if commentLimit > 0 {
+		keys = append(keys, fmt.Sprintf("%s-issue-comments-last-%d", prefix, commentLimit))
+	}
+	if headSHA != "" {
+		keys = append(keys, fmt.Sprintf("%s-checks-%s", prefix, headSHA))
+	}
+	return keys
+}
+
+// cachedHeadSHA returns the head commit of a cached PR, or "" if it is not a cached PR
+func (h *Engine) cachedHeadSHA(org string, project string, num int) string {
+	x := h.cache.GetNewerThan(fmt.Sprintf("%s-%s-%d-pr", org, project, num), time.Time{})
+	if x == nil || len(x.PullRequests) == 0 {
+		return ""
+	}
+	return x.PullRequests[0].GetHead().GetSHA()
+}
+
+// InvalidateItem clears cached data for a single issue or PR, so that it is re-fetched on the next refresh
+func (h *Engine) InvalidateItem(org string, project string, num int) {
+	org, project = h.canonicalName(org, project)
+	klog.Infof("invalidating %s/%s #%d", org, project, num)
+	now := time.Now()
+
+	for _, key := range itemKeys(org, project, num, h.maxTimelineEvents, h.commentLimit, h.cachedHeadSHA(org, project, num)) {
+		if err := h.cache.DeleteOlderThan(key, now); err != nil {
+			klog.Errorf("delete %q: %v", key, err)
+		}
+	}
+
+	// Treat the item as updated now, so that any per-item data cached before this point is considered stale
+	h.updateMtimeLong(org, project, num, now)
+
+	h.seenMu.Lock()
+	defer h.seenMu.Unlock()
+	for url, co := range h.seen {
+		if co.Organization == org && co.Project == project && co.ID == num {
+			delete(h.seen, url)
+		}
 	}
-	return fmt.Sprintf("%s-%s-%s-prs", sp.Repo.Organization, sp.Repo.Project, sp.State)
 }
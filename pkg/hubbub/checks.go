@@ -0,0 +1,71 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"k8s.io/klog/v2"
+)
+
+// needChecks returns whether any filter requires the check state of linked PR's
+func needChecks(fs []provider.Filter) bool {
+	for _, f := range fs {
+		if f.LinkedPRChecks != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// cachedCheckState returns the check state for the head commit of a PR, keyed by commit so that pushes are seen
+func (h *Engine) cachedCheckState(ctx context.Context, sp provider.SearchParams) (string, time.Time, error) {
+	sp.SearchKey = fmt.Sprintf("%s-%s-%d-checks-%s", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber, sp.Ref)
+
+	if x := h.cachedThing(sp.SearchKey, sp.NewerThan); x != nil {
+		return x.CheckState, x.Created, nil
+	}
+
+	klog.V(1).Infof("cache miss for %s newer than %s", sp.SearchKey, sp.NewerThan)
+	if !sp.Fetch {
+		return "", time.Time{}, nil
+	}
+	return h.updateCheckState(ctx, sp)
+}
+
+func (h *Engine) updateCheckState(ctx context.Context, sp provider.SearchParams) (string, time.Time, error) {
+	klog.V(1).Infof("Downloading check state for %s/%s #%d at %s", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber, sp.Ref)
+	start := time.Now()
+
+	p := provider.ResolveProviderByHost(sp.Repo.Host)
+	state, resp, err := p.ChecksGetState(ctx, sp)
+	if err != nil {
+		if h.cacheNegative(sp, err) {
+			return "", start, nil
+		}
+		return "", start, err
+	}
+
+	h.logRate(resp.Rate)
+
+	if err := h.cache.Set(sp.SearchKey, &provider.Thing{CheckState: state}); err != nil {
+		klog.Errorf("set %q failed: %v", sp.SearchKey, err)
+	}
+
+	return state, start, nil
+}
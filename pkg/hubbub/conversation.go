@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/google/triage-party/pkg/tag"
+	"k8s.io/klog/v2"
 )
 
 // Issue is a type representing an issue
@@ -30,6 +31,8 @@ const PullRequest = "pull_request"
 // Conversation represents a discussion within a GitHub item (issue or PR)
 type Conversation struct {
 	ID int `json:"id"`
+	// GlobalID is the provider's ID for the item, which is kept when an issue is transferred to another repository
+	GlobalID int64 `json:"global_id"`
 
 	Organization string `json:"organization"`
 	Project      string `json:"project"`
@@ -44,30 +47,83 @@ type Conversation struct {
 	// Latest comment or event
 	Updated time.Time `json:"updated"`
 
+	// StateSince is when the item entered its current state: when it was closed, or when it was created or last reopened
+	StateSince time.Time `json:"state_since"`
+
 	// Seen is the age of the data which generated this data
 	Seen         time.Time `json:"seen"`
 	CommentsSeen int       `json:"comments_seen"`
 
 	// When did this item reach the current priority?
 	Prioritized time.Time `json:"prioritized"`
+	// Most recent time each currently applied label was added, according to the timeline
+	LabeledAt map[string]time.Time `json:"labeled_at"`
+	// When a label was first added by someone other than a bot, and how long after creation (0 if never labeled)
+	FirstLabeled     time.Time     `json:"first_labeled"`
+	TimeToFirstLabel time.Duration `json:"time_to_first_label"`
 
 	SelfInflicted bool `json:"self_inflicted"`
+	// AuthorListedMember is true if the author is counted as a member, although GitHub does not report a member role
+	// for them
+	AuthorListedMember bool `json:"author_listed_member"`
 
 	ReviewState string `json:"review_state"`
 
+	// OutstandingChanges is true if a reviewer requested changes, and the author has not pushed since
+	OutstandingChanges bool `json:"outstanding_changes"`
+
+	// PRBallIn is who a PR is waiting on: author, reviewer, or none (empty for issues). See prBallIn.
+	PRBallIn string `json:"pr_ball_in"`
+
+	// MergeableState is GitHub's detailed mergeability of a PR, such as clean, behind, or blocked
+	MergeableState string `json:"mergeable_state"`
+
+	// ForcePushes is how many times the head branch of a PR was force-pushed
+	ForcePushes int `json:"force_pushes"`
+	// ReopenCount is how many times the item was reopened, by anyone
+	ReopenCount int `json:"reopen_count"`
+
 	LatestAuthorResponse   time.Time `json:"latest_author_response"`
 	LatestAssigneeResponse time.Time `json:"latest_assignee_response"`
 	LatestMemberResponse   time.Time `json:"latest_member_response"`
 
+	// PickedUpAt is when a member was first assigned, by themselves or anyone else
+	PickedUpAt time.Time `json:"picked_up_at"`
+	// Unpicked is true if the item is open, and no member has been assigned to it
+	Unpicked bool `json:"unpicked"`
+	// AbandonedAssignment is true if the item is open, and everyone who was assigned to it has been unassigned
+	AbandonedAssignment bool `json:"abandoned_assignment"`
+	// MemberTouched is true if a member other than the author has commented, been assigned, or acted on the timeline
+	MemberTouched bool `json:"member_touched"`
+
+	// FirstMemberResponse is when a member other than the author first commented, if ever
+	FirstMemberResponse time.Time `json:"first_member_response"`
+	// FirstResponseTime is how long it took for the first member response, or 0 if there has not been one
+	FirstResponseTime time.Duration `json:"first_response_time"`
+
 	AccumulatedHoldTime time.Duration `json:"accumulated_hold_time"`
 	CurrentHoldTime     time.Duration `json:"current_hold_time"`
 
+	// AttentionScore is how urgently the item needs attention, as of the search which returned it. See AttentionScore.
+	AttentionScore float64 `json:"attention_score"`
+
 	Assignees []*provider.User  `json:"assignees"`
 	Labels    []*provider.Label `json:"labels"`
 
+	// LabelConflicts are the exclusive label groups which this item has more than one label from
+	LabelConflicts []string `json:"label_conflicts"`
+
 	ReactionsTotal    int            `json:"reactions_total"`
 	Reactions         map[string]int `json:"reactions"`
 	ReactionsPerMonth float64        `json:"reactions_per_month"`
+	MemberReactions   int            `json:"member_reactions"`
+	// Reactors maps the lower-case login of each user who reacted to the item to their reactions, if fetched
+	Reactors map[string][]string `json:"reactors"`
+	// Most reactions received by a single comment
+	TopCommentReactions int `json:"top_comment_reactions"`
+
+	// Most recent bounty amount posted in a comment
+	Bounty float64 `json:"bounty"`
 
 	Commenters         []*provider.User `json:"commenters"`
 	LastCommentBody    string           `json:"last_comment_body"`
@@ -75,24 +131,102 @@ type Conversation struct {
 	CommentsTotal      int              `json:"comments_total"`
 	CommentersTotal    int              `json:"commenters_total"`
 	CommentersPerMonth float64          `json:"commenters_per_month"`
+	// LastCommentByMember is true if the most recent human comment was left by a project member
+	LastCommentByMember bool `json:"last_comment_by_member"`
+	// LastCommenter is the author of the most recent comment, unlike LastCommentAuthor, which ignores bots
+	LastCommenter *provider.User `json:"last_commenter"`
+	// BotLast is true if the most recent comment was left by a bot
+	BotLast bool `json:"bot_last"`
+
+	// Comments by project members versus everyone else, ignoring bots
+	MemberCommentsTotal    int     `json:"member_comments_total"`
+	NonMemberCommentsTotal int     `json:"non_member_comments_total"`
+	MemberCommentRatio     float64 `json:"member_comment_ratio"`
+
+	// Task list items in the body, and the fraction which are checked (0 if there are none)
+	TasksCompleted int     `json:"tasks_completed"`
+	TasksTotal     int     `json:"tasks_total"`
+	TaskCompletion float64 `json:"task_completion"`
+
+	// The last human to comment, push, or otherwise act on this item
+	LastTouchedBy *provider.User `json:"last_touched_by"`
+	LastTouched   time.Time      `json:"last_touched"`
 
 	ClosedCommentsTotal   int            `json:"closed_comments_total"`
 	ClosedCommentersTotal int            `json:"closed_commenters_total"`
 	ClosedAt              time.Time      `json:"closed_at"`
 	ClosedBy              *provider.User `json:"closed_by"`
 
+	// LockReason is empty if the item was locked without a reason
+	Locked     bool   `json:"locked"`
+	LockReason string `json:"lock_reason"`
+
 	TimelineTotal int `json:"timeline_total"`
 	ReviewsTotal  int `json:"reviews_total"`
 
 	IssueRefs       []*RelatedConversation `json:"issue_refs"`
 	PullRequestRefs []*RelatedConversation `json:"pull_request_refs"`
 
+	// RelatedTruncated is set if references were dropped from IssueRefs or PullRequestRefs by max_related_refs
+	RelatedTruncated bool `json:"related_truncated"`
+
+	// How many items are linked via GitHub's "Development" sidebar, which does not say which
+	DevelopmentLinks int `json:"development_links"`
+
+	// ProjectStatus is the status of the item on each configured project board it is on, by board name. It is only
+	// set if a filter needs it.
+	ProjectStatus map[string]string `json:"project_status,omitempty"`
+
+	// DeadLinks are the external links in the description which no longer exist. It is only set if a filter needs it.
+	DeadLinks []string `json:"dead_links,omitempty"`
+
 	Tags map[tag.Tag]bool `json:"tags"`
 
 	// Similar issues to this one
 	Similar []*RelatedConversation `json:"similar"`
 
 	Milestone *provider.Milestone `json:"milestone"`
+	IssueType string              `json:"issue_type"`
+
+	// When the title was most recently changed, and what it was changed from and to
+	Renamed     time.Time `json:"renamed"`
+	RenamedFrom string    `json:"renamed_from"`
+	RenamedTo   string    `json:"renamed_to"`
+
+	// When the issue was transferred into this repository
+	TransferredAt time.Time `json:"transferred_at"`
+	// The copy of this issue in its previous repository, if both were found
+	TransferredFrom *RelatedConversation `json:"transferred_from"`
+	// The copy of this issue in the repository it was transferred to, if both were found
+	TransferredTo *RelatedConversation `json:"transferred_to"`
+
+	// Author associations seen on this item, by login, for events which do not include one
+	roles map[string]string
+	// Users seen with a member role on this item
+	memberLogins map[string]bool
+	// The body of the item, for the similarity index
+	body string
+}
+
+// StateAge returns how long the item has been in its current state
+func (co *Conversation) StateAge() time.Duration {
+	return time.Since(co.StateSince)
+}
+
+// UntouchedByMembers returns whether no member other than the author has been involved with the item, including
+// reactions if they were fetched
+func (co *Conversation) UntouchedByMembers() bool {
+	return !co.MemberTouched && co.MemberReactions == 0
+}
+
+// touched records a human acting on the conversation. Actions at the same time as the latest are treated as more
+// recent, so timeline events win ties with comments, as they are processed afterwards.
+func (co *Conversation) touched(u *provider.User, t time.Time) {
+	if u == nil || t.Before(co.LastTouched) {
+		return
+	}
+	co.LastTouchedBy = u
+	co.LastTouched = t
 }
 
 // A subset of Conversation for related items (requires less memory than a Conversation)
@@ -111,6 +245,66 @@ type RelatedConversation struct {
 	Updated     time.Time      `json:"updated"`
 	Seen        time.Time      `json:"seen"`
 	ReviewState string         `json:"review_state"`
+
+	// CheckState is the CI state of the head commit of a PR, if it was fetched
+	CheckState string `json:"check_state"`
+
+	SelfInflicted bool `json:"self_inflicted"`
+}
+
+// MergeTransferred removes stale copies of issues which were transferred between repositories, as they share a
+// GlobalID. The copy which was transferred most recently, or otherwise updated most recently, is kept. The two are
+// linked via TransferredFrom on the kept copy, and TransferredTo on the stale copy.
+func MergeTransferred(cs []*Conversation) []*Conversation {
+	latest := map[int64]*Conversation{}
+	for _, co := range cs {
+		if co.Type != Issue || co.GlobalID == 0 {
+			continue
+		}
+
+		ex, ok := latest[co.GlobalID]
+		if !ok || ex.URL == co.URL {
+			latest[co.GlobalID] = co
+			continue
+		}
+
+		newer, older := co, ex
+		if ex.TransferredAt.After(co.TransferredAt) || (ex.TransferredAt.Equal(co.TransferredAt) && ex.Updated.After(co.Updated)) {
+			newer, older = ex, co
+		}
+
+		klog.Infof("%s was transferred to %s", older.URL, newer.URL)
+		newer.TransferredFrom = makeRelated(older)
+		older.TransferredTo = makeRelated(newer)
+		latest[co.GlobalID] = newer
+	}
+
+	merged := []*Conversation{}
+	for _, co := range cs {
+		if co.Type == Issue && co.GlobalID != 0 && latest[co.GlobalID] != co {
+			continue
+		}
+		merged = append(merged, co)
+	}
+	return merged
+}
+
+// DedupByURL removes repeated conversations, such as when a rule lists a repository under both its current and
+// previous name, keeping the first. Conversations are keyed by their HTML URL: numbers are only unique within a
+// repository, so items from different repositories with the same number are kept apart, whereas the URL of an item
+// is the same however it was found.
+func DedupByURL(cs []*Conversation) []*Conversation {
+	seen := map[string]bool{}
+	deduped := []*Conversation{}
+	for _, co := range cs {
+		if seen[co.URL] {
+			klog.V(1).Infof("%s was found more than once", co.URL)
+			continue
+		}
+		seen[co.URL] = true
+		deduped = append(deduped, co)
+	}
+	return deduped
 }
 
 func makeRelated(c *Conversation) *RelatedConversation {
@@ -128,5 +322,7 @@ func makeRelated(c *Conversation) *RelatedConversation {
 		Updated: c.Updated,
 		Tags:    c.Tags,
 		Seen:    c.Seen,
+
+		SelfInflicted: c.SelfInflicted,
 	}
 }
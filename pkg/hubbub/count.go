@@ -0,0 +1,142 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"k8s.io/klog/v2"
+)
+
+// countFields are the filter fields evaluated by preFetchMatch and businessMatch, before comments, timelines,
+// or reviews are fetched. Any other field requires a full search.
+var countFields = map[string]bool{
+	"RawLabel":          true,
+	"RawTag":            true,
+	"RawTitle":          true,
+	"RawMilestone":      true,
+	"RawAuthor":         true,
+	"Team":              true,
+	"AuthorAssociation": true,
+	"RawIssueType":      true,
+	"RawAttachment":     true,
+	"HasAttachment":     true,
+	"Created":           true,
+	"TitleLength":       true,
+	"Updated":           true,
+	"UpdatedBusiness":   true,
+	"UpdatedOffHours":   true,
+	"Closed":            true,
+	"State":             true,
+	"MergeableState":    true,
+	"UnlabeledInfinite": true,
+}
+
+// CountOnly returns whether filters can be evaluated without fetching comments, timelines, or reviews
+func CountOnly(fs []provider.Filter) bool {
+	for _, f := range fs {
+		// The only tag known before fetching events
+		if f.TagRegex() != nil && f.TagRegex().String() != "^assigned$" {
+			return false
+		}
+
+		v := reflect.ValueOf(f)
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			// Unexported fields are compiled from their exported counterparts
+			if field.PkgPath != "" || v.Field(i).IsZero() {
+				continue
+			}
+			if !countFields[field.Name] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// CountAny returns the URLs of issues and PR's matching count-only filters
+func (h *Engine) CountAny(ctx context.Context, sp provider.SearchParams) (map[string]bool, time.Time, error) {
+	is, ts, err := h.CountIssues(ctx, sp)
+	if err != nil {
+		return is, ts, err
+	}
+
+	prs, pts, err := h.CountPullRequests(ctx, sp)
+	if err != nil {
+		return is, ts, err
+	}
+
+	if pts.Before(ts) {
+		ts = pts
+	}
+
+	for url := range prs {
+		is[url] = true
+	}
+	return is, ts, nil
+}
+
+// CountIssues returns the URLs of issues matching count-only filters
+func (h *Engine) CountIssues(ctx context.Context, sp provider.SearchParams) (map[string]bool, time.Time, error) {
+	if !CountOnly(sp.Filters) {
+		return nil, time.Time{}, fmt.Errorf("filters require fetching comments or events: %v", sp.Filters)
+	}
+
+	sp.Repo = h.canonicalRepo(sp.Repo)
+	sp.Filters = openByDefault(sp)
+	is, age := h.issueCandidates(ctx, sp)
+
+	matched := map[string]bool{}
+	for _, i := range is {
+		labels := []*provider.Label{}
+		for _, l := range i.Labels {
+			l := l
+			labels = append(labels, l)
+		}
+
+		if preFetchMatch(i, labels, sp.Filters) && h.businessMatch(i, sp.Filters) {
+			matched[i.GetHTMLURL()] = true
+		}
+	}
+
+	klog.V(1).Infof("%s/%s: %d of %d issues match %v", sp.Repo.Organization, sp.Repo.Project, len(matched), len(is), sp.Filters)
+	return matched, age, nil
+}
+
+// CountPullRequests returns the URLs of PR's matching count-only filters
+func (h *Engine) CountPullRequests(ctx context.Context, sp provider.SearchParams) (map[string]bool, time.Time, error) {
+	if !CountOnly(sp.Filters) {
+		return nil, time.Time{}, fmt.Errorf("filters require fetching comments or events: %v", sp.Filters)
+	}
+
+	sp.Repo = h.canonicalRepo(sp.Repo)
+	sp.Filters = openByDefault(sp)
+	prs, age := h.pullRequestCandidates(ctx, sp)
+
+	matched := map[string]bool{}
+	for _, pr := range prs {
+		if preFetchMatch(pr, pr.Labels, sp.Filters) && h.businessMatch(pr, sp.Filters) {
+			matched[pr.GetHTMLURL()] = true
+		}
+	}
+
+	klog.V(1).Infof("%s/%s: %d of %d PR's match %v", sp.Repo.Organization, sp.Repo.Project, len(matched), len(prs), sp.Filters)
+	return matched, age, nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/triage-party/pkg/provider"
+	"k8s.io/klog/v2"
+)
+
+// Filter stages, in the order that searches evaluate them
+const (
+	StagePreFetch   = "pre-fetch"
+	StagePostFetch  = "post-fetch"
+	StagePostEvents = "post-events"
+)
+
+// DebugTrace explains how a single item is evaluated against a set of filters
+type DebugTrace struct {
+	Organization string `json:"org"`
+	Project      string `json:"project"`
+	Number       int    `json:"number"`
+
+	// Found is false if the item is not a candidate for the search, such as a closed item when the filters do not
+	// require closed items. Nothing else is set if so.
+	Found bool `json:"found"`
+
+	// Matched is true if the item passed every stage, and would be returned by a search
+	Matched bool          `json:"matched"`
+	Stages  []*DebugStage `json:"stages"`
+
+	Conversation *Conversation `json:"conversation"`
+
+	// Raw data, fetched in full regardless of whether the filters require it
+	Issue       *provider.Issue               `json:"issue,omitempty"`
+	PullRequest *provider.PullRequest         `json:"pull_request,omitempty"`
+	Comments    []*provider.Comment           `json:"comments"`
+	Timeline    []*provider.Timeline          `json:"timeline"`
+	Reviews     []*provider.PullRequestReview `json:"reviews,omitempty"`
+}
+
+// DebugStage is the result of each filter at a single stage
+type DebugStage struct {
+	Name    string         `json:"name"`
+	Passed  bool           `json:"passed"`
+	Results []*DebugResult `json:"results"`
+}
+
+// DebugResult is whether a single filter passed
+type DebugResult struct {
+	Filter provider.Filter `json:"filter"`
+	Passed bool            `json:"passed"`
+}
+
+// DebugItem explains why an item within sp.Repo does or does not match sp.Filters. Unlike a search, every stage is
+// evaluated, even if an earlier one failed, and all data is fetched. It evaluates the item with the same stages as a
+// search, and the engine state it shares with them is locked, so it may be called while searches are running.
+func (h *Engine) DebugItem(ctx context.Context, sp provider.SearchParams, number int) (*DebugTrace, error) {
+	sp.Repo = h.canonicalRepo(sp.Repo)
+	sp.Filters = openByDefault(sp)
+	dt := &DebugTrace{
+		Organization: sp.Repo.Organization,
+		Project:      sp.Repo.Project,
+		Number:       number,
+	}
+
+	var boards map[string]map[string]string
+	if needProjects(sp.Filters) {
+		boards = h.projectStatuses(ctx, sp)
+	}
+
+	is, age := h.issueCandidates(ctx, sp)
+	for _, i := range is {
+		if i.GetNumber() == number {
+			dt.Found = true
+			dt.Issue = i
+			ep := evalParams{age: age, mostRecentUpdate: mostRecentUpdate(is), boards: boards, fetchAll: true}
+			dt.setResult(h.evalIssue(ctx, &sp, ep, i, dt.stage))
+			return dt, nil
+		}
+	}
+
+	prs, age := h.pullRequestCandidates(ctx, sp)
+	for _, pr := range prs {
+		if pr.GetNumber() == number {
+			dt.Found = true
+			dt.PullRequest = pr
+			ep := evalParams{age: age, boards: boards, fetchAll: true}
+			dt.setResult(h.evalPullRequest(ctx, &sp, ep, pr, dt.stage))
+			return dt, nil
+		}
+	}
+
+	klog.Infof("debug: %s/%s #%d is not a candidate for %v", sp.Repo.Organization, sp.Repo.Project, number, sp.Filters)
+	return dt, nil
+}
+
+// stage is the stageFunc of a trace: it records the stage, and continues whether or not it passed
+func (dt *DebugTrace) stage(name string, fs []provider.Filter, match func([]provider.Filter) bool) bool {
+	dt.addStage(name, fs, match)
+	return true
+}
+
+// setResult records the outcome of an evaluation
+func (dt *DebugTrace) setResult(co *Conversation, data *itemData) {
+	dt.Conversation = co
+	dt.Comments = data.comments
+	dt.Timeline = data.timeline
+	dt.Reviews = data.reviews
+}
+
+// addStage records the result of each filter at a stage. An item matches if it passes every filter at every stage.
+func (dt *DebugTrace) addStage(name string, fs []provider.Filter, match func([]provider.Filter) bool) {
+	st := &DebugStage{Name: name, Passed: true}
+	for _, f := range fs {
+		ok := match([]provider.Filter{f})
+		st.Results = append(st.Results, &DebugResult{Filter: f, Passed: ok})
+		if !ok {
+			st.Passed = false
+		}
+	}
+
+	dt.Stages = append(dt.Stages, st)
+	dt.Matched = true
+	for _, s := range dt.Stages {
+		if !s.Passed {
+			dt.Matched = false
+		}
+	}
+}
+
+// String returns a one-line summary of the trace
+func (dt *DebugTrace) String() string {
+	if !dt.Found {
+		return fmt.Sprintf("%s/%s #%d: not a candidate", dt.Organization, dt.Project, dt.Number)
+	}
+
+	failed := []string{}
+	for _, s := range dt.Stages {
+		if !s.Passed {
+			failed = append(failed, s.Name)
+		}
+	}
+	if len(failed) == 0 {
+		return fmt.Sprintf("%s/%s #%d: matched", dt.Organization, dt.Project, dt.Number)
+	}
+	return fmt.Sprintf("%s/%s #%d: failed %v", dt.Organization, dt.Project, dt.Number, failed)
+}
@@ -0,0 +1,249 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/tag"
+)
+
+// escalationOps are the comparison operators an escalation condition may use
+var escalationOps = map[string]bool{"==": true, "!=": true, ">": true, ">=": true, "<": true, "<=": true}
+
+// escalationFields are what an escalation condition may compare, and the kind of value each is compared to
+var escalationFields = map[string]string{
+	"state":               "string",
+	"reactions":           "number",
+	"reactions-per-month": "number",
+	"comments":            "number",
+	"commenters":          "number",
+	"assignees":           "number",
+	"hold":                "duration",
+	"age":                 "duration",
+	"updated":             "duration",
+	"tag":                 "tag",
+}
+
+// EscalationCondition compares a conversation field to a value, such as "reactions >= 10"
+type EscalationCondition struct {
+	Field string
+	Op    string
+	Value string
+
+	num float64
+	dur time.Duration
+	tag tag.Tag
+}
+
+// EscalationRule tags a conversation as escalated if every condition in All holds, and either Any is empty or at
+// least one condition within it holds.
+type EscalationRule struct {
+	All []EscalationCondition
+	Any []EscalationCondition
+}
+
+// ParseEscalationCondition parses a condition of the form "<field> <op> <value>"
+func ParseEscalationCondition(s string) (EscalationCondition, error) {
+	parts := strings.Fields(s)
+	if len(parts) != 3 {
+		return EscalationCondition{}, fmt.Errorf("%q is not of the form <field> <op> <value>", s)
+	}
+
+	c := EscalationCondition{Field: parts[0], Op: parts[1], Value: parts[2]}
+	kind, ok := escalationFields[c.Field]
+	if !ok {
+		return c, fmt.Errorf("%q: unknown field %q", s, c.Field)
+	}
+	if !escalationOps[c.Op] {
+		return c, fmt.Errorf("%q: unknown operator %q", s, c.Op)
+	}
+
+	switch kind {
+	case "string", "tag":
+		if c.Op != "==" && c.Op != "!=" {
+			return c, fmt.Errorf("%q: %s may only be compared with == or !=", s, c.Field)
+		}
+	case "number":
+		n, err := strconv.ParseFloat(c.Value, 64)
+		if err != nil {
+			return c, fmt.Errorf("%q: %q is not a number", s, c.Value)
+		}
+		c.num = n
+	case "duration":
+		d, within, over := ParseDuration(c.Value)
+		if d <= 0 || within || over {
+			return c, fmt.Errorf("%q: %q is not a duration", s, c.Value)
+		}
+		c.dur = d
+	}
+
+	if kind == "tag" {
+		for _, t := range tag.All() {
+			if t.ID == c.Value {
+				c.tag = t
+			}
+		}
+		if c.tag.ID == "" {
+			return c, fmt.Errorf("%q: unknown tag %q", s, c.Value)
+		}
+	}
+
+	return c, nil
+}
+
+// ParseEscalationRule parses the conditions of an escalation rule, returning nil if there are none
+func ParseEscalationRule(all []string, any []string) (*EscalationRule, error) {
+	if len(all) == 0 && len(any) == 0 {
+		return nil, nil
+	}
+
+	r := &EscalationRule{}
+	for _, s := range all {
+		c, err := ParseEscalationCondition(s)
+		if err != nil {
+			return nil, fmt.Errorf("all: %w", err)
+		}
+		r.All = append(r.All, c)
+	}
+	for _, s := range any {
+		c, err := ParseEscalationCondition(s)
+		if err != nil {
+			return nil, fmt.Errorf("any: %w", err)
+		}
+		r.Any = append(r.Any, c)
+	}
+	return r, nil
+}
+
+// Match returns whether a conversation is escalated under this rule
+func (r *EscalationRule) Match(co *Conversation) bool {
+	for _, c := range r.All {
+		if !c.Match(co) {
+			return false
+		}
+	}
+
+	if len(r.Any) == 0 {
+		return true
+	}
+	for _, c := range r.Any {
+		if c.Match(co) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns whether a condition holds for a conversation
+func (c EscalationCondition) Match(co *Conversation) bool {
+	switch c.Field {
+	case "state":
+		return (co.State == c.Value) == (c.Op == "==")
+	case "tag":
+		return co.Tags[c.tag] == (c.Op == "==")
+	case "reactions":
+		return compareFloat(float64(co.ReactionsTotal), c.Op, c.num)
+	case "reactions-per-month":
+		return compareFloat(co.ReactionsPerMonth, c.Op, c.num)
+	case "comments":
+		return compareFloat(float64(co.CommentsTotal), c.Op, c.num)
+	case "commenters":
+		return compareFloat(float64(co.CommentersTotal), c.Op, c.num)
+	case "assignees":
+		return compareFloat(float64(len(co.Assignees)), c.Op, c.num)
+	case "hold":
+		return compareFloat(float64(co.CurrentHoldTime), c.Op, float64(c.dur))
+	case "age":
+		return compareFloat(float64(time.Since(co.Created)), c.Op, float64(c.dur))
+	case "updated":
+		return compareFloat(float64(time.Since(co.Updated)), c.Op, float64(c.dur))
+	}
+	return false
+}
+
+func compareFloat(v float64, op string, want float64) bool {
+	switch op {
+	case "==":
+		return v == want
+	case "!=":
+		return v != want
+	case ">":
+		return v > want
+	case ">=":
+		return v >= want
+	case "<":
+		return v < want
+	case "<=":
+		return v <= want
+	}
+	return false
+}
+
+// needs returns whether any condition refers to a tag which needs data, or to a field
+func (r *EscalationRule) needs(need func(tag.Tag) bool, fields ...string) bool {
+	for _, c := range append(append([]EscalationCondition{}, r.All...), r.Any...) {
+		if c.Field == "tag" && need(c.tag) {
+			return true
+		}
+		for _, f := range fields {
+			if c.Field == f {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// escalationUsed returns whether the escalated tag is filtered on or shown. used is the list of tags the collection
+// uses, or nil for all.
+func (h *Engine) escalationUsed(fs []provider.Filter, used []string) bool {
+	if h.escalation == nil {
+		return false
+	}
+	for _, f := range fs {
+		if f.TagRegex() != nil && f.TagRegex().MatchString(tag.Escalated.ID) {
+			return true
+		}
+	}
+	return used == nil || usedTagsNeed(used, func(t tag.Tag) bool { return t == tag.Escalated })
+}
+
+// escalationNeedsTag returns whether the escalation rule refers to a tag which needs data, such as the timeline, and
+// the escalated tag is used
+func (h *Engine) escalationNeedsTag(fs []provider.Filter, used []string, need func(tag.Tag) bool) bool {
+	return h.escalationUsed(fs, used) && h.escalation.needs(need)
+}
+
+// escalationNeedsReactions returns whether the escalation rule compares reactions, and the escalated tag is used
+func (h *Engine) escalationNeedsReactions(fs []provider.Filter, used []string) bool {
+	never := func(tag.Tag) bool { return false }
+	return h.escalationUsed(fs, used) && h.escalation.needs(never, "reactions", "reactions-per-month")
+}
+
+// escalate tags a conversation as escalated if it matches the escalation rule. It is called once enrichment is
+// complete, so that conditions may refer to any tag.
+func (h *Engine) escalate(co *Conversation) {
+	if h.escalation == nil {
+		return
+	}
+	if h.escalation.Match(co) {
+		co.Tags[tag.Escalated] = true
+	}
+}
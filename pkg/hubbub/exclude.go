@@ -0,0 +1,107 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/triage-party/pkg/provider"
+	"k8s.io/klog/v2"
+)
+
+// ParseExclude parses an exclude list entry into the key it matches: an item URL or "org/project#123" matches a
+// single item, and "#123" matches that number in every repository.
+func ParseExclude(s string) (string, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return "", err
+		}
+		// https://github.com/org/project/issues/123 or https://gitlab.com/org/project/-/merge_requests/123
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) < 4 {
+			return "", fmt.Errorf("%q is not an issue or PR URL", s)
+		}
+		return excludeKey(parts[0], parts[1], parts[len(parts)-1])
+	}
+
+	i := strings.LastIndex(s, "#")
+	if i < 0 {
+		return "", fmt.Errorf("%q is not a URL, org/project#number, or #number", s)
+	}
+
+	repo := s[:i]
+	if repo == "" {
+		return excludeKey("", "", s[i+1:])
+	}
+
+	parts := strings.Split(repo, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("%q is not a URL, org/project#number, or #number", s)
+	}
+	return excludeKey(parts[0], parts[1], s[i+1:])
+}
+
+func excludeKey(org string, project string, num string) (string, error) {
+	n, err := strconv.Atoi(num)
+	if err != nil || n <= 0 {
+		return "", fmt.Errorf("invalid number: %q", num)
+	}
+
+	if org == "" {
+		return fmt.Sprintf("#%d", n), nil
+	}
+	return strings.ToLower(fmt.Sprintf("%s/%s#%d", org, project, n)), nil
+}
+
+// SetExclude replaces the list of items dropped from every search, taking effect from the next search. Invalid
+// entries are returned as an error, and nothing is changed.
+func (h *Engine) SetExclude(entries []string) error {
+	exclude := map[string]bool{}
+	for _, e := range entries {
+		k, err := ParseExclude(e)
+		if err != nil {
+			return err
+		}
+		exclude[k] = true
+	}
+
+	h.excludeMu.Lock()
+	defer h.excludeMu.Unlock()
+	h.exclude = exclude
+
+	klog.Infof("excluding %d items from every search: %v", len(exclude), entries)
+	return nil
+}
+
+// excluded returns whether an item within a repository is on the exclude list
+func (h *Engine) excluded(r provider.Repo, i provider.IItem) bool {
+	h.excludeMu.RLock()
+	defer h.excludeMu.RUnlock()
+
+	if len(h.exclude) == 0 {
+		return false
+	}
+
+	if h.exclude[fmt.Sprintf("#%d", i.GetNumber())] {
+		return true
+	}
+	return h.exclude[strings.ToLower(fmt.Sprintf("%s/%s#%d", r.Organization, r.Project, i.GetNumber()))]
+}
@@ -16,10 +16,16 @@
 package hubbub
 
 import (
+	"net/http"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/google/triage-party/pkg/calendar"
 	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/similarity"
 	"k8s.io/klog/v2"
 )
 
@@ -31,18 +37,99 @@ type Config struct {
 	// MinSimilarity is how close two items need to be to each other to be called similar
 	MinSimilarity float64
 
+	// Similarity is the algorithm used to find similar items (default: title similarity, if MinSimilarity is set)
+	Similarity similarity.Similarity
+
 	// The furthest we will query back for information on closed issues
 	MaxClosedUpdateAge time.Duration
 
 	// DebugNumbers is used when you want to debug why a single item is being handled in a certain wait
 	DebugNumbers map[int]bool
 
+	// Exclude lists items to drop from every search, as parsed by ParseExclude. It is the inverse of DebugNumbers.
+	Exclude []string
+
 	// MemberRoles are which roles to consider as members
 	// https://developer.github.com/v4/enum/commentauthorassociation/
 	MemberRoles []string
 
 	// Members are which specific users to consider as members
 	Members []string
+
+	// Bots are logins to treat as bots, in addition to those detected automatically, such as "k8s-ci-robot"
+	Bots []string
+
+	// CollaboratorsAreMembers overrides whether the COLLABORATOR role is considered a member, independently of
+	// MemberRoles. GitHub uses it for anyone with access to the repository, including outside collaborators.
+	// If nil, MemberRoles decides.
+	CollaboratorsAreMembers *bool
+
+	// HoldFromReadyForReview measures PR hold time from when it was last marked ready for review, rather than created
+	HoldFromReadyForReview bool
+
+	// PreferMemberList treats users as members if they are listed in Members, or have been seen with a member role
+	// on the same item or as the author of a search candidate, even if the author association of a particular
+	// comment says otherwise
+	PreferMemberList bool
+
+	// IssuesIncludePRs allows PR's returned by the issues API to appear in issue searches
+	IssuesIncludePRs bool
+
+	// CountPendingReviews includes unsubmitted reviews by the API token owner when calculating review state
+	CountPendingReviews bool
+
+	// MaxTimelineEvents caps how many recent timeline events are fetched for issues (0 for unlimited)
+	MaxTimelineEvents int
+
+	// CommentLimit caps how many recent comments are fetched when filters only need recent ones (0 for unlimited)
+	CommentLimit int
+
+	// MaxRelatedRefs caps how many issue and PR references are resolved for each conversation (0 for unlimited)
+	MaxRelatedRefs int
+
+	// BountyRegex extracts a bounty amount from comments, using the first submatch
+	BountyRegex *regexp.Regexp
+	// BountyAuthor is the login of the bot which posts bounty comments (any author if empty)
+	BountyAuthor string
+
+	// ExclusiveLabels are named groups of labels, such as priorities, of which an item should have at most one
+	ExclusiveLabels map[string]*regexp.Regexp
+
+	// OldAge and AncientAge are how long an item must be open to be tagged as old or ancient (0 to disable)
+	OldAge     time.Duration
+	AncientAge time.Duration
+
+	// FirstResponseSLA is how long a member has to first respond before an item is tagged first-response-breached (0 to disable)
+	FirstResponseSLA time.Duration
+	// FirstResponseIgnoreSelfInflicted skips the first response SLA for items filed by members
+	FirstResponseIgnoreSelfInflicted bool
+
+	// RecvQComments only tags items as recv-q if the question is within this many of the latest comments (0 for any)
+	RecvQComments int
+	// RecvQWithin only tags items as recv-q if the question was asked within this duration (0 for any)
+	RecvQWithin time.Duration
+
+	// RepoAliases maps the previous names of renamed or moved repositories to their current ones, as org/project
+	RepoAliases map[string]string
+
+	// Attention weighs the inputs to each conversation's attention score (DefaultAttentionWeights if nil)
+	Attention *AttentionWeights
+
+	// Escalation tags conversations which match it as escalated (nil to disable)
+	Escalation *EscalationRule
+
+	// Projects are the project boards which project filters may refer to
+	Projects []provider.Board
+
+	// LinkTimeout bounds each check of an external link by the dead-links filter (DefaultLinkTimeout if 0)
+	LinkTimeout time.Duration
+	// MaxLinks is the most external links checked per item (DefaultMaxLinks if 0)
+	MaxLinks int
+	// LinksPerSecond is the most link checks started per second (DefaultLinksPerSecond if 0)
+	LinksPerSecond float64
+
+	// Calendar defines business hours for business time filters (default: Monday to Friday, UTC)
+	Calendar *calendar.Calendar
 }
 
 // Engine is the search engine interface for hubbub
@@ -55,26 +142,103 @@ type Engine struct {
 	// The furthest we will query back for information on closed issues
 	MaxClosedUpdateAge time.Duration
 
+	// The most recent timeline events to fetch for issues
+	maxTimelineEvents int
+
+	// The most recent comments to fetch, if the full history is not needed
+	commentLimit int
+
+	// The most issue and PR references to resolve for each conversation
+	maxRelatedRefs int
+
+	holdFromReadyForReview bool
+	countPendingReviews    bool
+	issuesIncludePRs       bool
+
+	bountyRegex  *regexp.Regexp
+	bountyAuthor string
+
+	exclusiveLabels map[string]*regexp.Regexp
+
+	calendar *calendar.Calendar
+
+	oldAge     time.Duration
+	ancientAge time.Duration
+
+	firstResponseSLA                 time.Duration
+	firstResponseIgnoreSelfInflicted bool
+
+	recvQComments int
+	recvQWithin   time.Duration
+
+	attention AttentionWeights
+
+	escalation *EscalationRule
+
+	projects []provider.Board
+
+	// external link checks, spaced by linkInterval: linkNext is when the next may start, guarded by linkMu
+	linkClient   *http.Client
+	maxLinks     int
+	linkInterval time.Duration
+	linkNext     time.Time
+	linkMu       sync.Mutex
+
+	// previous repository names to current ones, keyed by lower-case org/project
+	repoAliases map[string]string
+
 	debug map[int]bool
 
-	titleToURLs   sync.Map
-	similarTitles sync.Map
+	// items dropped from every search, keyed by ParseExclude, guarded by excludeMu
+	exclude   map[string]bool
+	excludeMu sync.RWMutex
+
+	similarity similarity.Similarity
+	// when the similarity index was last changed, and the title indexed for each URL, guarded by similarityMu
+	similarityUpdated time.Time
+	similarTitles     map[string]string
+	similarityMu      sync.Mutex
 
 	memberRoles map[string]bool
 	members     map[string]bool
 
-	// Workaround because GitHub doesn't update issues if cross-references occur
+	// Logins to treat as bots, lower-cased
+	bots map[string]bool
+
+	preferMemberList bool
+	// authors of search candidates seen with a member role, used if preferMemberList is set
+	knownMembers sync.Map
+
+	// Workaround because GitHub doesn't update issues if cross-references occur, guarded by updatedMu
 	updatedAt map[string]time.Time
+	updatedMu sync.RWMutex
 
-	// indexes used for similarity matching & conversation caching
-	seen map[string]*Conversation
+	// indexes used for similarity matching & conversation caching, guarded by seenMu
+	seen   map[string]*Conversation
+	seenMu sync.RWMutex
 }
 
 // ConversationsTotal returns the number of conversations we've seen so far
 func (e *Engine) ConversationsTotal() int {
+	e.seenMu.RLock()
+	defer e.seenMu.RUnlock()
 	return len(e.seen)
 }
 
+// seenConversation returns the conversation last created for a URL, or nil
+func (e *Engine) seenConversation(url string) *Conversation {
+	e.seenMu.RLock()
+	defer e.seenMu.RUnlock()
+	return e.seen[url]
+}
+
+// setSeen records the conversation created for a URL
+func (e *Engine) setSeen(url string, co *Conversation) {
+	e.seenMu.Lock()
+	defer e.seenMu.Unlock()
+	e.seen[url] = co
+}
+
 func New(cfg Config) *Engine {
 	e := &Engine{
 		cache: cfg.Cache,
@@ -83,10 +247,75 @@ func New(cfg Config) *Engine {
 		seen:               map[string]*Conversation{},
 		MinSimilarity:      cfg.MinSimilarity,
 		debug:              cfg.DebugNumbers,
+		maxTimelineEvents:  cfg.MaxTimelineEvents,
+		commentLimit:       cfg.CommentLimit,
+		maxRelatedRefs:     cfg.MaxRelatedRefs,
+		similarity:         cfg.Similarity,
+
+		holdFromReadyForReview: cfg.HoldFromReadyForReview,
+		countPendingReviews:    cfg.CountPendingReviews,
+		issuesIncludePRs:       cfg.IssuesIncludePRs,
+		preferMemberList:       cfg.PreferMemberList,
+		bountyRegex:            cfg.BountyRegex,
+		exclusiveLabels:        cfg.ExclusiveLabels,
+		bountyAuthor:           cfg.BountyAuthor,
+		calendar:               cfg.Calendar,
+		oldAge:                 cfg.OldAge,
+		ancientAge:             cfg.AncientAge,
+
+		firstResponseSLA:                 cfg.FirstResponseSLA,
+		firstResponseIgnoreSelfInflicted: cfg.FirstResponseIgnoreSelfInflicted,
+
+		recvQComments: cfg.RecvQComments,
+		recvQWithin:   cfg.RecvQWithin,
+
+		attention:  DefaultAttentionWeights,
+		escalation: cfg.Escalation,
+		projects:   cfg.Projects,
 
 		updatedAt:   map[string]time.Time{},
 		memberRoles: map[string]bool{},
 		members:     map[string]bool{},
+		bots:        map[string]bool{},
+	}
+
+	if e.calendar == nil {
+		e.calendar = calendar.Default()
+	}
+
+	if len(cfg.RepoAliases) > 0 {
+		aliases, err := ParseRepoAliases(cfg.RepoAliases)
+		if err != nil {
+			klog.Errorf("repo aliases: %v", err)
+		}
+		e.repoAliases = aliases
+	}
+
+	if cfg.Attention != nil {
+		e.attention = *cfg.Attention
+	}
+
+	timeout := cfg.LinkTimeout
+	if timeout <= 0 {
+		timeout = DefaultLinkTimeout
+	}
+	e.linkClient = newLinkClient(timeout)
+
+	e.maxLinks = cfg.MaxLinks
+	if e.maxLinks <= 0 {
+		e.maxLinks = DefaultMaxLinks
+	}
+
+	rate := cfg.LinksPerSecond
+	if rate <= 0 {
+		rate = DefaultLinksPerSecond
+	}
+	e.linkInterval = time.Duration(float64(time.Second) / rate)
+
+	if len(cfg.Exclude) > 0 {
+		if err := e.SetExclude(cfg.Exclude); err != nil {
+			klog.Errorf("exclude: %v", err)
+		}
 	}
 
 	klog.Infof("considering users as members: %v", cfg.Members)
@@ -94,6 +323,10 @@ func New(cfg Config) *Engine {
 		e.members[user] = true
 	}
 
+	for _, user := range cfg.Bots {
+		e.bots[strings.ToLower(user)] = true
+	}
+
 	klog.Infof("considering roles as members: %v", cfg.MemberRoles)
 	for _, role := range cfg.MemberRoles {
 		e.memberRoles[role] = true
@@ -104,6 +337,19 @@ func New(cfg Config) *Engine {
 		klog.Warningf("No memberships defined, using default: %v", e.memberRoles)
 	}
 
+	if cfg.CollaboratorsAreMembers != nil {
+		if *cfg.CollaboratorsAreMembers {
+			e.memberRoles["collaborator"] = true
+		} else {
+			delete(e.memberRoles, "collaborator")
+		}
+		klog.Infof("collaborators are members: %v, member roles are now: %v", *cfg.CollaboratorsAreMembers, e.memberRoles)
+	}
+
+	if e.similarity == nil && e.MinSimilarity > 0 {
+		e.similarity = similarity.NewTitle(e.MinSimilarity)
+	}
+
 	// This value is typically programmed on the fly, but lets give it a good enough default
 	if e.MaxClosedUpdateAge == 0 {
 		e.MaxClosedUpdateAge = 24 * 3 * time.Hour
@@ -32,7 +32,7 @@ import (
 func (h *Engine) cachedIssues(ctx context.Context, sp provider.SearchParams) ([]*provider.Issue, time.Time, error) {
 	sp.SearchKey = issueSearchKey(sp)
 
-	if x := h.cache.GetNewerThan(sp.SearchKey, sp.NewerThan); x != nil {
+	if x := h.cachedThing(sp.SearchKey, sp.NewerThan); x != nil {
 		// Normally the similarity tables are only updated when fresh data is encountered.
 		if sp.NewerThan.IsZero() {
 			go h.updateSimilarIssues(sp.SearchKey, x.Issues)
@@ -58,17 +58,23 @@ func (h *Engine) updateIssues(ctx context.Context, sp provider.SearchParams) ([]
 	start := time.Now()
 
 	sp.IssueListByRepoOptions = provider.IssueListByRepoOptions{
-		ListOptions: provider.ListOptions{PerPage: 100},
+		ListOptions: provider.ListOptions{PerPage: perPage(sp)},
 		State:       sp.State,
 	}
 
+	// Issues are listed newest first by default, but a capped listing should have the most recently active
+	if sp.MaxPages > 0 {
+		sp.IssueListByRepoOptions.Sort = constants.UpdatedSortOption
+		sp.IssueListByRepoOptions.Direction = constants.DescDirectionOption
+	}
+
 	if sp.UpdateAge != 0 {
 		sp.IssueListByRepoOptions.Since = time.Now().Add(-1 * sp.UpdateAge)
 	}
 
 	var allIssues []*provider.Issue
 
-	for {
+	for page := 1; ; page++ {
 		if sp.UpdateAge == 0 {
 			klog.Infof("Downloading %s issues for %s/%s (page %d)...",
 				sp.State, sp.Repo.Organization, sp.Repo.Project, sp.IssueListByRepoOptions.Page)
@@ -95,18 +101,17 @@ func (h *Engine) updateIssues(ctx context.Context, sp provider.SearchParams) ([]
 
 		h.logRate(resp.Rate)
 
+		// PR's are cached too, and are excluded by issueCandidates unless issuesIncludePRs is set
 		for _, i := range is {
-			if i.IsPullRequest() {
-				continue
+			if !i.IsPullRequest() {
+				h.updateMtime(i, i.GetUpdatedAt())
 			}
-
-			h.updateMtime(i, i.GetUpdatedAt())
 			allIssues = append(allIssues, i)
 		}
 
 		go h.updateSimilarIssues(sp.SearchKey, is)
 
-		if resp.NextPage == 0 {
+		if lastPage(sp, resp, page) {
 			break
 		}
 		sp.IssueListByRepoOptions.Page = resp.NextPage
@@ -123,10 +128,18 @@ func (h *Engine) updateIssues(ctx context.Context, sp provider.SearchParams) ([]
 func (h *Engine) cachedIssueComments(ctx context.Context, sp provider.SearchParams) ([]*provider.IssueComment, time.Time, error) {
 	sp.SearchKey = fmt.Sprintf("%s-%s-%d-issue-comments", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
 
-	if x := h.cache.GetNewerThan(sp.SearchKey, sp.NewerThan); x != nil {
+	if x := h.cachedThing(sp.SearchKey, sp.NewerThan); x != nil {
 		return x.IssueComments, x.Created, nil
 	}
 
+	// The full history satisfies a request for recent comments, but not vice versa
+	if sp.CommentLimit > 0 {
+		sp.SearchKey = fmt.Sprintf("%s-last-%d", sp.SearchKey, sp.CommentLimit)
+		if x := h.cachedThing(sp.SearchKey, sp.NewerThan); x != nil {
+			return x.IssueComments, x.Created, nil
+		}
+	}
+
 	if !sp.Fetch {
 		return nil, time.Time{}, nil
 	}
@@ -162,6 +175,9 @@ func (h *Engine) updateIssueComments(ctx context.Context, sp provider.SearchPara
 		cs, resp, err := pr.IssuesListComments(ctx, sp)
 
 		if err != nil {
+			if h.cacheNegative(sp, err) {
+				return nil, start, nil
+			}
 			return cs, start, err
 		}
 		h.logRate(resp.Rate)
@@ -171,6 +187,22 @@ func (h *Engine) updateIssueComments(ctx context.Context, sp provider.SearchPara
 			break
 		}
 		sp.IssueListCommentsOptions.Page = resp.NextPage
+
+		// The issue comments API only lists oldest-first, so skip ahead to the pages containing the most recent
+		// comments. One extra page is fetched, as the last may be short.
+		if sp.CommentLimit > 0 && resp.LastPage > 0 {
+			perPage := sp.IssueListCommentsOptions.PerPage
+			first := resp.LastPage - (sp.CommentLimit+perPage-1)/perPage
+			if first > sp.IssueListCommentsOptions.Page {
+				klog.V(1).Infof("%s: skipping to comment page %d of %d", sp.SearchKey, first, resp.LastPage)
+				sp.IssueListCommentsOptions.Page = first
+				allComments = nil
+			}
+		}
+	}
+
+	if sp.CommentLimit > 0 && len(allComments) > sp.CommentLimit {
+		allComments = allComments[len(allComments)-sp.CommentLimit:]
 	}
 
 	if err := h.cache.Set(sp.SearchKey, &provider.Thing{IssueComments: allComments}); err != nil {
@@ -188,7 +220,13 @@ func toYAML(v interface{}) string {
 	return strings.Replace(strings.TrimSpace(string(s)), "\n", "; ", -1)
 }
 
+// openByDefault adds a state filter if none is set, using the default state of the search (open unless overridden)
 func openByDefault(sp provider.SearchParams) []provider.Filter {
+	// A search query selects its own state, such as "is:closed"
+	if sp.Query != "" {
+		return sp.Filters
+	}
+
 	found := false
 	for _, f := range sp.Filters {
 		if f.State != "" {
@@ -196,11 +234,13 @@ func openByDefault(sp provider.SearchParams) []provider.Filter {
 		}
 	}
 	if !found {
-		var state string
-		if sp.Repo.Host == constants.GitlabProviderHost {
-			state = constants.OpenedState
-		} else {
-			state = constants.OpenState
+		state := sp.DefaultState
+		if state == "" || state == constants.OpenState {
+			if sp.Repo.Host == constants.GitlabProviderHost {
+				state = constants.OpenedState
+			} else {
+				state = constants.OpenState
+			}
 		}
 		sp.Filters = append(sp.Filters, provider.Filter{State: state})
 	}
@@ -227,11 +267,11 @@ func (h *Engine) createIssueSummary(i *provider.Issue, cs []*provider.IssueComme
 // IssueSummary returns a cached conversation for an issue
 func (h *Engine) IssueSummary(i *provider.Issue, cs []*provider.IssueComment, age time.Time) *Conversation {
 	key := i.GetHTMLURL()
-	cached, ok := h.seen[key]
-	if ok {
+	cached := h.seenConversation(key)
+	if cached != nil {
 		minAge := h.mtime(i)
 		if !cached.Seen.Before(minAge) && cached.CommentsSeen >= len(cs) {
-			return h.seen[key]
+			return cached
 		}
 		if cached.CommentsSeen < len(cs) {
 			klog.V(2).Infof("%s in issue cache, but is missing comments. Live @ %s (%d comments), cached @ %s (%d comments)  ", i.GetHTMLURL(), minAge, len(cs), cached.Seen, cached.CommentsSeen)
@@ -240,11 +280,17 @@ func (h *Engine) IssueSummary(i *provider.Issue, cs []*provider.IssueComment, ag
 		}
 	}
 
-	h.seen[key] = h.createIssueSummary(i, cs, age)
-	return h.seen[key]
+	co := h.createIssueSummary(i, cs, age)
+	h.setSeen(key, co)
+	return co
 }
 
-func isBot(u *provider.User) bool {
+func (h *Engine) isBot(u *provider.User) bool {
+	if h.bots[strings.ToLower(u.GetLogin())] {
+		klog.V(3).Infof("%s is listed as a bot", u.GetLogin())
+		return true
+	}
+
 	if u.GetType() == "bot" {
 		klog.V(3).Infof("%s type=bot", u.GetLogin())
 		return true
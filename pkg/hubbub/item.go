@@ -21,6 +21,7 @@ import (
 	"github.com/google/triage-party/pkg/tag"
 	"k8s.io/klog/v2"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -39,22 +40,39 @@ var (
 	// codeRe matches code
 	codeRe    = regexp.MustCompile("(?s)```.*?```")
 	detailsRe = regexp.MustCompile(`(?s)<details>.*</details>`)
+
+	// attachmentRe matches markdown and HTML images, and files uploaded to GitHub or GitLab
+	attachmentRe = regexp.MustCompile(`!\[[^\]]*\]\([^)]+\)|<img\s|https://(private-)?user-images\.githubusercontent\.com/|https://github\.com/(user-attachments/(assets|files)|[\w.-]+/[\w.-]+/files)/|/uploads/[0-9a-f]{32}/`)
+
+	// replyHeaderRe matches the line email clients add before quoting the message being replied to
+	replyHeaderRe = regexp.MustCompile(`^On .* wrote:$`)
+
+	// taskRe matches task list items at any depth, like "- [x] done" or "  1. [ ] todo", capturing the check mark
+	taskRe = regexp.MustCompile(`(?m)^[ \t]*(?:[-*+]|\d+[.)])[ \t]+\[([ xX])\](?:[ \t]|$)`)
 )
 
 // createConversation creates a conversation from an issue-like
 func (h *Engine) createConversation(i provider.IItem, cs []*provider.Comment, age time.Time) *Conversation {
+	return h.createConversationSince(i, cs, age, i.GetCreatedAt())
+}
+
+// createConversationSince creates a conversation from an issue-like, with hold time accruing after holdStart
+func (h *Engine) createConversationSince(i provider.IItem, cs []*provider.Comment, age time.Time, holdStart time.Time) *Conversation {
 	klog.Infof("creating conversation for #%d with %d/%d comments (age: %s)", i.GetNumber(), len(cs), i.GetComments(), age)
 
+	seen := h.memberLogins(i, cs)
 	authorIsMember := false
-	if h.isMember(i.GetUser().GetLogin(), i.GetAuthorAssociation()) {
+	if h.isMember(i.GetUser().GetLogin(), i.GetAuthorAssociation(), seen) {
 		authorIsMember = true
 	}
 
 	co := &Conversation{
 		ID:            i.GetNumber(),
+		GlobalID:      i.GetID(),
 		URL:           i.GetHTMLURL(),
 		Author:        i.GetUser(),
 		Title:         i.GetTitle(),
+		body:          i.GetBody(),
 		State:         i.GetState(),
 		Type:          Issue,
 		Seen:          age,
@@ -67,24 +85,40 @@ func (h *Engine) createConversation(i provider.IItem, cs []*provider.Comment, ag
 		SelfInflicted:        authorIsMember,
 		LatestAuthorResponse: i.GetCreatedAt(),
 		Milestone:            i.GetMilestone(),
+		IssueType:            i.GetTypeName(),
+		Locked:               i.GetLocked(),
+		LockReason:           i.GetActiveLockReason(),
 		Reactions:            map[string]int{},
 		LastCommentAuthor:    i.GetUser(),
+		LastTouchedBy:        i.GetUser(),
+		LastTouched:          i.GetCreatedAt(),
 		LastCommentBody:      i.GetBody(),
 		Tags:                 map[tag.Tag]bool{},
+		roles:                map[string]string{i.GetUser().GetLogin(): i.GetAuthorAssociation()},
+		memberLogins:         seen,
 	}
 
 	if co.CommentsTotal == 0 {
 		co.CommentsTotal = len(cs)
 	}
 
+	// Reopened items are updated from the timeline by addEvents
+	co.StateSince = co.Created
+	if co.State != constants.OpenState && co.State != constants.OpenedState && !co.ClosedAt.IsZero() {
+		co.StateSince = co.ClosedAt
+	}
+
 	// "https://github.com/kubernetes/minikube/issues/7179",
 	urlParts := strings.Split(i.GetHTMLURL(), "/")
-	co.Organization = urlParts[3]
-	co.Project = urlParts[4]
+	co.Organization, co.Project = h.canonicalName(urlParts[3], urlParts[4])
 	h.parseRefs(i.GetBody(), co, i.GetUpdatedAt())
+	co.TasksCompleted, co.TasksTotal = taskList(i.GetBody())
+	if co.TasksTotal > 0 {
+		co.TaskCompletion = float64(co.TasksCompleted) / float64(co.TasksTotal)
+	}
 
-	if i.GetAssignee() != nil {
-		co.Assignees = append(co.Assignees, i.GetAssignee())
+	co.Assignees = assignees(i)
+	if len(co.Assignees) > 0 {
 		co.Tags[tag.Assigned] = true
 	}
 
@@ -93,6 +127,8 @@ func (h *Engine) createConversation(i provider.IItem, cs []*provider.Comment, ag
 	}
 
 	lastQuestion := time.Time{}
+	// how many comments followed the last question
+	afterQuestion := 0
 	seenCommenters := map[string]bool{}
 	seenClosedCommenters := map[string]bool{}
 	seenMemberComment := false
@@ -101,21 +137,34 @@ func (h *Engine) createConversation(i provider.IItem, cs []*provider.Comment, ag
 		klog.Errorf("debug conversation: %s", formatStruct(co))
 	}
 
-	for _, c := range cs {
+	for n, c := range cs {
 		h.parseRefs(c.Body, co, c.Updated)
 		if h.debug[co.ID] {
 			klog.Errorf("debug conversation comment: %s", formatStruct(c))
 		}
 
+		// Bounties are usually posted by bots, so check before ignoring them
+		if amount, ok := h.parseBounty(c); ok {
+			co.Bounty = amount
+			co.Tags[tag.Bountied] = true
+		}
+
+		co.LastCommenter = c.User
+		co.BotLast = h.isBot(c.User)
+
 		// We don't like their kind around here
-		if isBot(c.User) {
+		if co.BotLast {
 			continue
 		}
 
 		co.LastCommentBody = c.Body
 		co.LastCommentAuthor = c.User
+		co.touched(c.User, c.Created)
 
 		r := c.Reactions
+		if r.GetTotalCount() > co.TopCommentReactions {
+			co.TopCommentReactions = r.GetTotalCount()
+		}
 		if r.GetTotalCount() > 0 {
 			co.ReactionsTotal += r.GetTotalCount()
 			for k, v := range reactions(r) {
@@ -137,35 +186,51 @@ func (h *Engine) createConversation(i provider.IItem, cs []*provider.Comment, ag
 			co.LatestAssigneeResponse = c.Created
 		}
 
-		if h.isMember(c.User.GetLogin(), c.AuthorAssoc) && !isBot(c.User) {
+		if !h.isMember(c.User.GetLogin(), c.AuthorAssoc, seen) {
+			co.NonMemberCommentsTotal++
+			co.LastCommentByMember = false
+		}
+
+		if h.isMember(c.User.GetLogin(), c.AuthorAssoc, seen) && !h.isBot(c.User) {
+			co.MemberCommentsTotal++
+			co.LastCommentByMember = true
 			if !co.LatestMemberResponse.After(co.LatestAuthorResponse) && !authorIsMember {
-				co.AccumulatedHoldTime += c.Created.Sub(co.LatestAuthorResponse)
+				if start := latest(co.LatestAuthorResponse, holdStart); c.Created.After(start) {
+					co.AccumulatedHoldTime += c.Created.Sub(start)
+				}
 			}
 			co.LatestMemberResponse = c.Created
+			if co.FirstMemberResponse.IsZero() && c.User.GetLogin() != i.GetUser().GetLogin() {
+				co.FirstMemberResponse = c.Created
+				co.FirstResponseTime = c.Created.Sub(co.Created)
+			}
 			if !seenMemberComment {
 				co.Tags[tag.Commented] = true
 				seenMemberComment = true
 			}
 		}
 
-		if strings.Contains(c.Body, "?") {
-			for _, line := range strings.Split(c.Body, "\n") {
-				line = strings.TrimSpace(line)
-				if strings.HasPrefix(line, ">") {
-					continue
-				}
-				if strings.Contains(line, "?") {
-					lastQuestion = c.Created
-				}
-			}
+		if hasQuestion(c.Body) {
+			lastQuestion = c.Created
+			afterQuestion = len(cs) - 1 - n
 		}
 
+		co.roles[c.User.GetLogin()] = c.AuthorAssoc
+
 		if !seenCommenters[*c.User.Login] {
 			co.Commenters = append(co.Commenters, c.User)
 			seenCommenters[*c.User.Login] = true
 		}
 	}
 
+	co.MemberTouched = !co.FirstMemberResponse.IsZero()
+	// Assigning requires triage access, so an assignee other than the author means a member was involved
+	for _, a := range co.Assignees {
+		if a.GetLogin() != co.Author.GetLogin() {
+			co.MemberTouched = true
+		}
+	}
+
 	if co.Milestone != nil && co.Milestone.GetState() == "open" {
 		co.Tags[tag.OpenMilestone] = true
 	}
@@ -174,6 +239,13 @@ func (h *Engine) createConversation(i provider.IItem, cs []*provider.Comment, ag
 		co.Tags[tag.AssigneeUpdated] = true
 	}
 
+	// The hold clock stops when an item is closed, so closed items are never currently on hold
+	closed := co.State == constants.ClosedState
+	holdEnd := time.Now()
+	if closed && !co.ClosedAt.IsZero() {
+		holdEnd = co.ClosedAt
+	}
+
 	// Only add these tags if we've seen all the comments
 	if len(cs) >= co.CommentsTotal {
 		if co.LatestMemberResponse.After(co.LatestAuthorResponse) {
@@ -181,13 +253,21 @@ func (h *Engine) createConversation(i provider.IItem, cs []*provider.Comment, ag
 			co.CurrentHoldTime = 0
 		} else if !authorIsMember {
 			co.Tags[tag.Recv] = true
-			co.CurrentHoldTime += time.Since(co.LatestAuthorResponse)
-			co.AccumulatedHoldTime += time.Since(co.LatestAuthorResponse)
+			if start := latest(co.LatestAuthorResponse, holdStart); holdEnd.After(start) {
+				co.AccumulatedHoldTime += holdEnd.Sub(start)
+				if !closed {
+					co.CurrentHoldTime += holdEnd.Sub(start)
+				}
+			}
 		}
 
-		if lastQuestion.After(co.LatestMemberResponse) {
+		if lastQuestion.After(co.LatestMemberResponse) && h.recentQuestion(lastQuestion, afterQuestion) {
 			co.Tags[tag.RecvQ] = true
 		}
+
+		if h.firstResponseBreached(co, holdEnd) {
+			co.Tags[tag.FirstResponseBreached] = true
+		}
 	}
 
 	if len(cs) > 0 {
@@ -206,8 +286,12 @@ func (h *Engine) createConversation(i provider.IItem, cs []*provider.Comment, ag
 		}
 	}
 
-	if co.State == constants.ClosedState {
+	co.AuthorListedMember = h.authorListedMember(i)
+
+	if closed {
 		co.Tags[tag.Closed] = true
+	} else if t, ok := h.ageTag(co.Created); ok {
+		co.Tags[t] = true
 	}
 
 	co.CommentersTotal = len(seenCommenters)
@@ -222,6 +306,12 @@ func (h *Engine) createConversation(i provider.IItem, cs []*provider.Comment, ag
 	co.CommentersPerMonth = float64(co.CommentersTotal) / months
 	co.ReactionsPerMonth = float64(co.ReactionsTotal) / months
 
+	// Avoid dividing by zero: with no outside comments, the ratio is the member comment count
+	co.MemberCommentRatio = float64(co.MemberCommentsTotal)
+	if co.NonMemberCommentsTotal > 0 {
+		co.MemberCommentRatio = float64(co.MemberCommentsTotal) / float64(co.NonMemberCommentsTotal)
+	}
+
 	tagNames := []string{}
 	for k := range co.Tags {
 		tagNames = append(tagNames, k.ID)
@@ -233,8 +323,50 @@ func (h *Engine) createConversation(i provider.IItem, cs []*provider.Comment, ag
 	return co
 }
 
-// Return if a user or role should be considered a member
-func (h *Engine) isMember(user string, role string) bool {
+// parseBounty returns the bounty amount posted in a comment, if any
+func (h *Engine) parseBounty(c *provider.Comment) (float64, bool) {
+	if h.bountyRegex == nil {
+		return 0, false
+	}
+
+	if h.bountyAuthor != "" && !strings.EqualFold(c.User.GetLogin(), h.bountyAuthor) {
+		return 0, false
+	}
+
+	m := h.bountyRegex.FindStringSubmatch(c.Body)
+	if len(m) < 2 {
+		return 0, false
+	}
+
+	amount, err := strconv.ParseFloat(strings.Replace(m[1], ",", "", -1), 64)
+	if err != nil {
+		klog.Warningf("unable to parse bounty amount %q: %v", m[1], err)
+		return 0, false
+	}
+	return amount, true
+}
+
+// latest returns the later of two timestamps
+func latest(a time.Time, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// assignees returns everyone assigned to an item, falling back to the primary assignee if the list is unavailable
+func assignees(i provider.IItem) []*provider.User {
+	if as := i.GetAssignees(); len(as) > 0 {
+		return as
+	}
+	if a := i.GetAssignee(); a != nil {
+		return []*provider.User{a}
+	}
+	return nil
+}
+
+// Return if a user or role should be considered a member. seen are the users seen with a member role on the same item.
+func (h *Engine) isMember(user string, role string, seen map[string]bool) bool {
 	if h.members[user] {
 		return true
 	}
@@ -243,14 +375,126 @@ func (h *Engine) isMember(user string, role string) bool {
 		return true
 	}
 
-	klog.V(1).Infof("%s (%s) is not considered a member: members=%s memberRoles=%s", user, role, h.members, h.memberRoles)
+	// Author associations are per-comment, and may be stale or NONE for members commenting from a different context
+	if h.preferMemberList {
+		if seen[user] {
+			klog.V(1).Infof("%s (%s) was seen as a member on the same item", user, role)
+			return true
+		}
+		if _, ok := h.knownMembers.Load(user); ok {
+			klog.V(1).Infof("%s (%s) was previously seen as a member", user, role)
+			return true
+		}
+	}
+
+	klog.V(1).Infof("%s (%s) is not considered a member: members=%v memberRoles=%v", user, role, h.members, h.memberRoles)
+	return false
+}
+
+// memberLogins returns the users seen with a member role on an item (if set) or its comments. They are found before
+// anyone is judged, so that membership does not depend on the order comments are processed in.
+func (h *Engine) memberLogins(i provider.IItem, cs []*provider.Comment) map[string]bool {
+	seen := map[string]bool{}
+	if i != nil && h.memberRoles[strings.ToLower(i.GetAuthorAssociation())] {
+		seen[i.GetUser().GetLogin()] = true
+	}
+	for _, c := range cs {
+		if h.memberRoles[strings.ToLower(c.AuthorAssoc)] {
+			seen[c.User.GetLogin()] = true
+		}
+	}
+	return seen
+}
+
+// learnMember records a user seen with a member role, if preferMemberList is set. Searches record the authors of
+// every candidate before matching any, so that membership does not depend on the order items are processed in.
+func (h *Engine) learnMember(user string, role string) {
+	if h.preferMemberList && h.memberRoles[strings.ToLower(role)] {
+		h.knownMembers.Store(user, true)
+	}
+}
+
+// userIsMember returns whether a user seen in a timeline event is a member. As events do not include an author
+// association, roles are inferred from the item and its comments.
+func (h *Engine) userIsMember(co *Conversation, u *provider.User) bool {
+	if u == nil || h.isBot(u) {
+		return false
+	}
+	return h.isMember(u.GetLogin(), co.roles[u.GetLogin()], co.memberLogins)
+}
+
+// authorListedMember returns whether the author of an item is counted as a member, by being listed in members or seen
+// authoring another candidate with a member role (if preferMemberList is set), although GitHub does not report a
+// member role for them.
+// GitHub reports the author's current role rather than their role when the item was filed, and does not keep a
+// history of roles, so this cannot tell when someone became a member.
+func (h *Engine) authorListedMember(i provider.IItem) bool {
+	if h.memberRoles[strings.ToLower(i.GetAuthorAssociation())] {
+		return false
+	}
+
+	login := i.GetUser().GetLogin()
+	if h.members[login] {
+		return true
+	}
+
+	if h.preferMemberList {
+		if _, ok := h.knownMembers.Load(login); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasQuestion returns whether a comment asks a question, ignoring quoted text and code blocks
+func hasQuestion(body string) bool {
+	if !strings.Contains(body, "?") {
+		return false
+	}
+
+	inQuote := false
+	inCode := false
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+
+		// Nested quotes (>> or > >) also begin with >
+		if strings.HasPrefix(line, ">") {
+			inQuote = true
+			continue
+		}
+
+		// A quote continues until a blank line ("lazy continuation")
+		if line == "" {
+			inQuote = false
+			continue
+		}
+		if inQuote {
+			continue
+		}
+
+		if replyHeaderRe.MatchString(line) {
+			continue
+		}
+
+		if strings.Contains(line, "?") {
+			return true
+		}
+	}
 	return false
 }
 
 // UpdateIssueRefs updates referenced issues within a conversation, adding it if necessary
 func (co *Conversation) UpdateIssueRefs(rc *RelatedConversation) {
 	for i, ex := range co.IssueRefs {
-		if ex.URL == rc.URL {
+		if refKey(ex) == refKey(rc) {
 			if ex.Seen.After(rc.Seen) {
 				return
 			}
@@ -265,7 +509,7 @@ func (co *Conversation) UpdateIssueRefs(rc *RelatedConversation) {
 // UpdatePullRequestRefs updates referenced PR's within a conversation, adding it if necessary
 func (co *Conversation) UpdatePullRequestRefs(rc *RelatedConversation) {
 	for i, ex := range co.PullRequestRefs {
-		if ex.URL == rc.URL {
+		if refKey(ex) == refKey(rc) {
 			if ex.Seen.After(rc.Seen) {
 				return
 			}
@@ -277,12 +521,65 @@ func (co *Conversation) UpdatePullRequestRefs(rc *RelatedConversation) {
 	co.PullRequestRefs = append(co.PullRequestRefs, rc)
 }
 
+// refLimited returns whether a reference should be dropped because refs already holds as many references as
+// max_related_refs allows, marking the conversation as truncated. References which are already held are never
+// dropped, so that they can still be updated.
+func (h *Engine) refLimited(co *Conversation, refs []*RelatedConversation, org string, project string, id int) bool {
+	if h.maxRelatedRefs <= 0 || len(refs) < h.maxRelatedRefs {
+		return false
+	}
+
+	for _, ex := range refs {
+		if ex.ID == id && ex.Organization == org && ex.Project == project {
+			return false
+		}
+	}
+
+	if !co.RelatedTruncated {
+		klog.V(1).Infof("%s has more than %d references, dropping %s/%s #%d onwards", co.URL, h.maxRelatedRefs, org, project, id)
+	}
+	co.RelatedTruncated = true
+	return true
+}
+
+// stripCode replaces code samples and collapsed details blocks with empty placeholders
+func stripCode(text string) string {
+	text = codeRe.ReplaceAllString(text, "<code></code>")
+	return detailsRe.ReplaceAllString(text, "<details></details>")
+}
+
+// taskList returns how many task list items in a body are checked, and how many there are. Nested items count the
+// same as top-level ones, as in GitHub's progress summary. Examples within code samples are ignored.
+func taskList(body string) (int, int) {
+	done := 0
+	ms := taskRe.FindAllStringSubmatch(stripCode(body), -1)
+	for _, m := range ms {
+		if m[1] != " " {
+			done++
+		}
+	}
+	return done, len(ms)
+}
+
+// hasAttachment returns whether a body includes an image, uploaded file, or collapsed details block, which is
+// typically used for logs. Examples within code samples are ignored. If re is nil, attachmentRe is used.
+func hasAttachment(body string, re *regexp.Regexp) bool {
+	text := stripCode(body)
+	if detailsRe.MatchString(text) {
+		return true
+	}
+
+	if re == nil {
+		re = attachmentRe
+	}
+	return re.MatchString(text)
+}
+
 // parse any references and update mention time
 func (h *Engine) parseRefs(text string, co *Conversation, t time.Time) {
 
 	// remove code samples which mention unrelated issues
-	text = codeRe.ReplaceAllString(text, "<code></code>")
-	text = detailsRe.ReplaceAllString(text, "<details></details>")
+	text = stripCode(text)
 
 	var ms [][]string
 	ms = append(ms, wordRelRefRe.FindAllStringSubmatch(text, -1)...)
@@ -313,18 +610,17 @@ func (h *Engine) parseRefs(text string, co *Conversation, t time.Time) {
 			h.updateMtimeLong(co.Organization, co.Project, i, t)
 		}
 
-		if !seen[fmt.Sprintf("%s/%d", rc.Project, rc.ID)] {
+		if !seen[refKey(rc)] && !h.refLimited(co, co.IssueRefs, rc.Organization, rc.Project, rc.ID) {
 			co.UpdateIssueRefs(rc)
 		}
-		seen[fmt.Sprintf("%s/%d", rc.Project, rc.ID)] = true
+		seen[refKey(rc)] = true
 	}
 
 	for _, m := range absRefRe.FindAllStringSubmatch(text, -1) {
-		org := m[1]
-		project := m[2]
+		org, project := h.canonicalName(m[1], m[2])
 		i, err := strconv.Atoi(m[3])
 		if err != nil {
-			klog.Errorf("unable to parse int from %s: %v", err)
+			klog.Errorf("unable to parse int from %s: %v", m[3], err)
 			continue
 		}
 
@@ -344,9 +640,81 @@ func (h *Engine) parseRefs(text string, co *Conversation, t time.Time) {
 			h.updateMtimeLong(org, project, i, t)
 		}
 
-		if !seen[fmt.Sprintf("%s/%d", rc.Project, rc.ID)] {
+		if !seen[refKey(rc)] && !h.refLimited(co, co.IssueRefs, rc.Organization, rc.Project, rc.ID) {
 			co.UpdateIssueRefs(rc)
 		}
-		seen[fmt.Sprintf("%s/%d", rc.Project, rc.ID)] = true
+		seen[refKey(rc)] = true
+	}
+}
+
+// refKey identifies a referenced item. References parsed from text have no URL, and numbers are only unique within
+// a repository, and project names within an organization, so all three are included.
+func refKey(rc *RelatedConversation) string {
+	return fmt.Sprintf("%s/%s#%d", rc.Organization, rc.Project, rc.ID)
+}
+
+// setLabels sets the labels of a conversation, along with any exclusive label groups they conflict within
+func (h *Engine) setLabels(co *Conversation, labels []*provider.Label) {
+	co.Labels = labels
+	co.LabelConflicts = h.labelConflicts(labels)
+}
+
+// labelConflicts returns the sorted names of exclusive label groups which more than one label belongs to
+func (h *Engine) labelConflicts(labels []*provider.Label) []string {
+	conflicts := []string{}
+	for name, re := range h.exclusiveLabels {
+		seen := 0
+		for _, l := range labels {
+			if re.MatchString(l.GetName()) {
+				seen++
+			}
+		}
+		if seen > 1 {
+			conflicts = append(conflicts, name)
+		}
+	}
+
+	sort.Strings(conflicts)
+	return conflicts
+}
+
+// recentQuestion returns whether a question is recent enough to tag an item as recv-q, given when it was asked and
+// how many comments followed it. Any question counts unless recv_q limits are configured.
+func (h *Engine) recentQuestion(asked time.Time, after int) bool {
+	if h.recvQComments > 0 && after >= h.recvQComments {
+		return false
+	}
+	if h.recvQWithin > 0 && time.Since(asked) > h.recvQWithin {
+		return false
+	}
+	return true
+}
+
+// firstResponseBreached returns whether a member failed to first respond within the SLA. Items which have not yet had
+// a response are measured until end, which is when they were closed, or now.
+func (h *Engine) firstResponseBreached(co *Conversation, end time.Time) bool {
+	if h.firstResponseSLA == 0 {
+		return false
+	}
+
+	if co.SelfInflicted && h.firstResponseIgnoreSelfInflicted {
+		return false
+	}
+
+	if co.FirstMemberResponse.IsZero() {
+		return end.Sub(co.Created) > h.firstResponseSLA
+	}
+	return co.FirstResponseTime > h.firstResponseSLA
+}
+
+// ageTag returns the tag for an open item created at the given time, if it is old enough to have one
+func (h *Engine) ageTag(created time.Time) (tag.Tag, bool) {
+	age := time.Since(created)
+	if h.ancientAge > 0 && age > h.ancientAge {
+		return tag.Ancient, true
+	}
+	if h.oldAge > 0 && age > h.oldAge {
+		return tag.Old, true
 	}
+	return tag.None, false
 }
@@ -0,0 +1,1231 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/tag"
+)
+
+func TestHasQuestion(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{"question", "Which version are you running?", true},
+		{"statement", "Fixed in v1.2.", false},
+		{"quoted question", "> Which version are you running?\n\nv1.2.", false},
+		{"nested quote", ">> Does this work?\n> No\n\nThanks, fixed now.", false},
+		{"spaced nested quote", "> > Does this work?\n\nIt does.", false},
+		{"lazy continuation", "> I tried it.\nDid it work?\n\nYes, it did.", false},
+		{"question after quote", "> I tried it.\n\nDid it work?", true},
+		{
+			"email reply",
+			"Works for me now.\r\n\r\nOn Tue, Jun 2, 2020 at 10:01 AM Jane <notifications@github.com> wrote:\r\n\r\n> Can you try the latest release?\r\n>\r\n> —\r\n> You are receiving this because you were mentioned.",
+			false,
+		},
+		{"code block", "Here is my config:\n```\nx := a ? b : c\n```\n", false},
+		{"question after code", "```\nfoo\n```\nIs this expected?", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := hasQuestion(tc.body); got != tc.want {
+				t.Errorf("hasQuestion(%q) = %v, want %v", tc.body, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAgeTag(t *testing.T) {
+	day := 24 * time.Hour
+	h := &Engine{oldAge: 365 * day, ancientAge: 730 * day}
+
+	tests := []struct {
+		name string
+		age  time.Duration
+		want tag.Tag
+		ok   bool
+	}{
+		{"new", 10 * day, tag.None, false},
+		{"old", 400 * day, tag.Old, true},
+		{"ancient", 800 * day, tag.Ancient, true},
+	}
+	for _, tc := range tests {
+		got, ok := h.ageTag(time.Now().Add(-tc.age))
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("%s: ageTag = %s, %v, want %s, %v", tc.name, got.ID, ok, tc.want.ID, tc.ok)
+		}
+	}
+
+	h = &Engine{ancientAge: 730 * day}
+	if got, ok := h.ageTag(time.Now().Add(-400 * day)); ok {
+		t.Errorf("disabled old: ageTag = %s, want none", got.ID)
+	}
+}
+
+func TestAuthorListedMember(t *testing.T) {
+	h := &Engine{
+		memberRoles: map[string]bool{"member": true},
+		members:     map[string]bool{"listed": true},
+	}
+
+	issue := func(login, assoc string) *provider.Issue {
+		return &provider.Issue{User: &provider.User{Login: &login}, AuthorAssociation: &assoc}
+	}
+	h.knownMembers.Store("elsewhere", true)
+
+	tests := []struct {
+		name   string
+		i      *provider.Issue
+		prefer bool
+		want   bool
+	}{
+		{"member role", issue("listed", "MEMBER"), false, false},
+		{"listed without a member role", issue("listed", "CONTRIBUTOR"), false, true},
+		{"member elsewhere", issue("elsewhere", "NONE"), true, true},
+		{"member elsewhere, member list not preferred", issue("elsewhere", "NONE"), false, false},
+		{"never a member", issue("user", "NONE"), true, false},
+	}
+	for _, tc := range tests {
+		h.preferMemberList = tc.prefer
+		if got := h.authorListedMember(tc.i); got != tc.want {
+			t.Errorf("%s: authorListedMember = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestHoldTimeClosedInRecv(t *testing.T) {
+	h := New(Config{MemberRoles: []string{"member"}})
+
+	day := 24 * time.Hour
+	created := time.Now().Add(-30 * day)
+	closed := created.Add(10 * day)
+
+	issue := func(state string) *provider.Issue {
+		author, assoc, url, comments := "user", "NONE", "https://github.com/org/project/issues/1", 2
+		i := &provider.Issue{
+			User:              &provider.User{Login: &author},
+			AuthorAssociation: &assoc,
+			HTMLURL:           &url,
+			State:             &state,
+			CreatedAt:         &created,
+			UpdatedAt:         &closed,
+			Comments:          &comments,
+		}
+		if state == "closed" {
+			i.ClosedAt = &closed
+		}
+		return i
+	}
+
+	member, author := "maintainer", "user"
+	cs := []*provider.Comment{
+		{User: &provider.User{Login: &member}, AuthorAssoc: "MEMBER", Created: created.Add(1 * day)},
+		{User: &provider.User{Login: &author}, AuthorAssoc: "NONE", Created: created.Add(2 * day)},
+	}
+
+	// Waiting on a member since day 2, until the item was closed on day 10
+	co := h.createConversation(issue("closed"), cs, time.Now())
+	if !co.Tags[tag.Recv] {
+		t.Errorf("closed item is not tagged recv: %v", co.Tags)
+	}
+	if co.CurrentHoldTime != 0 {
+		t.Errorf("closed item CurrentHoldTime = %s, want 0", co.CurrentHoldTime)
+	}
+	if want := 9 * day; co.AccumulatedHoldTime != want {
+		t.Errorf("closed item AccumulatedHoldTime = %s, want %s", co.AccumulatedHoldTime, want)
+	}
+
+	// Still waiting on a member
+	co = h.createConversation(issue("open"), cs, time.Now())
+	if co.CurrentHoldTime < 27*day {
+		t.Errorf("open item CurrentHoldTime = %s, want at least 27 days", co.CurrentHoldTime)
+	}
+	if co.AccumulatedHoldTime < 28*day {
+		t.Errorf("open item AccumulatedHoldTime = %s, want at least 28 days", co.AccumulatedHoldTime)
+	}
+}
+
+func TestMatchLabeledWithin(t *testing.T) {
+	co := &Conversation{LabeledAt: map[string]time.Time{
+		"needs-review": time.Now().Add(-2 * time.Hour),
+		"stale":        time.Now().Add(-72 * time.Hour),
+	}}
+
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"needs-review:24h", true},
+		{"Needs-Review:1h", false},
+		{"stale:24h", false},
+		{"stale:7d", true},
+		{"missing:7d", false},
+		{"needs-review", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			if got := matchLabeledWithin(co, tc.in); got != tc.want {
+				t.Errorf("matchLabeledWithin(%q) = %v, want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCollaboratorsAreMembers(t *testing.T) {
+	yes := true
+	no := false
+
+	tests := []struct {
+		name  string
+		cfg   Config
+		roles map[string]bool
+	}{
+		{"default", Config{}, map[string]bool{"owner": true, "member": true, "collaborator": true, "none": false}},
+		{"excluded", Config{CollaboratorsAreMembers: &no}, map[string]bool{"owner": true, "member": true, "collaborator": false}},
+		{"included", Config{MemberRoles: []string{"owner"}, CollaboratorsAreMembers: &yes}, map[string]bool{"owner": true, "member": false, "collaborator": true}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(tc.cfg)
+			for role, want := range tc.roles {
+				if got := h.isMember("someone", strings.ToUpper(role), nil); got != want {
+					t.Errorf("isMember(%q) = %v, want %v", role, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPreferMemberListOrder(t *testing.T) {
+	created := time.Now().Add(-72 * time.Hour)
+	issue := func(num int, login string) *provider.Issue {
+		url := fmt.Sprintf("https://github.com/org/project/issues/%d", num)
+		assoc := "NONE"
+		return &provider.Issue{Number: &num, HTMLURL: &url, User: &provider.User{Login: &login}, AuthorAssociation: &assoc, CreatedAt: &created}
+	}
+	comment := func(login, assoc string, hours int) *provider.Comment {
+		return &provider.Comment{User: &provider.User{Login: &login}, AuthorAssoc: assoc, Created: created.Add(time.Duration(hours) * time.Hour)}
+	}
+
+	// The member role is only reported on the later comment
+	cs := []*provider.Comment{comment("maintainer", "NONE", 1), comment("maintainer", "MEMBER", 2)}
+	other := []*provider.Comment{comment("maintainer", "NONE", 1)}
+
+	for _, order := range [][]int{{1, 2}, {2, 1}} {
+		h := New(Config{PreferMemberList: true})
+		got := map[int]int{}
+		for _, n := range order {
+			if n == 1 {
+				got[n] = h.createConversation(issue(n, "user"), cs, time.Now()).MemberCommentsTotal
+			} else {
+				got[n] = h.createConversation(issue(n, "user"), other, time.Now()).MemberCommentsTotal
+			}
+		}
+
+		// Roles on one item count for all of its comments, but not for comments on other items
+		if got[1] != 2 || got[2] != 0 {
+			t.Errorf("order %v: member comments = %v, want map[1:2 2:0]", order, got)
+		}
+	}
+
+	h := New(Config{PreferMemberList: true})
+	h.learnMember("lead", "OWNER")
+	if !h.isMember("lead", "NONE", nil) {
+		t.Errorf("isMember(lead) = false after learnMember, want true")
+	}
+}
+
+func TestFirstResponseBreached(t *testing.T) {
+	created := time.Now().Add(-72 * time.Hour)
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		co         *Conversation
+		ignoreSelf bool
+		want       bool
+	}{
+		{"fast response", &Conversation{Created: created, FirstMemberResponse: created.Add(time.Hour), FirstResponseTime: time.Hour}, false, false},
+		{"slow response", &Conversation{Created: created, FirstMemberResponse: created.Add(36 * time.Hour), FirstResponseTime: 36 * time.Hour}, false, true},
+		{"no response", &Conversation{Created: created}, false, true},
+		{"self-inflicted", &Conversation{Created: created, SelfInflicted: true}, false, true},
+		{"self-inflicted ignored", &Conversation{Created: created, SelfInflicted: true}, true, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(Config{FirstResponseSLA: 24 * time.Hour, FirstResponseIgnoreSelfInflicted: tc.ignoreSelf})
+			if got := h.firstResponseBreached(tc.co, now); got != tc.want {
+				t.Errorf("firstResponseBreached() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	if New(Config{}).firstResponseBreached(&Conversation{Created: created}, now) {
+		t.Errorf("firstResponseBreached() = true with no SLA configured")
+	}
+}
+
+func TestHasAttachment(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{"plain", "It crashes when I click the button", false},
+		{"markdown image", "Screenshot:\n![image](https://example.com/a.png)", true},
+		{"html image", `<img width="400" src="https://example.com/a.png">`, true},
+		{"github upload", "See https://github.com/google/triage-party/files/123/log.txt", true},
+		{"github attachment", "https://github.com/user-attachments/assets/0b1c2d3e", true},
+		{"gitlab upload", "[log.txt](/uploads/0123456789abcdef0123456789abcdef/log.txt)", true},
+		{"details", "<details>\n<summary>Logs</summary>\nE0101 failed\n</details>", true},
+		{"code sample", "Use this markdown:\n```\n![image](https://example.com/a.png)\n```", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := hasAttachment(tc.body, nil); got != tc.want {
+				t.Errorf("hasAttachment(%q) = %v, want %v", tc.body, got, tc.want)
+			}
+		})
+	}
+
+	re := regexp.MustCompile(`pastebin\.com`)
+	if !hasAttachment("https://pastebin.com/abc", re) {
+		t.Errorf("hasAttachment with custom regex did not match")
+	}
+}
+
+func TestTaskList(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		done  int
+		total int
+	}{
+		{"none", "Just a description\n- a plain list item", 0, 0},
+		{"flat", "- [x] design\n- [ ] implement\n* [X] review\n+ [ ] release", 2, 4},
+		{"nested", "- [ ] epic\n  - [x] part one\n  - [ ] part two\n    1. [x] step", 2, 4},
+		{"numbered", "1. [x] one\n2) [ ] two", 1, 2},
+		{"code sample", "- [x] real\n```\n- [ ] example\n```", 1, 1},
+		{"details", "- [ ] real\n<details>\n- [x] hidden\n</details>", 0, 1},
+		{"not a task", "- [link](https://example.com)\n-[x] no space\n- [x]done", 0, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			done, total := taskList(tc.body)
+			if done != tc.done || total != tc.total {
+				t.Errorf("taskList(%q) = %d/%d, want %d/%d", tc.body, done, total, tc.done, tc.total)
+			}
+		})
+	}
+
+	co := &Conversation{TasksCompleted: 1, TasksTotal: 4, TaskCompletion: 0.25}
+	if !postFetchMatch(co, []provider.Filter{{TaskCompletion: "<0.5"}}) {
+		t.Errorf("25%% complete did not match <0.5")
+	}
+	if postFetchMatch(&Conversation{}, []provider.Filter{{TaskCompletion: "<0.5"}}) {
+		t.Errorf("item without a task list matched <0.5")
+	}
+}
+
+func TestLabelConflicts(t *testing.T) {
+	h := New(Config{ExclusiveLabels: map[string]*regexp.Regexp{
+		"priority": regexp.MustCompile(`^priority/`),
+		"kind":     regexp.MustCompile(`^kind/`),
+	}})
+
+	labels := func(names ...string) []*provider.Label {
+		ls := []*provider.Label{}
+		for _, n := range names {
+			n := n
+			ls = append(ls, &provider.Label{Name: &n})
+		}
+		return ls
+	}
+
+	tests := []struct {
+		name   string
+		labels []*provider.Label
+		want   []string
+	}{
+		{"none", labels(), []string{}},
+		{"one of each", labels("priority/p1", "kind/bug", "help wanted"), []string{}},
+		{"two priorities", labels("priority/p1", "priority/p2", "kind/bug"), []string{"priority"}},
+		{"both", labels("priority/p1", "priority/p2", "kind/bug", "kind/feature"), []string{"kind", "priority"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := h.labelConflicts(tc.labels)
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("labelConflicts() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMatchReactedBy(t *testing.T) {
+	reaction := func(login string, content string) *provider.Reaction {
+		return &provider.Reaction{User: &provider.User{Login: &login}, Content: &content}
+	}
+	co := &Conversation{Reactors: reactors([]*provider.Reaction{
+		reaction("PM", "+1"),
+		reaction("pm", "eyes"),
+		reaction("someone", "heart"),
+	})}
+
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"pm", true},
+		{"Pm:+1", true},
+		{"pm:eyes", true},
+		{"pm:heart", false},
+		{"someone:heart", true},
+		{"nobody", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			if got := matchReactedBy(co, tc.in); got != tc.want {
+				t.Errorf("matchReactedBy(%q) = %v, want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestForcePushes(t *testing.T) {
+	event := func(s string) *provider.Timeline { return &provider.Timeline{Event: &s} }
+	timeline := []*provider.Timeline{
+		event("committed"),
+		event("head_ref_force_pushed"),
+		event("reviewed"),
+		event("head_ref_force_pushed"),
+		event("base_ref_force_pushed"),
+	}
+
+	co := &Conversation{ForcePushes: forcePushes(timeline)}
+	if co.ForcePushes != 2 {
+		t.Fatalf("forcePushes() = %d, want 2", co.ForcePushes)
+	}
+
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{">1", true},
+		{">2", false},
+		{"2", true},
+		{"<1", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			if got := postEventsMatch(co, []provider.Filter{{ForcePushes: tc.in}}); got != tc.want {
+				t.Errorf("postEventsMatch(force-pushes: %q) = %v, want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMatchRenamedWithin(t *testing.T) {
+	tests := []struct {
+		name    string
+		renamed time.Time
+		in      string
+		want    bool
+	}{
+		{"recent", time.Now().Add(-2 * time.Hour), "24h", true},
+		{"old", time.Now().Add(-72 * time.Hour), "24h", false},
+		{"never", time.Time{}, "3650d", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			co := &Conversation{Renamed: tc.renamed}
+			if got := postEventsMatch(co, []provider.Filter{{RenamedWithin: tc.in}}); got != tc.want {
+				t.Errorf("postEventsMatch(renamed-within: %q) = %v, want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLastTouchedByCommit(t *testing.T) {
+	// As returned by the GitHub timeline API: committed events have no actor or created_at
+	raw := `[
+	{
+		"event": "commented",
+		"actor": {"login": "reviewer", "type": "User"},
+		"created_at": "2020-06-01T10:00:00Z"
+	},
+	{
+		"sha": "7638417db6d59f3c431d3e1f261cc637155684cd",
+		"node_id": "MDY6Q29tbWl0NzYzODQxN2RiNmQ1OWYzYzQzMWQzZTFmMjYxY2M2MzcxNTU2ODRjZA==",
+		"url": "https://api.github.com/repos/octocat/Hello-World/git/commits/7638417db6d59f3c431d3e1f261cc637155684cd",
+		"html_url": "https://github.com/octocat/Hello-World/commit/7638417db6d59f3c431d3e1f261cc637155684cd",
+		"author": {"name": "Monalisa Octocat", "email": "mona@github.com", "date": "2020-06-01T09:00:00Z"},
+		"committer": {"name": "Monalisa Octocat", "email": "mona@github.com", "date": "2020-06-01T11:00:00Z"},
+		"tree": {"sha": "691272480426f78a0138979dd3ce63b77f706feb", "url": "https://api.github.com/repos/octocat/Hello-World/git/trees/691272480426f78a0138979dd3ce63b77f706feb"},
+		"message": "Fix the frobnicator",
+		"parents": [],
+		"verification": {"verified": false, "reason": "unsigned", "signature": null, "payload": null},
+		"event": "committed"
+	}
+	]`
+
+	var timeline []*provider.Timeline
+	if err := json.Unmarshal([]byte(raw), &timeline); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	committed := time.Date(2020, 6, 1, 11, 0, 0, 0, time.UTC)
+	if got := timeline[1].GetCommittedAt(); !got.Equal(committed) {
+		t.Errorf("GetCommittedAt = %s, want %s", got, committed)
+	}
+
+	login := "author"
+	h := &Engine{}
+	for _, tc := range []struct {
+		name string
+		typ  string
+		want string
+	}{
+		{"pull request", PullRequest, "author"},
+		{"issue", Issue, "reviewer"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			co := &Conversation{Type: tc.typ, Author: &provider.User{Login: &login}, Tags: map[tag.Tag]bool{}}
+			h.addEvents(context.Background(), provider.SearchParams{}, co, timeline)
+			if got := co.LastTouchedBy.GetLogin(); got != tc.want {
+				t.Errorf("LastTouchedBy = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPickedUp(t *testing.T) {
+	h := &Engine{
+		memberRoles: map[string]bool{"member": true},
+		members:     map[string]bool{"listed": true},
+	}
+
+	user := func(login string) *provider.User { return &provider.User{Login: &login} }
+	assigned := func(login string, at time.Time) *provider.Timeline {
+		e := "assigned"
+		return &provider.Timeline{Event: &e, Assignee: user(login), Actor: user(login), CreatedAt: &at}
+	}
+
+	start := time.Now().Add(-48 * time.Hour)
+	tests := []struct {
+		name      string
+		state     string
+		assignees []*provider.User
+		timeline  []*provider.Timeline
+		want      time.Time
+		unpicked  bool
+	}{
+		{"never assigned", "open", nil, nil, time.Time{}, true},
+		{"assigned a user", "open", nil, []*provider.Timeline{assigned("user", start)}, time.Time{}, true},
+		{"assigned a commenting member", "open", nil, []*provider.Timeline{assigned("user", start), assigned("commenter", start.Add(time.Hour))}, start.Add(time.Hour), false},
+		{"first member assignment wins", "open", nil, []*provider.Timeline{assigned("listed", start), assigned("commenter", start.Add(time.Hour))}, start, false},
+		{"assigned before the timeline", "open", []*provider.User{user("listed")}, nil, time.Time{}, false},
+		{"closed", "closed", nil, nil, time.Time{}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			co := &Conversation{State: tc.state, Assignees: tc.assignees, Tags: map[tag.Tag]bool{}, roles: map[string]string{"commenter": "MEMBER", "user": "NONE"}}
+			h.addEvents(context.Background(), provider.SearchParams{}, co, tc.timeline)
+			if !co.PickedUpAt.Equal(tc.want) {
+				t.Errorf("PickedUpAt = %s, want %s", co.PickedUpAt, tc.want)
+			}
+			if co.Unpicked != tc.unpicked {
+				t.Errorf("Unpicked = %v, want %v", co.Unpicked, tc.unpicked)
+			}
+		})
+	}
+}
+
+func TestStateSince(t *testing.T) {
+	h := New(Config{MemberRoles: []string{"member"}})
+
+	created := time.Now().Add(-90 * 24 * time.Hour)
+	closed := time.Now().Add(-10 * 24 * time.Hour)
+	reopened := time.Now().Add(-2 * 24 * time.Hour)
+
+	event := func(name string, at time.Time) *provider.Timeline {
+		return &provider.Timeline{Event: &name, CreatedAt: &at}
+	}
+	issue := func(state string, closedAt time.Time) *provider.Issue {
+		login, url := "user", "https://github.com/org/project/issues/1"
+		return &provider.Issue{User: &provider.User{Login: &login}, HTMLURL: &url, State: &state, CreatedAt: &created, ClosedAt: &closedAt}
+	}
+
+	tests := []struct {
+		name     string
+		i        *provider.Issue
+		timeline []*provider.Timeline
+		want     time.Time
+	}{
+		{"open", issue("open", time.Time{}), nil, created},
+		{"reopened", issue("open", time.Time{}), []*provider.Timeline{event("closed", closed), event("reopened", reopened)}, reopened},
+		{"closed", issue("closed", closed), []*provider.Timeline{event("reopened", reopened.Add(-30*24*time.Hour))}, closed},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			co := h.createConversation(tc.i, nil, time.Now())
+			h.addEvents(context.Background(), provider.SearchParams{}, co, tc.timeline)
+			if !co.StateSince.Equal(tc.want) {
+				t.Errorf("StateSince = %s, want %s", co.StateSince, tc.want)
+			}
+		})
+	}
+
+	co := &Conversation{StateSince: reopened}
+	if !postEventsMatch(co, []provider.Filter{{StateAge: "-7d"}}) || postEventsMatch(co, []provider.Filter{{StateAge: "+7d"}}) {
+		t.Errorf("state-age did not match a conversation reopened %s ago", co.StateAge())
+	}
+}
+
+func TestRecvQLimits(t *testing.T) {
+	day := 24 * time.Hour
+	created := time.Now().Add(-30 * day)
+
+	author, member, assoc, url, state := "user", "maintainer", "NONE", "https://github.com/org/project/issues/1", "open"
+	comments := 4
+	i := &provider.Issue{
+		User:              &provider.User{Login: &author},
+		AuthorAssociation: &assoc,
+		HTMLURL:           &url,
+		State:             &state,
+		CreatedAt:         &created,
+		Comments:          &comments,
+	}
+
+	// A question 20 days ago, followed by three comments without one
+	cs := []*provider.Comment{
+		{User: &provider.User{Login: &member}, AuthorAssoc: "MEMBER", Body: "Thanks", Created: created.Add(1 * day)},
+		{User: &provider.User{Login: &author}, AuthorAssoc: "NONE", Body: "Any update?", Created: created.Add(10 * day)},
+		{User: &provider.User{Login: &author}, AuthorAssoc: "NONE", Body: "Still broken.", Created: created.Add(11 * day)},
+		{User: &provider.User{Login: &author}, AuthorAssoc: "NONE", Body: "Here are logs.", Created: created.Add(12 * day)},
+	}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want bool
+	}{
+		{"default", Config{}, true},
+		{"within comments", Config{RecvQComments: 3}, true},
+		{"beyond comments", Config{RecvQComments: 2}, false},
+		{"within window", Config{RecvQWithin: 30 * day}, true},
+		{"beyond window", Config{RecvQWithin: 7 * day}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.MemberRoles = []string{"member"}
+			co := New(tc.cfg).createConversation(i, cs, time.Now())
+			if co.Tags[tag.RecvQ] != tc.want {
+				t.Errorf("recv-q = %v, want %v", co.Tags[tag.RecvQ], tc.want)
+			}
+		})
+	}
+}
+
+func TestMatchMergeableState(t *testing.T) {
+	pr := func(state string) *provider.PullRequest {
+		if state == "" {
+			return &provider.PullRequest{}
+		}
+		return &provider.PullRequest{MergeableState: &state}
+	}
+
+	tests := []struct {
+		state  string
+		filter string
+		want   bool
+	}{
+		{"behind", "behind", true},
+		{"Behind", "behind,dirty", true},
+		{"clean", "behind, dirty", false},
+		{"", "clean", false},
+		{"", "unknown", true},
+		{"unknown", "clean,unknown", true},
+	}
+
+	for _, tc := range tests {
+		if got := preFetchMatch(pr(tc.state), nil, []provider.Filter{{MergeableState: tc.filter}}); got != tc.want {
+			t.Errorf("mergeable state %q vs %q = %v, want %v", tc.state, tc.filter, got, tc.want)
+		}
+	}
+
+	if preFetchMatch(&provider.Issue{}, nil, []provider.Filter{{MergeableState: "unknown"}}) {
+		t.Errorf("issue matched mergeable-state")
+	}
+}
+
+func TestAbandonedAssignment(t *testing.T) {
+	h := &Engine{memberRoles: map[string]bool{"member": true}}
+
+	login := "user"
+	event := func(name string) *provider.Timeline {
+		at := time.Now()
+		return &provider.Timeline{Event: &name, Assignee: &provider.User{Login: &login}, CreatedAt: &at}
+	}
+
+	tests := []struct {
+		name      string
+		state     string
+		assignees []*provider.User
+		timeline  []*provider.Timeline
+		want      bool
+	}{
+		{"never assigned", "open", nil, nil, false},
+		{"still assigned", "open", []*provider.User{{Login: &login}}, []*provider.Timeline{event("assigned")}, false},
+		{"reassigned", "open", []*provider.User{{Login: &login}}, []*provider.Timeline{event("assigned"), event("unassigned"), event("assigned")}, false},
+		{"unassigned", "open", nil, []*provider.Timeline{event("assigned"), event("unassigned")}, true},
+		{"closed", "closed", nil, []*provider.Timeline{event("assigned"), event("unassigned")}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			co := &Conversation{State: tc.state, Assignees: tc.assignees, Tags: map[tag.Tag]bool{}}
+			h.addEvents(context.Background(), provider.SearchParams{}, co, tc.timeline)
+			if co.AbandonedAssignment != tc.want {
+				t.Errorf("AbandonedAssignment = %v, want %v", co.AbandonedAssignment, tc.want)
+			}
+			if got := postEventsMatch(co, []provider.Filter{{AbandonedAssignment: &tc.want}}); !got {
+				t.Errorf("abandoned-assignment: %v did not match", tc.want)
+			}
+		})
+	}
+}
+
+func TestAttentionScore(t *testing.T) {
+	day := 24 * time.Hour
+	w := AttentionWeights{Hold: 1, Reactions: 2, Recency: 8, RecvQ: 10}
+
+	tests := []struct {
+		name string
+		co   *Conversation
+		want float64
+	}{
+		{"empty", &Conversation{}, 0},
+		{"hold", &Conversation{CurrentHoldTime: 3 * day}, 3},
+		{"reactions", &Conversation{ReactionsPerMonth: 1.5}, 3},
+		{"updated two weeks ago", &Conversation{Updated: time.Now().Add(-14 * day)}, 2},
+		{"recv-q", &Conversation{Tags: map[tag.Tag]bool{tag.RecvQ: true}}, 10},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := AttentionScore(tc.co, w)
+			if got < tc.want-0.01 || got > tc.want+0.01 {
+				t.Errorf("AttentionScore() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	cs := []*Conversation{{ID: 1, AttentionScore: 1}, {ID: 2, AttentionScore: 5}, {ID: 3, AttentionScore: 1}}
+	SortByAttention(cs)
+	got := []int{cs[0].ID, cs[1].ID, cs[2].ID}
+	if got[0] != 2 || got[1] != 1 || got[2] != 3 {
+		t.Errorf("SortByAttention() = %v, want [2 1 3]", got)
+	}
+}
+
+func TestUntouchedByMembers(t *testing.T) {
+	h := &Engine{memberRoles: map[string]bool{"member": true}, members: map[string]bool{"maintainer": true}}
+
+	author, maintainer, bystander := "user", "maintainer", "bystander"
+	event := func(name string, actor string) *provider.Timeline {
+		at := time.Now()
+		return &provider.Timeline{Event: &name, Actor: &provider.User{Login: &actor}, CreatedAt: &at}
+	}
+
+	tests := []struct {
+		name     string
+		co       *Conversation
+		timeline []*provider.Timeline
+		want     bool
+	}{
+		{"nothing", &Conversation{}, nil, true},
+		{"author labeled", &Conversation{}, []*provider.Timeline{event("labeled", author)}, true},
+		{"bystander referenced", &Conversation{}, []*provider.Timeline{event("referenced", bystander)}, true},
+		{"labeled", &Conversation{}, []*provider.Timeline{event("labeled", bystander)}, false},
+		{"member referenced", &Conversation{}, []*provider.Timeline{event("referenced", maintainer)}, false},
+		{"member commented", &Conversation{MemberTouched: true}, nil, false},
+		{"member reacted", &Conversation{MemberReactions: 1}, nil, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			co := tc.co
+			co.State = "open"
+			co.Author = &provider.User{Login: &author}
+			co.Tags = map[tag.Tag]bool{}
+			h.addEvents(context.Background(), provider.SearchParams{}, co, tc.timeline)
+			if co.UntouchedByMembers() != tc.want {
+				t.Errorf("UntouchedByMembers() = %v, want %v", co.UntouchedByMembers(), tc.want)
+			}
+			if got := postEventsMatch(co, []provider.Filter{{UntouchedByMembers: &tc.want}}); !got {
+				t.Errorf("untouched-by-members: %v did not match", tc.want)
+			}
+		})
+	}
+}
+
+func TestLastComment(t *testing.T) {
+	created := time.Now().Add(-24 * time.Hour)
+	author, member, bot, assoc, url, state := "user", "maintainer", "ci-bot", "NONE", "https://github.com/org/project/issues/1", "open"
+	comments := 3
+	i := &provider.Issue{
+		User:              &provider.User{Login: &author},
+		AuthorAssociation: &assoc,
+		HTMLURL:           &url,
+		State:             &state,
+		CreatedAt:         &created,
+		Comments:          &comments,
+	}
+
+	cs := []*provider.Comment{
+		{User: &provider.User{Login: &author}, AuthorAssoc: "NONE", Body: "Any update?", Created: created.Add(time.Hour)},
+		{User: &provider.User{Login: &member}, AuthorAssoc: "MEMBER", Body: "Waiting on upstream.", Created: created.Add(2 * time.Hour)},
+		{User: &provider.User{Login: &bot}, AuthorAssoc: "NONE", Body: "Build passed", Created: created.Add(3 * time.Hour)},
+	}
+
+	h := New(Config{MemberRoles: []string{"member"}})
+	co := h.createConversation(i, cs, time.Now())
+	empty := h.createConversation(i, nil, time.Now())
+
+	yes, no := true, false
+	tests := []struct {
+		name string
+		f    provider.Filter
+		co   *Conversation
+		want bool
+	}{
+		{"match", provider.Filter{RawLastComment: "(?i)waiting on upstream"}, co, true},
+		{"case", provider.Filter{RawLastComment: "waiting on upstream"}, co, false},
+		{"bot ignored", provider.Filter{RawLastComment: "passed"}, co, false},
+		{"negated", provider.Filter{RawLastComment: "!merging on green"}, co, true},
+		{"no comments", provider.Filter{RawLastComment: "upstream"}, empty, false},
+		{"no comments negated", provider.Filter{RawLastComment: "!upstream"}, empty, true},
+		{"by member", provider.Filter{LastCommentByMember: &yes}, co, true},
+		{"not by member", provider.Filter{LastCommentByMember: &no}, co, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.f.RawLastComment != "" {
+				if err := tc.f.LoadLastCommentRegex(); err != nil {
+					t.Fatalf("LoadLastCommentRegex: %v", err)
+				}
+			}
+			if got := postFetchMatch(tc.co, []provider.Filter{tc.f}); got != tc.want {
+				t.Errorf("postFetchMatch(%q) = %v, want %v", tc.co.LastCommentBody, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMatchMilestoneOverdue(t *testing.T) {
+	open, closed := "open", "closed"
+	past, future := time.Now().Add(-48*time.Hour), time.Now().Add(48*time.Hour)
+
+	tests := []struct {
+		name string
+		m    *provider.Milestone
+		want bool
+	}{
+		{"no milestone", nil, false},
+		{"no due date", &provider.Milestone{State: &open}, false},
+		{"due later", &provider.Milestone{State: &open, DueOn: &future}, false},
+		{"overdue", &provider.Milestone{State: &open, DueOn: &past}, true},
+		{"closed", &provider.Milestone{State: &closed, DueOn: &past}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			co := &Conversation{Milestone: tc.m}
+			if got := postFetchMatch(co, []provider.Filter{{MilestoneOverdue: &tc.want}}); !got {
+				t.Errorf("milestone-overdue: %v did not match", tc.want)
+			}
+		})
+	}
+}
+
+func TestReopenCount(t *testing.T) {
+	h := &Engine{}
+	event := func(name string) *provider.Timeline {
+		at := time.Now()
+		return &provider.Timeline{Event: &name, CreatedAt: &at}
+	}
+
+	co := &Conversation{State: "open", Tags: map[tag.Tag]bool{}}
+	h.addEvents(context.Background(), provider.SearchParams{}, co, []*provider.Timeline{
+		event("closed"), event("reopened"), event("labeled"), event("closed"), event("reopened"), event("closed"), event("reopened"),
+	})
+	if co.ReopenCount != 3 {
+		t.Fatalf("ReopenCount = %d, want 3", co.ReopenCount)
+	}
+
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{">2", true},
+		{">3", false},
+		{"3", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			if got := postEventsMatch(co, []provider.Filter{{ReopenCount: tc.in}}); got != tc.want {
+				t.Errorf("postEventsMatch(reopen-count: %q) = %v, want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMatchTitleLength(t *testing.T) {
+	title := "crash: panic: runtime error: index out of range [3] with length 3"
+	i := &provider.Issue{Title: &title}
+
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{">60", true},
+		{">70", false},
+		{"<10", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			if got := preFetchMatch(i, nil, []provider.Filter{{TitleLength: tc.in}}); got != tc.want {
+				t.Errorf("preFetchMatch(title-length: %q) = %v, want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+
+	// Characters rather than bytes
+	accented := "éééé"
+	if !preFetchMatch(&provider.Issue{Title: &accented}, nil, []provider.Filter{{TitleLength: "4"}}) {
+		t.Errorf("title-length of %q did not match 4", accented)
+	}
+}
+
+func TestMaxRelatedRefs(t *testing.T) {
+	h := New(Config{MemberRoles: []string{"member"}, MaxRelatedRefs: 2})
+
+	xref := func(n int) *provider.Timeline {
+		event := "cross-referenced"
+		url := fmt.Sprintf("https://github.com/org/other/issues/%d", n)
+		at := time.Now()
+		return &provider.Timeline{Event: &event, CreatedAt: &at, Source: &provider.Source{Issue: &provider.Issue{Number: &n, HTMLURL: &url}}}
+	}
+
+	co := &Conversation{ID: 1, Organization: "org", Project: "project", URL: "https://github.com/org/project/issues/1", State: "open", Tags: map[tag.Tag]bool{}}
+	h.addEvents(context.Background(), provider.SearchParams{}, co, []*provider.Timeline{xref(10), xref(11), xref(12), xref(10)})
+
+	got := []int{}
+	for _, ref := range co.IssueRefs {
+		got = append(got, ref.ID)
+	}
+	if len(got) != 2 || got[0] != 10 || got[1] != 11 {
+		t.Errorf("IssueRefs = %v, want [10 11]", got)
+	}
+	if !co.RelatedTruncated {
+		t.Errorf("RelatedTruncated = false, want true")
+	}
+
+	co = &Conversation{ID: 2, Organization: "org", Project: "project", URL: "https://github.com/org/project/issues/2", State: "open", Tags: map[tag.Tag]bool{}}
+	h.addEvents(context.Background(), provider.SearchParams{}, co, []*provider.Timeline{xref(10), xref(11), xref(10)})
+	if len(co.IssueRefs) != 2 || co.RelatedTruncated {
+		t.Errorf("within the limit: %d refs, truncated=%v, want 2 refs, not truncated", len(co.IssueRefs), co.RelatedTruncated)
+	}
+}
+
+func TestMatchAssociationHold(t *testing.T) {
+	assoc := func(a string) *provider.Issue { return &provider.Issue{AuthorAssociation: &a} }
+
+	tests := []struct {
+		name   string
+		i      *provider.Issue
+		filter string
+		want   bool
+	}{
+		{"listed", assoc("NONE"), "none,first_timer", true},
+		{"unlisted", assoc("MEMBER"), "NONE, FIRST_TIMER", false},
+		{"negated", assoc("MEMBER"), "!NONE", true},
+		{"gitlab", &provider.Issue{}, "NONE", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := preFetchMatch(tc.i, nil, []provider.Filter{{AuthorAssociation: tc.filter}}); got != tc.want {
+				t.Errorf("preFetchMatch(author-association: %q) = %v, want %v", tc.filter, got, tc.want)
+			}
+		})
+	}
+
+	co := &Conversation{CurrentHoldTime: 6 * time.Hour}
+	for hold, want := range map[string]bool{"+4h": true, ">4h": true, "+2d": false, "-1d": true} {
+		if got := postFetchMatch(co, []provider.Filter{{Hold: hold}}); got != want {
+			t.Errorf("postFetchMatch(hold: %q) with 6h on hold = %v, want %v", hold, got, want)
+		}
+	}
+}
+
+func TestBotLast(t *testing.T) {
+	created := time.Now().Add(-24 * time.Hour)
+	author, member, bot, listed, url, state := "user", "maintainer", "ci-bot", "Stale-Checker", "https://github.com/org/project/issues/1", "open"
+	i := &provider.Issue{User: &provider.User{Login: &author}, HTMLURL: &url, State: &state, CreatedAt: &created}
+	comment := func(login string, assoc string, n int) *provider.Comment {
+		return &provider.Comment{User: &provider.User{Login: &login}, AuthorAssoc: assoc, Created: created.Add(time.Duration(n) * time.Hour)}
+	}
+
+	h := New(Config{MemberRoles: []string{"member"}, Bots: []string{"stale-checker"}})
+
+	tests := []struct {
+		name string
+		cs   []*provider.Comment
+		want bool
+	}{
+		{"no comments", nil, false},
+		{"human last", []*provider.Comment{comment(bot, "NONE", 1), comment(member, "MEMBER", 2)}, false},
+		{"detected bot last", []*provider.Comment{comment(author, "NONE", 1), comment(member, "MEMBER", 2), comment(bot, "NONE", 3)}, true},
+		{"listed bot last", []*provider.Comment{comment(author, "NONE", 1), comment(listed, "NONE", 2)}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			co := h.createConversation(i, tc.cs, time.Now())
+			if co.BotLast != tc.want {
+				t.Errorf("BotLast = %v, want %v", co.BotLast, tc.want)
+			}
+			if got := postFetchMatch(co, []provider.Filter{{BotLast: &tc.want}}); !got {
+				t.Errorf("bot-last: %v did not match", tc.want)
+			}
+			if len(tc.cs) > 0 && co.LastCommenter.GetLogin() != tc.cs[len(tc.cs)-1].User.GetLogin() {
+				t.Errorf("LastCommenter = %s, want %s", co.LastCommenter.GetLogin(), tc.cs[len(tc.cs)-1].User.GetLogin())
+			}
+		})
+	}
+
+	// The human last comment author is unaffected
+	co := h.createConversation(i, []*provider.Comment{comment(member, "MEMBER", 1), comment(listed, "NONE", 2)}, time.Now())
+	if co.LastCommentAuthor.GetLogin() != member || !co.Tags[tag.Send] {
+		t.Errorf("LastCommentAuthor = %s, send=%v, want %s and send", co.LastCommentAuthor.GetLogin(), co.Tags[tag.Send], member)
+	}
+}
+
+func TestEscalate(t *testing.T) {
+	r, err := ParseEscalationRule([]string{"state == open", "reactions >= 10", "assignees == 0"}, []string{"tag == recv-q", "hold > 7d"})
+	if err != nil {
+		t.Fatalf("ParseEscalationRule: %v", err)
+	}
+	h := &Engine{escalation: r}
+
+	tests := []struct {
+		name      string
+		state     string
+		reactions int
+		assignees int
+		recvQ     bool
+		hold      time.Duration
+		want      bool
+	}{
+		{"recv-q", "open", 12, 0, true, 0, true},
+		{"long hold", "open", 10, 0, false, 8 * 24 * time.Hour, true},
+		{"neither", "open", 12, 0, false, 24 * time.Hour, false},
+		{"closed", "closed", 12, 0, true, 0, false},
+		{"few reactions", "open", 3, 0, true, 0, false},
+		{"assigned", "open", 12, 1, true, 0, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			co := &Conversation{State: tc.state, ReactionsTotal: tc.reactions, CurrentHoldTime: tc.hold, Tags: map[tag.Tag]bool{}}
+			for i := 0; i < tc.assignees; i++ {
+				co.Assignees = append(co.Assignees, &provider.User{})
+			}
+			if tc.recvQ {
+				co.Tags[tag.RecvQ] = true
+			}
+			h.escalate(co)
+			if co.Tags[tag.Escalated] != tc.want {
+				t.Errorf("escalated = %v, want %v", co.Tags[tag.Escalated], tc.want)
+			}
+		})
+	}
+
+	for _, s := range []string{"reactions >= lots", "state > open", "color == red", "tag == nope", "hold > soon", "reactions =~ 3", "reactions>=3"} {
+		if _, err := ParseEscalationCondition(s); err == nil {
+			t.Errorf("ParseEscalationCondition(%q) = nil error, want error", s)
+		}
+	}
+}
+
+func TestEscalationNeeds(t *testing.T) {
+	timeline := func(t tag.Tag) bool { return t.NeedsTimeline }
+	tagFilter := func(re string) provider.Filter {
+		f := provider.Filter{RawTag: re}
+		if err := f.LoadTagRegex(); err != nil {
+			t.Fatalf("load tag regex: %v", err)
+		}
+		return f
+	}
+
+	r, err := ParseEscalationRule([]string{"reactions >= 10"}, []string{"tag == pr-approved"})
+	if err != nil {
+		t.Fatalf("ParseEscalationRule: %v", err)
+	}
+	h := &Engine{escalation: r}
+
+	if !h.escalationNeedsTag(nil, nil, timeline) || !h.escalationNeedsReactions(nil, nil) {
+		t.Errorf("escalation with all tags shown does not need the timeline and reactions")
+	}
+	if h.escalationNeedsTag(nil, []string{"recv"}, timeline) || h.escalationNeedsReactions(nil, []string{"recv"}) {
+		t.Errorf("escalation needs data although the escalated tag is not used")
+	}
+	if !h.escalationNeedsTag([]provider.Filter{tagFilter("escalated")}, []string{}, timeline) {
+		t.Errorf("escalation filter does not need the timeline")
+	}
+
+	r, err = ParseEscalationRule([]string{"state == open", "tag == recv-q"}, nil)
+	if err != nil {
+		t.Fatalf("ParseEscalationRule: %v", err)
+	}
+	h = &Engine{escalation: r}
+	if h.escalationNeedsTag(nil, nil, timeline) || h.escalationNeedsReactions(nil, nil) {
+		t.Errorf("escalation on comment tags needs the timeline or reactions")
+	}
+
+	if (&Engine{}).escalationNeedsTag(nil, nil, timeline) {
+		t.Errorf("no escalation rule needs the timeline")
+	}
+}
+
+func TestTimeToFirstLabel(t *testing.T) {
+	h := New(Config{MemberRoles: []string{"member"}})
+	created := time.Now().Add(-30 * 24 * time.Hour)
+	labeled := func(login string, at time.Time) *provider.Timeline {
+		e, l := "labeled", "kind/bug"
+		return &provider.Timeline{Event: &e, Actor: &provider.User{Login: &login}, Label: &provider.Label{Name: &l}, CreatedAt: &at}
+	}
+
+	tests := []struct {
+		name     string
+		timeline []*provider.Timeline
+		want     time.Duration
+	}{
+		{"never labeled", nil, 0},
+		{"labeled", []*provider.Timeline{labeled("triager", created.Add(72*time.Hour)), labeled("triager", created.Add(96*time.Hour))}, 72 * time.Hour},
+		{"bot labels ignored", []*provider.Timeline{labeled("triage-bot", created), labeled("triager", created.Add(time.Hour))}, time.Hour},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			co := &Conversation{Created: created, State: "open", Tags: map[tag.Tag]bool{}}
+			h.addEvents(context.Background(), provider.SearchParams{}, co, tc.timeline)
+			if co.TimeToFirstLabel != tc.want {
+				t.Errorf("TimeToFirstLabel = %s, want %s", co.TimeToFirstLabel, tc.want)
+			}
+		})
+	}
+
+	slow := &Conversation{FirstLabeled: created.Add(72 * time.Hour), TimeToFirstLabel: 72 * time.Hour}
+	never := &Conversation{}
+	for _, tc := range []struct {
+		co       *Conversation
+		in       string
+		infinite bool
+		want     bool
+	}{
+		{slow, "+2d", false, true},
+		{slow, "-2d", false, false},
+		{never, "+2d", false, false},
+		{never, "+2d", true, true},
+		{never, "-2d", true, false},
+	} {
+		f := provider.Filter{TimeToFirstLabel: tc.in, UnlabeledInfinite: tc.infinite}
+		if got := postEventsMatch(tc.co, []provider.Filter{f}); got != tc.want {
+			t.Errorf("time-to-first-label %q (infinite=%v) on %s = %v, want %v", tc.in, tc.infinite, tc.co.TimeToFirstLabel, got, tc.want)
+		}
+	}
+}
+
+func TestPRBallIn(t *testing.T) {
+	now := time.Now()
+	hourAgo := now.Add(-time.Hour)
+	review := func(at time.Time) *provider.PullRequestReview {
+		return &provider.PullRequestReview{SubmittedAt: &at}
+	}
+
+	tests := []struct {
+		name    string
+		co      *Conversation
+		reviews []*provider.PullRequestReview
+		want    string
+	}{
+		{"merged", &Conversation{ReviewState: Merged, OutstandingChanges: true}, nil, BallInNone},
+		{"closed", &Conversation{State: "closed", ReviewState: Approved}, nil, BallInNone},
+		{"draft", &Conversation{ReviewState: Unreviewed, Tags: map[tag.Tag]bool{tag.Draft: true}}, nil, BallInAuthor},
+		{"changes requested", &Conversation{ReviewState: ChangesRequested, OutstandingChanges: true}, nil, BallInAuthor},
+		{"pushed since changes requested", &Conversation{ReviewState: NewCommits}, nil, BallInReviewer},
+		{"commented", &Conversation{ReviewState: Commented, LatestAuthorResponse: hourAgo}, []*provider.PullRequestReview{review(now)}, BallInAuthor},
+		{"author replied", &Conversation{ReviewState: Commented, LatestAuthorResponse: now}, []*provider.PullRequestReview{review(hourAgo)}, BallInReviewer},
+		{"approved", &Conversation{ReviewState: Approved}, nil, BallInReviewer},
+		{"unreviewed", &Conversation{ReviewState: Unreviewed}, nil, BallInReviewer},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := prBallIn(tc.co, tc.reviews); got != tc.want {
+				t.Errorf("prBallIn() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	co := &Conversation{PRBallIn: BallInReviewer}
+	if !postEventsMatch(co, []provider.Filter{{BallIn: "author, Reviewer"}}) {
+		t.Errorf("reviewer did not match author,reviewer")
+	}
+	if postEventsMatch(&Conversation{}, []provider.Filter{{BallIn: "none"}}) {
+		t.Errorf("issue matched ball-in")
+	}
+	if ValidBallIn("author,maintainer") == nil {
+		t.Errorf("ValidBallIn accepted maintainer")
+	}
+}
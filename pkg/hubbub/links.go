@@ -0,0 +1,243 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultLinkTimeout bounds each link check, including redirects
+	DefaultLinkTimeout = 10 * time.Second
+	// DefaultMaxLinks is the most links checked per item
+	DefaultMaxLinks = 10
+	// DefaultLinksPerSecond is the most link checks started per second, across every item
+	DefaultLinksPerSecond = 5.0
+
+	// linkCheckTTL is how long the status of a link is cached
+	linkCheckTTL = 24 * time.Hour
+	// linkNoHost is the status recorded for links whose host does not exist
+	linkNoHost = -1
+)
+
+// linkRegexp matches http(s) URLs, stopping at whitespace, quotes, and brackets, such as those around markdown links
+var linkRegexp = regexp.MustCompile("https?://[^\\s<>\"'`()\\[\\]]+")
+
+// internalLinkHosts are not external: links to them are references to other items, or uploads
+var internalLinkHosts = []string{"github.com", "gitlab.com", "githubusercontent.com"}
+
+// errPrivateAddress is returned when a link resolves to an address which is not on the public internet
+var errPrivateAddress = errors.New("refusing to connect to a private address")
+
+// privateNets are address ranges which links may not be checked within, so that an item can not make the server probe
+// its own network
+var privateNets = mustParseCIDRs("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "fc00::/7")
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := []*net.IPNet{}
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// publicIP returns whether an address is on the public internet
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, n := range privateNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// needLinkCheck returns whether any filter requires the external links of items to be checked
+func needLinkCheck(fs []provider.Filter) bool {
+	for _, f := range fs {
+		if f.DeadLinks != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// externalLinks returns up to max distinct external http(s) URLs within a body, in order of appearance
+func externalLinks(body string, max int) []string {
+	links := []string{}
+	seen := map[string]bool{}
+
+	for _, s := range linkRegexp.FindAllString(body, -1) {
+		// Trailing punctuation is more likely to end a sentence than a URL
+		s = strings.TrimRight(s, ".,;:!?*_~")
+
+		u, err := url.Parse(s)
+		if err != nil || u.Hostname() == "" || seen[s] || internalLink(u.Hostname()) {
+			continue
+		}
+
+		seen[s] = true
+		links = append(links, s)
+		if len(links) == max {
+			break
+		}
+	}
+	return links
+}
+
+func internalLink(host string) bool {
+	host = strings.ToLower(host)
+	for _, h := range internalLinkHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// newLinkClient returns an HTTP client for checking links, which refuses to connect to private addresses
+func newLinkClient(timeout time.Duration) *http.Client {
+	d := &net.Dialer{
+		Timeout: timeout,
+		// Checked once the address is resolved, so that a public name can not point at a private address
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return errPrivateAddress
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		// No proxy, as it would make the connection on our behalf, bypassing the address check
+		Transport: &http.Transport{DialContext: d.DialContext},
+	}
+}
+
+// deadLinks returns the external links within a body which no longer exist
+func (h *Engine) deadLinks(ctx context.Context, body string) []string {
+	dead := []string{}
+	for _, u := range externalLinks(body, h.maxLinks) {
+		if h.linkDead(ctx, u) {
+			dead = append(dead, u)
+		}
+	}
+	return dead
+}
+
+// linkDead returns whether a link is gone: its host does not exist, or it returns 404 or 410. Other failures, such
+// as timeouts, may be temporary, so are neither cached nor considered dead.
+func (h *Engine) linkDead(ctx context.Context, u string) bool {
+	key := "link-" + u
+	if x := h.cachedThing(key, time.Now().Add(-linkCheckTTL)); x != nil {
+		return deadStatus(x.LinkStatus)
+	}
+
+	status, err := h.checkLink(ctx, u)
+	if err != nil {
+		klog.V(1).Infof("unable to check %s: %v", u, err)
+		return false
+	}
+
+	if err := h.cache.Set(key, &provider.Thing{LinkStatus: status}); err != nil {
+		klog.Errorf("set %q failed: %v", key, err)
+	}
+	return deadStatus(status)
+}
+
+func deadStatus(status int) bool {
+	return status == linkNoHost || status == http.StatusNotFound || status == http.StatusGone
+}
+
+// checkLink returns the HTTP status of a link, or linkNoHost if its host does not exist
+func (h *Engine) checkLink(ctx context.Context, u string) (int, error) {
+	if err := h.waitForLinkCheck(ctx); err != nil {
+		return 0, err
+	}
+
+	status, err := h.requestLink(ctx, http.MethodHead, u)
+	// Some servers do not support HEAD requests, or only reject them
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented || status == http.StatusForbidden) {
+		status, err = h.requestLink(ctx, http.MethodGet, u)
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return linkNoHost, nil
+	}
+	return status, err
+}
+
+func (h *Engine) requestLink(ctx context.Context, method string, u string) (int, error) {
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := h.linkClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// waitForLinkCheck blocks until another link check may start, spacing checks by linkInterval
+func (h *Engine) waitForLinkCheck(ctx context.Context) error {
+	h.linkMu.Lock()
+	now := time.Now()
+	if h.linkNext.Before(now) {
+		h.linkNext = now
+	}
+	wait := h.linkNext.Sub(now)
+	h.linkNext = h.linkNext.Add(h.linkInterval)
+	h.linkMu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting to check link: %w", ctx.Err())
+	}
+}
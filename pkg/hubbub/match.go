@@ -2,11 +2,14 @@ package hubbub
 
 import (
 	"fmt"
+	"github.com/google/triage-party/pkg/calendar"
+	"github.com/google/triage-party/pkg/constants"
 	"github.com/google/triage-party/pkg/provider"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/triage-party/pkg/tag"
 	"k8s.io/klog/v2"
@@ -35,6 +38,27 @@ func preFetchMatch(i provider.IItem, labels []*provider.Label, fs []provider.Fil
 			}
 		}
 
+		if f.MergeableState != "" {
+			if ok := matchMergeableState(i, f.MergeableState); !ok {
+				klog.V(2).Infof("#%d does not have a mergeable state of %s", i.GetNumber(), f.MergeableState)
+				return false
+			}
+		}
+
+		if f.AuthorAssociation != "" {
+			if ok := matchAssociation(i.GetAuthorAssociation(), f.AuthorAssociation); !ok {
+				klog.V(2).Infof("#%d author association %q does not meet %s", i.GetNumber(), i.GetAuthorAssociation(), f.AuthorAssociation)
+				return false
+			}
+		}
+
+		if f.Team != "" {
+			if ok := matchTeam(i, f.Team); !ok {
+				klog.V(2).Infof("#%d does not have %s as a requested reviewer", i.GetNumber(), f.Team)
+				return false
+			}
+		}
+
 		if f.Updated != "" {
 			if ok := matchDuration(i.GetUpdatedAt(), f.Updated); !ok {
 				klog.V(2).Infof("#%d update at %s does not meet %s", i.GetNumber(), i.GetUpdatedAt(), f.Updated)
@@ -63,6 +87,14 @@ func preFetchMatch(i provider.IItem, labels []*provider.Label, fs []provider.Fil
 			}
 		}
 
+		if f.TitleLength != "" {
+			n := utf8.RuneCountInString(i.GetTitle())
+			if ok := matchRange(float64(n), f.TitleLength); !ok {
+				klog.V(2).Infof("#%d did not pass title-length matchRange: %d vs %s", i.GetNumber(), n, f.TitleLength)
+				return false
+			}
+		}
+
 		if f.LabelRegex() != nil {
 			if ok := matchLabel(labels, f.LabelRegex(), f.LabelNegate()); !ok {
 				klog.V(2).Infof("#%d labels do not meet %s", i.GetNumber(), f.LabelRegex())
@@ -70,6 +102,13 @@ func preFetchMatch(i provider.IItem, labels []*provider.Label, fs []provider.Fil
 			}
 		}
 
+		if f.HasAttachment != nil {
+			if hasAttachment(i.GetBody(), f.AttachmentRegex()) != *f.HasAttachment {
+				klog.V(2).Infof("#%d did not pass has-attachment: %v", i.GetNumber(), *f.HasAttachment)
+				return false
+			}
+		}
+
 		if f.MilestoneRegex() != nil {
 			if ok := matchNegateRegex(i.GetMilestone().GetTitle(), f.MilestoneRegex(), f.MilestoneNegate()); !ok {
 				klog.V(2).Infof("#%d milestone does not meet %s", i.GetNumber(), f.MilestoneRegex())
@@ -77,6 +116,21 @@ func preFetchMatch(i provider.IItem, labels []*provider.Label, fs []provider.Fil
 			}
 		}
 
+		if f.AuthorRegex() != nil {
+			if ok := matchNegateRegex(i.GetUser().GetLogin(), f.AuthorRegex(), f.AuthorNegate()); !ok {
+				klog.V(2).Infof("#%d author does not meet %s", i.GetNumber(), f.AuthorRegex())
+				return false
+			}
+		}
+
+		// Items without an issue type, including PR's, only match negated filters
+		if f.IssueTypeRegex() != nil {
+			if ok := matchNegateRegex(i.GetTypeName(), f.IssueTypeRegex(), f.IssueTypeNegate()); !ok {
+				klog.V(2).Infof("#%d issue type %q does not meet %s", i.GetNumber(), i.GetTypeName(), f.IssueTypeRegex())
+				return false
+			}
+		}
+
 		// This state can be performed without downloading comments
 		if f.TagRegex() != nil && f.TagRegex().String() == "^assigned$" {
 			// If assigned and no assignee, fail
@@ -89,9 +143,9 @@ func preFetchMatch(i provider.IItem, labels []*provider.Label, fs []provider.Fil
 			}
 		}
 
-		if f.Reactions != "" || f.ReactionsPerMonth != "" || f.Commenters != "" || f.Comments != "" {
+		if f.Reactions != "" || f.ReactionsPerMonth != "" || f.MemberReactions != "" || f.Commenters != "" || f.Comments != "" {
 			if !i.GetUpdatedAt().After(i.GetCreatedAt()) {
-				klog.V(1).Infof("#%d has no updates, but need one for: %s", i.GetNumber(), f)
+				klog.V(1).Infof("#%d has no updates, but need one for: %v", i.GetNumber(), f)
 				return false
 			}
 		}
@@ -111,6 +165,83 @@ func postFetchMatch(co *Conversation, fs []provider.Filter) bool {
 				return false
 			}
 		}
+		if f.AssigneeCount != "" {
+			if ok := matchRange(float64(len(co.Assignees)), f.AssigneeCount); !ok {
+				klog.V(2).Infof("#%d did not pass assignee count matchRange: %d vs %s", co.ID, len(co.Assignees), f.AssigneeCount)
+				return false
+			}
+		}
+
+		if f.Hold != "" {
+			if ok := matchHold(co.CurrentHoldTime, f.Hold); !ok {
+				klog.V(2).Infof("#%d did not pass hold: %s vs %s", co.ID, co.CurrentHoldTime, f.Hold)
+				return false
+			}
+		}
+
+		if f.LockReason != "" {
+			if ok := matchLockReason(co, f.LockReason); !ok {
+				klog.V(2).Infof("#%d did not pass lock-reason: locked=%v reason=%q vs %s", co.ID, co.Locked, co.LockReason, f.LockReason)
+				return false
+			}
+		}
+
+		if f.LastCommentRegex() != nil {
+			if ok := matchNegateRegex(co.LastCommentBody, f.LastCommentRegex(), f.LastCommentNegate()); !ok {
+				klog.V(2).Infof("#%d last comment does not meet %s (negate=%v)", co.ID, f.LastCommentRegex(), f.LastCommentNegate())
+				return false
+			}
+		}
+
+		if f.LastCommentByMember != nil {
+			if co.LastCommentByMember != *f.LastCommentByMember {
+				klog.V(2).Infof("#%d did not pass last-comment-by-member: %v vs %v", co.ID, co.LastCommentByMember, *f.LastCommentByMember)
+				return false
+			}
+		}
+
+		if f.BotLast != nil {
+			if co.BotLast != *f.BotLast {
+				klog.V(2).Infof("#%d did not pass bot-last: %v vs %v", co.ID, co.BotLast, *f.BotLast)
+				return false
+			}
+		}
+
+		if f.ConflictingLabels != nil {
+			if (len(co.LabelConflicts) > 0) != *f.ConflictingLabels {
+				klog.V(4).Infof("#%d did not pass conflicting-labels: %v vs %v", co.ID, co.LabelConflicts, *f.ConflictingLabels)
+				return false
+			}
+		}
+
+		if f.AuthorListedMember != nil {
+			if co.AuthorListedMember != *f.AuthorListedMember {
+				klog.V(2).Infof("#%d did not pass author-listed-member: %v vs %v", co.ID, co.AuthorListedMember, *f.AuthorListedMember)
+				return false
+			}
+		}
+
+		if f.HasMilestone != nil {
+			if has := co.Milestone != nil; has != *f.HasMilestone {
+				klog.V(2).Infof("#%d did not pass has-milestone: %v vs %v", co.ID, has, *f.HasMilestone)
+				return false
+			}
+		}
+
+		if f.MilestoneOverdue != nil {
+			if overdue := milestoneOverdue(co.Milestone); overdue != *f.MilestoneOverdue {
+				klog.V(2).Infof("#%d did not pass milestone-overdue: %v vs %v", co.ID, overdue, *f.MilestoneOverdue)
+				return false
+			}
+		}
+
+		if f.ClosedWithin != "" {
+			if ok := matchClosedWithin(co, f.ClosedWithin); !ok {
+				klog.V(2).Infof("#%d did not pass closed-within: %s vs %s", co.ID, co.ClosedAt, f.ClosedWithin)
+				return false
+			}
+		}
+
 		if f.Reactions != "" {
 			if ok := matchRange(float64(co.ReactionsTotal), f.Reactions); !ok {
 				klog.V(2).Infof("#%d did not pass reactions matchRange: %d vs %s", co.ID, co.ReactionsTotal, f.Reactions)
@@ -125,6 +256,46 @@ func postFetchMatch(co *Conversation, fs []provider.Filter) bool {
 			}
 		}
 
+		if f.TopCommentReacts != "" {
+			if ok := matchRange(float64(co.TopCommentReactions), f.TopCommentReacts); !ok {
+				klog.V(2).Infof("#%d did not pass top comment reactions matchRange: %d vs %s", co.ID, co.TopCommentReactions, f.TopCommentReacts)
+				return false
+			}
+		}
+
+		if f.ReactedBy != "" {
+			if ok := matchReactedBy(co, f.ReactedBy); !ok {
+				klog.V(2).Infof("#%d did not pass reacted-by: %v vs %s", co.ID, co.Reactors, f.ReactedBy)
+				return false
+			}
+		}
+
+		if f.MemberReactions != "" {
+			if ok := matchRange(float64(co.MemberReactions), f.MemberReactions); !ok {
+				klog.V(2).Infof("#%d did not pass member reactions matchRange: %d vs %s", co.ID, co.MemberReactions, f.MemberReactions)
+				return false
+			}
+		}
+
+		if f.MemberCommentRatio != "" {
+			if ok := matchRange(co.MemberCommentRatio, f.MemberCommentRatio); !ok {
+				klog.V(2).Infof("#%d did not pass member comment ratio matchRange: %f vs %s", co.ID, co.MemberCommentRatio, f.MemberCommentRatio)
+				return false
+			}
+		}
+
+		if f.TaskCompletion != "" {
+			// Items without a task list have no completion to compare
+			if co.TasksTotal == 0 {
+				klog.V(2).Infof("#%d did not pass task completion: no task list", co.ID)
+				return false
+			}
+			if ok := matchRange(co.TaskCompletion, f.TaskCompletion); !ok {
+				klog.V(2).Infof("#%d did not pass task completion matchRange: %f vs %s", co.ID, co.TaskCompletion, f.TaskCompletion)
+				return false
+			}
+		}
+
 		if f.Commenters != "" {
 			if ok := matchRange(float64(co.CommentersTotal), f.Commenters); !ok {
 				klog.V(2).Infof("#%d did not pass commenters matchRange: %d vs %s", co.ID, co.CommentersTotal, f.Commenters)
@@ -145,6 +316,13 @@ func postFetchMatch(co *Conversation, fs []provider.Filter) bool {
 				return false
 			}
 		}
+		if f.Bounty != "" {
+			if ok := matchRange(co.Bounty, f.Bounty); !ok {
+				klog.V(2).Infof("#%d did not pass bounty matchRange: %f vs %s", co.ID, co.Bounty, f.Bounty)
+				return false
+			}
+		}
+
 		if f.ClosedCommenters != "" {
 			if ok := matchRange(float64(co.ClosedCommentersTotal), f.ClosedCommenters); !ok {
 				klog.V(2).Infof("#%d did not pass commenters-while-closed matchRange: %d vs %s", co.ID, co.ClosedCommentersTotal, f.ClosedCommenters)
@@ -165,9 +343,23 @@ func postFetchMatch(co *Conversation, fs []provider.Filter) bool {
 // Check if an issue matches the summarized version, after events have been loaded
 func postEventsMatch(co *Conversation, fs []provider.Filter) bool {
 	for _, f := range fs {
+		if f.DeadLinks != "" {
+			if ok := matchRange(float64(len(co.DeadLinks)), f.DeadLinks); !ok {
+				klog.V(4).Infof("#%d did not pass dead-links: %v vs %s", co.ID, co.DeadLinks, f.DeadLinks)
+				return false
+			}
+		}
+
+		if f.Project != "" {
+			if ok := matchProject(co, f.Project); !ok {
+				klog.V(4).Infof("#%d did not pass project: %v vs %s", co.ID, co.ProjectStatus, f.Project)
+				return false
+			}
+		}
+
 		if f.TagRegex() != nil {
 			if ok, _ := matchTag(co.Tags, f.TagRegex(), f.TagNegate()); !ok {
-				klog.V(4).Infof("#%d did not pass matchTag: %s vs %s %v", co.ID, co.Tags, f.TagRegex(), f.TagNegate())
+				klog.V(4).Infof("#%d did not pass matchTag: %v vs %s %v", co.ID, co.Tags, f.TagRegex(), f.TagNegate())
 				return false
 			}
 		}
@@ -178,10 +370,153 @@ func postEventsMatch(co *Conversation, fs []provider.Filter) bool {
 				return false
 			}
 		}
+
+		if f.TimeToFirstLabel != "" {
+			if ok := matchTimeToFirstLabel(co, f.TimeToFirstLabel, f.UnlabeledInfinite); !ok {
+				klog.V(4).Infof("#%d did not pass time-to-first-label: %s vs %s", co.ID, co.TimeToFirstLabel, f.TimeToFirstLabel)
+				return false
+			}
+		}
+
+		if f.OutstandingChanges != nil {
+			if co.OutstandingChanges != *f.OutstandingChanges {
+				klog.V(4).Infof("#%d did not pass outstanding changes: %v vs %v", co.ID, co.OutstandingChanges, *f.OutstandingChanges)
+				return false
+			}
+		}
+
+		if f.BallIn != "" {
+			if ok := matchBallIn(co, f.BallIn); !ok {
+				klog.V(4).Infof("#%d did not pass ball-in: %q vs %s", co.ID, co.PRBallIn, f.BallIn)
+				return false
+			}
+		}
+
+		if f.HasLinkedIssue != nil {
+			linked := len(co.IssueRefs) > 0 || co.DevelopmentLinks > 0
+			if linked != *f.HasLinkedIssue {
+				klog.V(4).Infof("#%d did not pass has-linked-issue: %v vs %v", co.ID, linked, *f.HasLinkedIssue)
+				return false
+			}
+		}
+
+		if f.LastTouchedBy != "" {
+			if !strings.EqualFold(co.LastTouchedBy.GetLogin(), f.LastTouchedBy) {
+				klog.V(4).Infof("#%d did not pass last-touched-by: %s vs %s", co.ID, co.LastTouchedBy.GetLogin(), f.LastTouchedBy)
+				return false
+			}
+		}
+
+		if f.SelfInflicted != nil {
+			if co.SelfInflicted != *f.SelfInflicted {
+				klog.V(4).Infof("#%d did not pass self-inflicted: %v vs %v", co.ID, co.SelfInflicted, *f.SelfInflicted)
+				return false
+			}
+		}
+
+		if f.ForcePushes != "" {
+			if ok := matchRange(float64(co.ForcePushes), f.ForcePushes); !ok {
+				klog.V(2).Infof("#%d did not pass force-pushes matchRange: %d vs %s", co.ID, co.ForcePushes, f.ForcePushes)
+				return false
+			}
+		}
+
+		if f.ReopenCount != "" {
+			if ok := matchRange(float64(co.ReopenCount), f.ReopenCount); !ok {
+				klog.V(2).Infof("#%d did not pass reopen-count matchRange: %d vs %s", co.ID, co.ReopenCount, f.ReopenCount)
+				return false
+			}
+		}
+
+		if f.StateAge != "" {
+			if ok := matchDuration(co.StateSince, f.StateAge); !ok {
+				klog.V(2).Infof("#%d did not pass state-age: %s vs %s", co.ID, co.StateSince, f.StateAge)
+				return false
+			}
+		}
+
+		if f.AbandonedAssignment != nil {
+			if co.AbandonedAssignment != *f.AbandonedAssignment {
+				klog.V(2).Infof("#%d did not pass abandoned-assignment: %v vs %v", co.ID, co.AbandonedAssignment, *f.AbandonedAssignment)
+				return false
+			}
+		}
+
+		if f.UntouchedByMembers != nil {
+			if co.UntouchedByMembers() != *f.UntouchedByMembers {
+				klog.V(2).Infof("#%d did not pass untouched-by-members: %v vs %v", co.ID, co.UntouchedByMembers(), *f.UntouchedByMembers)
+				return false
+			}
+		}
+
+		if f.Unpicked != nil {
+			if co.Unpicked != *f.Unpicked {
+				klog.V(2).Infof("#%d did not pass unpicked: %v vs %v", co.ID, co.Unpicked, *f.Unpicked)
+				return false
+			}
+		}
+
+		if f.RenamedWithin != "" {
+			if ok := matchRenamedWithin(co, f.RenamedWithin); !ok {
+				klog.V(2).Infof("#%d did not pass renamed-within: %s vs %s", co.ID, co.Renamed, f.RenamedWithin)
+				return false
+			}
+		}
+
+		if f.LabeledWithin != "" {
+			if ok := matchLabeledWithin(co, f.LabeledWithin); !ok {
+				klog.V(2).Infof("#%d did not pass labeled-within: %v vs %s", co.ID, co.LabeledAt, f.LabeledWithin)
+				return false
+			}
+		}
+
+		if f.LinkedPRState != "" || f.LinkedPRByMember != nil || f.LinkedPRChecks != "" {
+			if ok := matchLinkedPR(co.PullRequestRefs, f); !ok {
+				klog.V(4).Infof("#%d did not pass linked PR: state=%q by-member=%v checks=%q", co.ID, f.LinkedPRState, f.LinkedPRByMember, f.LinkedPRChecks)
+				return false
+			}
+		}
 	}
 	return true
 }
 
+// matchLockReason matches the reason a locked item was locked, where "none" matches items locked without a reason.
+// Unlocked items never match.
+func matchLockReason(co *Conversation, reason string) bool {
+	if !co.Locked {
+		return false
+	}
+	if reason == "none" {
+		return co.LockReason == ""
+	}
+	return strings.EqualFold(co.LockReason, reason)
+}
+
+// matchLinkedPR returns whether a single linked PR satisfies all of the linked PR filters
+func matchLinkedPR(refs []*RelatedConversation, f provider.Filter) bool {
+	for _, ref := range refs {
+		state := ref.State
+		if ref.ReviewState == Merged {
+			state = "merged"
+		}
+
+		if f.LinkedPRState != "" && state != f.LinkedPRState {
+			continue
+		}
+
+		if f.LinkedPRByMember != nil && ref.SelfInflicted != *f.LinkedPRByMember {
+			continue
+		}
+
+		if f.LinkedPRChecks != "" && ref.CheckState != f.LinkedPRChecks {
+			continue
+		}
+
+		return true
+	}
+	return false
+}
+
 func matchLabel(labels []*provider.Label, re *regexp.Regexp, negate bool) bool {
 	for _, l := range labels {
 		if re.MatchString(*l.Name) {
@@ -257,6 +592,148 @@ func ParseDuration(ds string) (time.Duration, bool, bool) {
 	return d, within, over
 }
 
+// businessMatch checks filters which measure elapsed time using the business hours calendar
+func (h *Engine) businessMatch(i provider.IItem, fs []provider.Filter) bool {
+	for _, f := range fs {
+		if f.UpdatedBusiness != "" {
+			if ok := matchBusinessDuration(h.calendar, i.GetUpdatedAt(), f.UpdatedBusiness); !ok {
+				klog.V(2).Infof("#%d update at %s does not meet business hours %s", i.GetNumber(), i.GetUpdatedAt(), f.UpdatedBusiness)
+				return false
+			}
+		}
+
+		if f.UpdatedOffHours != nil {
+			if offHours := !h.calendar.Open(i.GetUpdatedAt()); offHours != *f.UpdatedOffHours {
+				klog.V(2).Infof("#%d update at %s did not pass updated-off-hours: %v vs %v", i.GetNumber(), i.GetUpdatedAt(), offHours, *f.UpdatedOffHours)
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchBusinessDuration is like matchDuration, but only counts time within business hours
+func matchBusinessDuration(cal *calendar.Calendar, t time.Time, ds string) bool {
+	d, within, over := ParseDuration(ds)
+	elapsed := cal.Since(t)
+
+	if within && elapsed < d {
+		return true
+	}
+	if over && elapsed > d {
+		return true
+	}
+	return false
+}
+
+// matchTeam matches PR's where a team slug, optionally prefixed by the organization, is directly requested as a reviewer.
+// Issues can not be assigned to teams, so never match.
+// matchMergeableState matches PR's whose mergeable state is within a comma-separated list, such as "behind,dirty".
+// GitHub computes the state in the background, so it may be missing or "unknown": these only match "unknown".
+func matchMergeableState(i provider.IItem, states string) bool {
+	pr, ok := i.(*provider.PullRequest)
+	if !ok {
+		return false
+	}
+
+	state := strings.ToLower(pr.GetMergeableState())
+	if state == "" {
+		state = "unknown"
+	}
+
+	for _, s := range strings.Split(states, ",") {
+		if strings.TrimSpace(strings.ToLower(s)) == state {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAssociation matches an author association against a comma-separated list, such as "NONE,CONTRIBUTOR", which
+// may be negated with a leading !. GitLab does not report associations, so its items are treated as NONE.
+func matchAssociation(association string, list string) bool {
+	negate := strings.HasPrefix(list, "!")
+	list = strings.TrimPrefix(list, "!")
+
+	if association == "" {
+		association = "NONE"
+	}
+
+	for _, a := range strings.Split(list, ",") {
+		if strings.EqualFold(strings.TrimSpace(a), association) {
+			return !negate
+		}
+	}
+	return negate
+}
+
+func matchTeam(i provider.IItem, team string) bool {
+	pr, ok := i.(*provider.PullRequest)
+	if !ok {
+		return false
+	}
+
+	// https://github.com/<org>/<project>/pull/<number>
+	org := ""
+	parts := strings.Split(pr.GetHTMLURL(), "/")
+	if len(parts) > 3 {
+		org = parts[3]
+	}
+
+	for _, t := range pr.RequestedTeams {
+		if strings.EqualFold(team, t.GetSlug()) || strings.EqualFold(team, org+"/"+t.GetSlug()) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLabeledWithin parses a labeled-within filter of the form <label>:<duration>, such as "needs-review:24h"
+func ParseLabeledWithin(s string) (string, time.Duration, error) {
+	i := strings.LastIndex(s, ":")
+	if i < 1 {
+		return "", 0, fmt.Errorf("%q is not of the form <label>:<duration>", s)
+	}
+
+	d, within, over := ParseDuration(s[i+1:])
+	if d <= 0 || within || over {
+		return "", 0, fmt.Errorf("%q has an invalid duration", s)
+	}
+	return s[:i], d, nil
+}
+
+// matchLabeledWithin matches items which were most recently given a label within a duration, and still have it
+func matchLabeledWithin(co *Conversation, s string) bool {
+	label, d, err := ParseLabeledWithin(s)
+	if err != nil {
+		klog.Errorf("labeled-within: %v", err)
+		return false
+	}
+
+	t, ok := co.LabeledAt[strings.ToLower(label)]
+	return ok && time.Since(t) < d
+}
+
+// matchClosedWithin matches items closed within a duration, excluding those still open
+func matchClosedWithin(co *Conversation, ds string) bool {
+	if co.ClosedAt.IsZero() || co.State == constants.OpenState || co.State == constants.OpenedState {
+		return false
+	}
+
+	d, _, _ := ParseDuration(ds)
+	return time.Since(co.ClosedAt) < d
+}
+
+// matchRenamedWithin matches items whose title was most recently changed within a duration
+func matchRenamedWithin(co *Conversation, ds string) bool {
+	if co.Renamed.IsZero() {
+		return false
+	}
+
+	d, _, _ := ParseDuration(ds)
+	return time.Since(co.Renamed) < d
+}
+
 func matchDuration(t time.Time, ds string) bool {
 	d, within, over := ParseDuration(ds)
 
@@ -269,6 +746,29 @@ func matchDuration(t time.Time, ds string) bool {
 	return false
 }
 
+// matchHold matches how long an item has been waiting on a project member against a duration, such as +4h
+func matchHold(held time.Duration, ds string) bool {
+	d, within, over := ParseDuration(ds)
+
+	if within && held < d {
+		return true
+	}
+	if over && held > d {
+		return true
+	}
+	return false
+}
+
+// matchTimeToFirstLabel matches how long an item went before being labeled against a duration, such as +2d. Items
+// which were never labeled are excluded, unless infinite is set, in which case they match any +duration.
+func matchTimeToFirstLabel(co *Conversation, ds string, infinite bool) bool {
+	if co.FirstLabeled.IsZero() {
+		_, _, over := ParseDuration(ds)
+		return infinite && over
+	}
+	return matchHold(co.TimeToFirstLabel, ds)
+}
+
 func matchRange(i float64, r string) bool {
 	matches := rangeRegexp.FindStringSubmatch(r)
 	if len(matches) != 3 {
@@ -298,3 +798,11 @@ func matchRange(i float64, r string) bool {
 		return false
 	}
 }
+
+// milestoneOverdue returns whether a milestone is open and past its due date. Milestones without one are never overdue.
+func milestoneOverdue(m *provider.Milestone) bool {
+	if m == nil || m.GetState() != "open" || m.GetDueOn().IsZero() {
+		return false
+	}
+	return time.Now().After(m.GetDueOn())
+}
@@ -0,0 +1,151 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/logu"
+	"github.com/google/triage-party/pkg/provider"
+	"k8s.io/klog/v2"
+)
+
+// needProjects returns whether any filter requires the status of items on project boards
+func needProjects(fs []provider.Filter) bool {
+	for _, f := range fs {
+		if f.Project != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseProjectFilter parses a project filter of the form <board>=<status> or <board>!=<status>, such as
+// "Roadmap=In Progress", returning the board name, status, and whether the match is negated
+func ParseProjectFilter(s string) (string, string, bool, error) {
+	i := strings.Index(s, "=")
+	if i < 1 {
+		return "", "", false, fmt.Errorf("%q is not of the form <board>=<status>", s)
+	}
+
+	name, status, negate := s[:i], strings.TrimSpace(s[i+1:]), false
+	if strings.HasSuffix(name, "!") {
+		name, negate = name[:len(name)-1], true
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" || status == "" {
+		return "", "", false, fmt.Errorf("%q is not of the form <board>=<status>", s)
+	}
+	return name, status, negate, nil
+}
+
+// matchProject matches items by their status on a project board. "none" matches items which are not on the board,
+// and "*" matches items which are, whatever their status.
+func matchProject(co *Conversation, s string) bool {
+	name, want, negate, err := ParseProjectFilter(s)
+	if err != nil {
+		klog.Errorf("project: %v", err)
+		return false
+	}
+
+	status, on := co.ProjectStatus[name]
+	match := false
+	switch want {
+	case "none":
+		match = !on
+	case "*":
+		match = on
+	default:
+		match = on && strings.EqualFold(status, want)
+	}
+	return match != negate
+}
+
+// projectStatuses returns the status of every item on each configured board, by board name and item URL. Boards are
+// a GitHub feature, so items in other repositories are never on one.
+func (h *Engine) projectStatuses(ctx context.Context, sp provider.SearchParams) map[string]map[string]string {
+	boards := map[string]map[string]string{}
+	if sp.Repo.Host != constants.GithubProviderHost {
+		return boards
+	}
+
+	for _, b := range h.projects {
+		sp.Board = b
+		items, err := h.cachedProjectItems(ctx, sp)
+		if err != nil {
+			klog.Errorf("project %s: %v", b.Name, err)
+			continue
+		}
+
+		statuses := map[string]string{}
+		for _, i := range items {
+			statuses[i.URL] = i.Status
+		}
+		boards[b.Name] = statuses
+	}
+	return boards
+}
+
+// setProjectStatus records the status of a conversation on each board it is on
+func setProjectStatus(co *Conversation, boards map[string]map[string]string) {
+	co.ProjectStatus = map[string]string{}
+	for name, statuses := range boards {
+		if status, ok := statuses[co.URL]; ok {
+			co.ProjectStatus[name] = status
+		}
+	}
+}
+
+// cachedProjectItems returns the items on the project board sp.Board. Moving an item between columns does not
+// update the item itself, so boards are cached as a whole, shared by every repository and collection.
+func (h *Engine) cachedProjectItems(ctx context.Context, sp provider.SearchParams) ([]*provider.ProjectItem, error) {
+	sp.SearchKey = fmt.Sprintf("project-%s-%d-%s", sp.Board.Owner, sp.Board.Number, sp.Board.StatusField())
+
+	if x := h.cachedThing(sp.SearchKey, sp.NewerThan); x != nil {
+		return x.ProjectItems, nil
+	}
+
+	klog.V(1).Infof("cache miss for %s newer than %s", sp.SearchKey, logu.STime(sp.NewerThan))
+	items, err := h.updateProjectItems(ctx, sp)
+	if err != nil {
+		klog.Warningf("Retrieving stale results for %s due to error: %v", sp.SearchKey, err)
+		if x := h.cache.GetNewerThan(sp.SearchKey, time.Time{}); x != nil {
+			return x.ProjectItems, nil
+		}
+	}
+	return items, err
+}
+
+func (h *Engine) updateProjectItems(ctx context.Context, sp provider.SearchParams) ([]*provider.ProjectItem, error) {
+	klog.V(1).Infof("Downloading items for project %s/%d", sp.Board.Owner, sp.Board.Number)
+
+	p := provider.ResolveProviderByHost(sp.Repo.Host)
+	items, resp, err := p.ProjectItemsList(ctx, sp)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logRate(resp.Rate)
+
+	if err := h.cache.Set(sp.SearchKey, &provider.Thing{ProjectItems: items}); err != nil {
+		klog.Errorf("set %q failed: %v", sp.SearchKey, err)
+	}
+	return items, nil
+}
@@ -36,12 +36,13 @@ const (
 	Commented           = "COMMENTED"
 	Merged              = "MERGED"
 	Closed              = "CLOSED"
+	Pending             = "PENDING"
 )
 
 // cachedPRs returns a list of cached PR's if possible
 func (h *Engine) cachedPRs(ctx context.Context, sp provider.SearchParams) ([]*provider.PullRequest, time.Time, error) {
 	sp.SearchKey = prSearchKey(sp)
-	if x := h.cache.GetNewerThan(sp.SearchKey, sp.NewerThan); x != nil {
+	if x := h.cachedThing(sp.SearchKey, sp.NewerThan); x != nil {
 		// Normally the similarity tables are only updated when fresh data is encountered.
 		if sp.NewerThan.IsZero() {
 			go h.updateSimilarPullRequests(sp.SearchKey, x.PullRequests)
@@ -65,7 +66,7 @@ func (h *Engine) cachedPRs(ctx context.Context, sp provider.SearchParams) ([]*pr
 func (h *Engine) updatePRs(ctx context.Context, sp provider.SearchParams) ([]*provider.PullRequest, time.Time, error) {
 	start := time.Now()
 	sp.PullRequestListOptions = provider.PullRequestListOptions{
-		ListOptions: provider.ListOptions{PerPage: 100},
+		ListOptions: provider.ListOptions{PerPage: perPage(sp)},
 		State:       sp.State,
 		Sort:        constants.UpdatedSortOption,
 		Direction:   constants.DescDirectionOption,
@@ -74,7 +75,7 @@ func (h *Engine) updatePRs(ctx context.Context, sp provider.SearchParams) ([]*pr
 
 	foundOldest := false
 	var allPRs []*provider.PullRequest
-	for {
+	for page := 1; ; page++ {
 		if sp.UpdateAge == 0 {
 			klog.Infof("Downloading %s pull requests for %s/%s (page %d)...",
 				sp.State, sp.Repo.Organization, sp.Repo.Project, sp.PullRequestListOptions.Page)
@@ -110,7 +111,7 @@ func (h *Engine) updatePRs(ctx context.Context, sp provider.SearchParams) ([]*pr
 
 		go h.updateSimilarPullRequests(sp.SearchKey, prs)
 
-		if resp.NextPage == 0 || foundOldest {
+		if lastPage(sp, resp, page) || foundOldest {
 			break
 		}
 		sp.PullRequestListOptions.Page = resp.NextPage
@@ -128,8 +129,8 @@ func (h *Engine) updatePRs(ctx context.Context, sp provider.SearchParams) ([]*pr
 func (h *Engine) cachedPR(ctx context.Context, sp provider.SearchParams) (*provider.PullRequest, time.Time, error) {
 	sp.SearchKey = fmt.Sprintf("%s-%s-%d-pr", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
 
-	if x := h.cache.GetNewerThan(sp.SearchKey, sp.NewerThan); x != nil {
-		return x.PullRequests[0], x.Created, nil
+	if x := h.cachedThing(sp.SearchKey, sp.NewerThan); x != nil {
+		return cachedPullRequest(x), x.Created, nil
 	}
 
 	klog.V(1).Infof("cache miss for %s newer than %s", sp.SearchKey, sp.NewerThan)
@@ -143,12 +144,20 @@ func (h *Engine) cachedPR(ctx context.Context, sp provider.SearchParams) (*provi
 		klog.Warningf("Retrieving stale results for %s due to error: %v", sp.SearchKey, err)
 		x := h.cache.GetNewerThan(sp.SearchKey, time.Time{})
 		if x != nil {
-			return x.PullRequests[0], x.Created, nil
+			return cachedPullRequest(x), x.Created, nil
 		}
 	}
 	return pr, created, err
 }
 
+// cachedPullRequest returns the PR within a cached thing, or nil for a negative result
+func cachedPullRequest(x *provider.Thing) *provider.PullRequest {
+	if len(x.PullRequests) == 0 {
+		return nil
+	}
+	return x.PullRequests[0]
+}
+
 // pr gets a single PR (not used very often)
 func (h *Engine) updatePR(ctx context.Context, sp provider.SearchParams) (*provider.PullRequest, time.Time, error) {
 	klog.V(1).Infof("Downloading single PR %s/%s #%d", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
@@ -158,6 +167,9 @@ func (h *Engine) updatePR(ctx context.Context, sp provider.SearchParams) (*provi
 	pr, resp, err := p.PullRequestsGet(ctx, sp)
 
 	if err != nil {
+		if h.cacheNegative(sp, err) {
+			return nil, start, nil
+		}
 		return pr, start, err
 	}
 
@@ -174,7 +186,7 @@ func (h *Engine) updatePR(ctx context.Context, sp provider.SearchParams) (*provi
 func (h *Engine) cachedReviewComments(ctx context.Context, sp provider.SearchParams) ([]*provider.PullRequestComment, time.Time, error) {
 	sp.SearchKey = fmt.Sprintf("%s-%s-%d-pr-comments", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
 
-	if x := h.cache.GetNewerThan(sp.SearchKey, sp.NewerThan); x != nil {
+	if x := h.cachedThing(sp.SearchKey, sp.NewerThan); x != nil {
 		return x.PullRequestComments, x.Created, nil
 	}
 
@@ -244,6 +256,9 @@ func (h *Engine) updateReviewComments(ctx context.Context, sp provider.SearchPar
 		cs, resp, err := p.PullRequestsListComments(ctx, sp)
 
 		if err != nil {
+			if h.cacheNegative(sp, err) {
+				return nil, start, nil
+			}
 			return cs, start, err
 		}
 
@@ -269,7 +284,12 @@ func (h *Engine) updateReviewComments(ctx context.Context, sp provider.SearchPar
 
 func (h *Engine) createPRSummary(ctx context.Context, sp provider.SearchParams, pr *provider.PullRequest, cs []*provider.Comment,
 	timeline []*provider.Timeline, reviews []*provider.PullRequestReview) *Conversation {
-	co := h.createConversation(pr, cs, sp.Age)
+	holdStart := pr.GetCreatedAt()
+	if h.holdFromReadyForReview {
+		holdStart = readyForReview(pr, timeline)
+	}
+
+	co := h.createConversationSince(pr, cs, sp.Age, holdStart)
 	co.Type = PullRequest
 	co.ReviewsTotal = len(reviews)
 	co.TimelineTotal = len(timeline)
@@ -277,6 +297,9 @@ func (h *Engine) createPRSummary(ctx context.Context, sp provider.SearchParams,
 
 	co.ReviewState = reviewState(pr, timeline, reviews)
 	co.Tags[reviewStateTag(co.ReviewState)] = true
+	co.OutstandingChanges = outstandingChanges(timeline, reviews)
+	co.ForcePushes = forcePushes(timeline)
+	co.MergeableState = pr.GetMergeableState()
 
 	if pr.GetDraft() {
 		co.Tags[tag.Draft] = true
@@ -288,17 +311,49 @@ func (h *Engine) createPRSummary(ctx context.Context, sp provider.SearchParams,
 		co.ReviewState = Merged
 		co.Tags[tag.Merged] = true
 	}
+	co.PRBallIn = prBallIn(co, reviews)
 
 	return co
 }
 
+// readyForReview returns when a PR was last marked as ready for review, or when it was created if it never was a draft
+func readyForReview(pr *provider.PullRequest, timeline []*provider.Timeline) time.Time {
+	// The review clock does not run for drafts, including those converted back into one
+	if pr.GetDraft() {
+		return time.Now()
+	}
+
+	ready := pr.GetCreatedAt()
+	for _, t := range timeline {
+		if t.GetEvent() == "ready_for_review" && t.GetCreatedAt().After(ready) {
+			ready = t.GetCreatedAt()
+		}
+	}
+	return ready
+}
+
+// forcePushes returns how many times the head branch of a PR was force-pushed
+func forcePushes(timeline []*provider.Timeline) int {
+	n := 0
+	for _, t := range timeline {
+		if t.GetEvent() == "head_ref_force_pushed" {
+			n++
+		}
+	}
+	return n
+}
+
 func (h *Engine) PRSummary(ctx context.Context, sp provider.SearchParams, pr *provider.PullRequest, cs []*provider.Comment, timeline []*provider.Timeline,
 	reviews []*provider.PullRequestReview) *Conversation {
 	key := pr.GetHTMLURL()
-	cached, ok := h.seen[key]
-	if ok {
+	cached := h.seenConversation(key)
+	// The issues API also lists PR's, which are cached under the same URL as issue-typed conversations
+	if cached != nil && cached.Type != PullRequest {
+		cached = nil
+	}
+	if cached != nil {
 		if !cached.Seen.Before(h.mtime(pr)) && cached.CommentsSeen >= len(cs) && cached.TimelineTotal >= len(timeline) && cached.ReviewsTotal >= len(reviews) {
-			return h.seen[key]
+			return cached
 		}
 		if cached.CommentsSeen < len(cs) {
 			klog.V(2).Infof("%s in issue cache, but is missing comments. Live @ %s (%d comments), cached @ %s (%d comments)  ", pr.GetHTMLURL(), h.mtime(pr), len(cs), cached.Seen, cached.CommentsSeen)
@@ -311,6 +366,7 @@ func (h *Engine) PRSummary(ctx context.Context, sp provider.SearchParams, pr *pr
 		}
 	}
 
-	h.seen[key] = h.createPRSummary(ctx, sp, pr, cs, timeline, reviews)
-	return h.seen[key]
+	co := h.createPRSummary(ctx, sp, pr, cs, timeline, reviews)
+	h.setSeen(key, co)
+	return co
 }
@@ -0,0 +1,229 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/logu"
+	"github.com/google/triage-party/pkg/provider"
+	"k8s.io/klog/v2"
+)
+
+// maxSearchResults is the most results the GitHub search API will return for a single query
+const maxSearchResults = 1000
+
+// cachedSearch returns issues and PR's matching a search query, cached if possible
+func (h *Engine) cachedSearch(ctx context.Context, sp provider.SearchParams) ([]*provider.Issue, time.Time, error) {
+	sp.SearchKey = fmt.Sprintf("%s-%s-search-%s%s", sp.Repo.Organization, sp.Repo.Project, sp.Query, pageSuffix(sp))
+
+	if x := h.cachedThing(sp.SearchKey, sp.NewerThan); x != nil {
+		return x.Issues, x.Created, nil
+	}
+
+	klog.V(1).Infof("cache miss for %s newer than %s", sp.SearchKey, logu.STime(sp.NewerThan))
+	issues, created, err := h.updateSearch(ctx, sp)
+	if err != nil {
+		klog.Warningf("Retrieving stale results for %s due to error: %v", sp.SearchKey, err)
+		x := h.cache.GetNewerThan(sp.SearchKey, time.Time{})
+		if x != nil {
+			return x.Issues, x.Created, nil
+		}
+	}
+	return issues, created, err
+}
+
+// updateSearch runs a search query, storing the results in cache
+func (h *Engine) updateSearch(ctx context.Context, sp provider.SearchParams) ([]*provider.Issue, time.Time, error) {
+	start := time.Now()
+	sp.ListOptions = provider.ListOptions{PerPage: perPage(sp)}
+
+	var all []*provider.Issue
+	for page := 1; ; page++ {
+		klog.Infof("Searching %s/%s for %q (page %d)...", sp.Repo.Organization, sp.Repo.Project, sp.Query, sp.ListOptions.Page)
+
+		p := provider.ResolveProviderByHost(sp.Repo.Host)
+		is, resp, err := p.IssuesSearch(ctx, sp)
+		if err != nil {
+			return is, start, err
+		}
+
+		h.logRate(resp.Rate)
+
+		for _, i := range is {
+			h.updateMtime(i, i.GetUpdatedAt())
+		}
+		all = append(all, is...)
+
+		if len(all) >= maxSearchResults {
+			klog.Warningf("%s/%s search for %q reached the %d result limit of the search API", sp.Repo.Organization, sp.Repo.Project, sp.Query, maxSearchResults)
+			break
+		}
+
+		if lastPage(sp, resp, page) {
+			break
+		}
+		sp.ListOptions.Page = resp.NextPage
+	}
+
+	if err := h.cache.Set(sp.SearchKey, &provider.Thing{Issues: all}); err != nil {
+		klog.Errorf("set %q failed: %v", sp.SearchKey, err)
+	}
+
+	klog.V(1).Infof("updateSearch %s returning %d items", sp.SearchKey, len(all))
+	return all, start, nil
+}
+
+// searchIssues returns the issues matching the search query
+func (h *Engine) searchIssues(ctx context.Context, sp provider.SearchParams) ([]*provider.Issue, time.Time) {
+	found, age, err := h.cachedSearch(ctx, sp)
+	if err != nil {
+		klog.Errorf("search issues: %v", err)
+	}
+
+	return found, age
+}
+
+// searchPullRequests returns the PR's matching the search query. As search results are issue-shaped,
+// each PR is then looked up individually.
+func (h *Engine) searchPullRequests(ctx context.Context, sp provider.SearchParams) ([]*provider.PullRequest, time.Time) {
+	found, age, err := h.cachedSearch(ctx, sp)
+	if err != nil {
+		klog.Errorf("search PR's: %v", err)
+	}
+
+	prs := []*provider.PullRequest{}
+	for _, i := range found {
+		if !i.IsPullRequest() {
+			continue
+		}
+
+		sp.IssueNumber = i.GetNumber()
+		sp.NewerThan = i.GetUpdatedAt()
+		sp.Fetch = true
+
+		pr, _, err := h.cachedPR(ctx, sp)
+		if err != nil {
+			klog.Errorf("PR #%d: %v", i.GetNumber(), err)
+			continue
+		}
+		if pr != nil {
+			prs = append(prs, pr)
+		}
+	}
+	return prs, age
+}
+
+// issueCandidates returns the issues to filter: those matching the search query if set, otherwise the repository
+// listing. Items on the exclude list are dropped from either.
+func (h *Engine) issueCandidates(ctx context.Context, sp provider.SearchParams) ([]*provider.Issue, time.Time) {
+	var found []*provider.Issue
+	var age time.Time
+	if sp.Query != "" {
+		found, age = h.searchIssues(ctx, sp)
+	} else {
+		found, age = h.listIssues(ctx, sp)
+	}
+
+	is := []*provider.Issue{}
+	for _, i := range found {
+		if h.excluded(sp.Repo, i) {
+			klog.Infof("Excluding %s: on the exclude list", i.GetHTMLURL())
+			continue
+		}
+		h.learnMember(i.GetUser().GetLogin(), i.GetAuthorAssociation())
+		is = append(is, i)
+	}
+
+	if h.issuesIncludePRs {
+		return is, age
+	}
+	return provider.IssuesOnly(is), age
+}
+
+// pullRequestCandidates returns the PR's to filter: those matching the search query if set, otherwise the repository
+// listing. Items on the exclude list are dropped from either.
+func (h *Engine) pullRequestCandidates(ctx context.Context, sp provider.SearchParams) ([]*provider.PullRequest, time.Time) {
+	var found []*provider.PullRequest
+	var age time.Time
+	if sp.Query != "" {
+		found, age = h.searchPullRequests(ctx, sp)
+	} else {
+		found, age = h.listPullRequests(ctx, sp)
+	}
+
+	prs := []*provider.PullRequest{}
+	for _, pr := range found {
+		if h.excluded(sp.Repo, pr) {
+			klog.Infof("Excluding %s: on the exclude list", pr.GetHTMLURL())
+			continue
+		}
+		h.learnMember(pr.GetUser().GetLogin(), pr.GetAuthorAssociation())
+		prs = append(prs, pr)
+	}
+
+	if needMergeableState(sp.Filters) {
+		prs = h.withMergeableState(ctx, sp, prs)
+	}
+	return prs, age
+}
+
+// needMergeableState returns whether filters match on the mergeable state, which the PR listing does not include
+func needMergeableState(fs []provider.Filter) bool {
+	for _, f := range fs {
+		if f.MergeableState != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeableStateCutoff returns how recently the mergeable state of a PR must have been fetched: since it was last
+// updated, and within MergeableStateTTL
+func mergeableStateCutoff(pr *provider.PullRequest, now time.Time) time.Time {
+	return latest(pr.GetUpdatedAt(), now.Add(-MergeableStateTTL))
+}
+
+// withMergeableState returns PR's with the mergeable state filled in, by looking up each open PR missing it
+// individually. The listed PR's are cached, so they are copied rather than modified.
+func (h *Engine) withMergeableState(ctx context.Context, sp provider.SearchParams, prs []*provider.PullRequest) []*provider.PullRequest {
+	filled := []*provider.PullRequest{}
+	for _, pr := range prs {
+		if pr.MergeableState != nil || (pr.GetState() != constants.OpenState && pr.GetState() != constants.OpenedState) {
+			filled = append(filled, pr)
+			continue
+		}
+
+		sp.IssueNumber = pr.GetNumber()
+		sp.NewerThan = mergeableStateCutoff(pr, time.Now())
+		sp.Fetch = true
+
+		full, _, err := h.cachedPR(ctx, sp)
+		if err != nil {
+			klog.Errorf("PR #%d: %v", pr.GetNumber(), err)
+		}
+
+		cp := *pr
+		if full != nil {
+			cp.Mergeable = full.Mergeable
+			cp.MergeableState = full.MergeableState
+		}
+		filled = append(filled, &cp)
+	}
+	return filled
+}
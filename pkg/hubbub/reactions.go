@@ -15,7 +15,13 @@
 package hubbub
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/google/triage-party/pkg/provider"
+	"k8s.io/klog/v2"
 )
 
 const reactThumbsUp = "thumbs_up"
@@ -35,3 +41,113 @@ func reactions(r *provider.Reactions) map[string]int {
 		reactHooray:     r.GetHooray(),
 	}
 }
+
+func (h *Engine) cachedReactions(ctx context.Context, sp provider.SearchParams, pr bool) ([]*provider.Reaction, time.Time, error) {
+	sp.SearchKey = fmt.Sprintf("%s-%s-%d-reactions", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
+	if pr {
+		sp.SearchKey = fmt.Sprintf("%s-%s-%d-pr-reactions", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
+	}
+
+	if x := h.cachedThing(sp.SearchKey, sp.NewerThan); x != nil {
+		return x.Reactions, x.Created, nil
+	}
+
+	klog.V(1).Infof("cache miss for %s newer than %s", sp.SearchKey, sp.NewerThan)
+	if !sp.Fetch {
+		return nil, time.Time{}, nil
+	}
+	return h.updateReactions(ctx, sp, pr)
+}
+
+func (h *Engine) updateReactions(ctx context.Context, sp provider.SearchParams, pr bool) ([]*provider.Reaction, time.Time, error) {
+	klog.V(1).Infof("Downloading reactions for %s/%s #%d", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
+	start := time.Now()
+
+	sp.ListOptions = provider.ListOptions{PerPage: 100}
+
+	var allReactions []*provider.Reaction
+	for {
+		klog.V(2).Infof("Downloading reactions for %s/%s #%d (page %d)...",
+			sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber, sp.ListOptions.Page)
+
+		p := provider.ResolveProviderByHost(sp.Repo.Host)
+		list := p.IssuesListReactions
+		if pr {
+			list = p.PullRequestsListReactions
+		}
+
+		rs, resp, err := list(ctx, sp)
+		if err != nil {
+			if h.cacheNegative(sp, err) {
+				return nil, start, nil
+			}
+			return rs, start, err
+		}
+
+		h.logRate(resp.Rate)
+
+		allReactions = append(allReactions, rs...)
+		if resp.NextPage == 0 {
+			break
+		}
+		sp.ListOptions.Page = resp.NextPage
+	}
+
+	if err := h.cache.Set(sp.SearchKey, &provider.Thing{Reactions: allReactions}); err != nil {
+		klog.Errorf("set %q failed: %v", sp.SearchKey, err)
+	}
+
+	return allReactions, start, nil
+}
+
+// reactors maps the lower-case login of each user who reacted to the content of their reactions, such as "+1"
+func reactors(rs []*provider.Reaction) map[string][]string {
+	m := map[string][]string{}
+	for _, r := range rs {
+		login := strings.ToLower(r.GetUser().GetLogin())
+		m[login] = append(m[login], r.GetContent())
+	}
+	return m
+}
+
+// matchReactedBy matches items reacted to by a login, optionally with a specific reaction, such as "tstromberg:+1"
+func matchReactedBy(co *Conversation, s string) bool {
+	login, content := s, ""
+	if i := strings.LastIndex(s, ":"); i > 0 {
+		login, content = s[:i], s[i+1:]
+	}
+
+	rs, ok := co.Reactors[strings.ToLower(login)]
+	if !ok {
+		return false
+	}
+	if content == "" {
+		return true
+	}
+
+	for _, r := range rs {
+		if strings.EqualFold(r, content) {
+			return true
+		}
+	}
+	return false
+}
+
+// memberReactions counts reactions left by project members. As reactions do not include an author
+// association, roles are inferred from the comments each user has left.
+func (h *Engine) memberReactions(rs []*provider.Reaction, cs []*provider.Comment) int {
+	roles := map[string]string{}
+	for _, c := range cs {
+		roles[c.User.GetLogin()] = c.AuthorAssoc
+	}
+	seen := h.memberLogins(nil, cs)
+
+	count := 0
+	for _, r := range rs {
+		login := r.GetUser().GetLogin()
+		if h.isMember(login, roles[login], seen) && !h.isBot(r.GetUser()) {
+			count++
+		}
+	}
+	return count
+}
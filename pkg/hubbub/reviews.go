@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"context"
+	"github.com/google/triage-party/pkg/constants"
 	"github.com/google/triage-party/pkg/tag"
 	"k8s.io/klog/v2"
 )
@@ -28,15 +29,34 @@ import (
 func (h *Engine) cachedReviews(ctx context.Context, sp provider.SearchParams) ([]*provider.PullRequestReview, time.Time, error) {
 	sp.SearchKey = fmt.Sprintf("%s-%s-%d-pr-reviews", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
 
-	if x := h.cache.GetNewerThan(sp.SearchKey, sp.NewerThan); x != nil {
-		return x.Reviews, x.Created, nil
+	if x := h.cachedThing(sp.SearchKey, sp.NewerThan); x != nil {
+		return h.submittedReviews(x.Reviews), x.Created, nil
 	}
 
 	klog.V(1).Infof("cache miss for %s newer than %s", sp.SearchKey, sp.NewerThan)
 	if !sp.Fetch {
 		return nil, time.Time{}, nil
 	}
-	return h.updateReviews(ctx, sp)
+
+	reviews, created, err := h.updateReviews(ctx, sp)
+	return h.submittedReviews(reviews), created, err
+}
+
+// submittedReviews drops pending reviews, which are unsubmitted drafts visible only to the owner of the API token
+func (h *Engine) submittedReviews(reviews []*provider.PullRequestReview) []*provider.PullRequestReview {
+	if h.countPendingReviews {
+		return reviews
+	}
+
+	submitted := []*provider.PullRequestReview{}
+	for _, r := range reviews {
+		if r.GetState() == Pending {
+			klog.V(1).Infof("ignoring pending review by %s", r.GetUser().GetLogin())
+			continue
+		}
+		submitted = append(submitted, r)
+	}
+	return submitted
 }
 
 func (h *Engine) updateReviews(ctx context.Context, sp provider.SearchParams) ([]*provider.PullRequestReview, time.Time, error) {
@@ -54,6 +74,9 @@ func (h *Engine) updateReviews(ctx context.Context, sp provider.SearchParams) ([
 		cs, resp, err := p.PullRequestsListReviews(ctx, sp)
 
 		if err != nil {
+			if h.cacheNegative(sp, err) {
+				return nil, start, nil
+			}
 			return cs, start, err
 		}
 
@@ -141,6 +164,116 @@ func reviewState(pr provider.IItem, timeline []*provider.Timeline, reviews []*pr
 	return state
 }
 
+// outstandingChanges returns whether any reviewer's latest review requested changes, with no push since
+func outstandingChanges(timeline []*provider.Timeline, reviews []*provider.PullRequestReview) bool {
+	lastCommitID := ""
+	lastPushTime := time.Time{}
+
+	for _, t := range timeline {
+		if t.GetEvent() == "head_ref_force_pushed" {
+			lastPushTime = t.GetCreatedAt()
+		}
+
+		if t.GetEvent() == "committed" {
+			commit := t.GetCommitID()
+			if commit == "" && strings.Contains(t.GetURL(), "/commits/") {
+				parts := strings.Split(t.GetURL(), "/")
+				commit = parts[len(parts)-1]
+			}
+			lastCommitID = commit
+		}
+	}
+
+	latest := map[string]*provider.PullRequestReview{}
+	for _, r := range reviews {
+		login := r.GetUser().GetLogin()
+		// Comments do not change whether a reviewer is satisfied
+		if r.GetState() == Commented {
+			continue
+		}
+		if ex, ok := latest[login]; ok && ex.GetSubmittedAt().After(r.GetSubmittedAt()) {
+			continue
+		}
+		latest[login] = r
+	}
+
+	for login, r := range latest {
+		if r.GetState() != ChangesRequested {
+			continue
+		}
+		if lastCommitID != "" && r.GetCommitID() != "" && r.GetCommitID() != lastCommitID {
+			continue
+		}
+		if lastPushTime.After(r.GetSubmittedAt()) {
+			continue
+		}
+		klog.V(1).Infof("changes requested by %s at %s are outstanding", login, r.GetSubmittedAt())
+		return true
+	}
+	return false
+}
+
+// Who a PR is waiting on, as in Conversation.PRBallIn
+const (
+	BallInAuthor   = "author"
+	BallInReviewer = "reviewer"
+	BallInNone     = "none"
+)
+
+// prBallIn returns who a PR is waiting on. In order of precedence:
+//
+// merged or closed: none
+// draft: author, as it is not ready for review
+// changes requested, with no push since: author
+// commented on the latest commit: author, unless they have responded since the latest review
+// anything else, including approved but unmerged: reviewer
+func prBallIn(co *Conversation, reviews []*provider.PullRequestReview) string {
+	if co.ReviewState == Merged || co.ReviewState == Closed || co.State == constants.ClosedState {
+		return BallInNone
+	}
+	if co.Tags[tag.Draft] || co.OutstandingChanges {
+		return BallInAuthor
+	}
+
+	if co.ReviewState == Commented {
+		lastReview := time.Time{}
+		for _, r := range reviews {
+			if r.GetSubmittedAt().After(lastReview) {
+				lastReview = r.GetSubmittedAt()
+			}
+		}
+		if lastReview.After(co.LatestAuthorResponse) {
+			return BallInAuthor
+		}
+	}
+	return BallInReviewer
+}
+
+// ValidBallIn returns an error if a ball-in filter contains a value other than author, reviewer, or none
+func ValidBallIn(s string) error {
+	for _, v := range strings.Split(s, ",") {
+		switch strings.TrimSpace(strings.ToLower(v)) {
+		case BallInAuthor, BallInReviewer, BallInNone:
+		default:
+			return fmt.Errorf("%q is not one of %s, %s, or %s", v, BallInAuthor, BallInReviewer, BallInNone)
+		}
+	}
+	return nil
+}
+
+// matchBallIn matches PR's waiting on anyone within a comma-separated list, such as "author,none". Issues never match.
+func matchBallIn(co *Conversation, s string) bool {
+	if co.PRBallIn == "" {
+		return false
+	}
+	for _, v := range strings.Split(s, ",") {
+		if strings.TrimSpace(strings.ToLower(v)) == co.PRBallIn {
+			return true
+		}
+	}
+	return false
+}
+
 func reviewStateTag(st string) tag.Tag {
 	switch st {
 	case Approved:
@@ -0,0 +1,141 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+)
+
+var reviewBase = time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func committed(id string, hours int) *provider.Timeline {
+	event := "committed"
+	at := reviewBase.Add(time.Duration(hours) * time.Hour)
+	return &provider.Timeline{Event: &event, CommitID: &id, CreatedAt: &at}
+}
+
+func forcePushed(hours int) *provider.Timeline {
+	event := "head_ref_force_pushed"
+	at := reviewBase.Add(time.Duration(hours) * time.Hour)
+	return &provider.Timeline{Event: &event, CreatedAt: &at}
+}
+
+func review(login string, state string, commit string, hours int) *provider.PullRequestReview {
+	at := reviewBase.Add(time.Duration(hours) * time.Hour)
+	return &provider.PullRequestReview{User: &provider.User{Login: &login}, State: &state, CommitID: &commit, SubmittedAt: &at}
+}
+
+func TestOutstandingChanges(t *testing.T) {
+	// GitHub may omit the commit ID, leaving it within the URL
+	event := "committed"
+	url := "https://api.github.com/repos/org/project/git/commits/def"
+	at := reviewBase.Add(2 * time.Hour)
+	urlOnly := &provider.Timeline{Event: &event, URL: &url, CreatedAt: &at}
+
+	tests := []struct {
+		name     string
+		timeline []*provider.Timeline
+		reviews  []*provider.PullRequestReview
+		want     bool
+		ballIn   string
+	}{
+		{
+			name:     "no reviews",
+			timeline: []*provider.Timeline{committed("abc", 0)},
+			want:     false,
+			ballIn:   BallInReviewer,
+		},
+		{
+			name:     "changes requested",
+			timeline: []*provider.Timeline{committed("abc", 0)},
+			reviews:  []*provider.PullRequestReview{review("r", ChangesRequested, "abc", 1)},
+			want:     true,
+			ballIn:   BallInAuthor,
+		},
+		{
+			name:     "changes requested then new commit",
+			timeline: []*provider.Timeline{committed("abc", 0), committed("def", 2)},
+			reviews:  []*provider.PullRequestReview{review("r", ChangesRequested, "abc", 1)},
+			want:     false,
+			ballIn:   BallInReviewer,
+		},
+		{
+			name:     "changes requested then force-push",
+			timeline: []*provider.Timeline{committed("abc", 0), forcePushed(2)},
+			reviews:  []*provider.PullRequestReview{review("r", ChangesRequested, "abc", 1)},
+			want:     false,
+			ballIn:   BallInReviewer,
+		},
+		{
+			name:     "force-push then changes requested",
+			timeline: []*provider.Timeline{committed("abc", 0), forcePushed(1)},
+			reviews:  []*provider.PullRequestReview{review("r", ChangesRequested, "abc", 2)},
+			want:     true,
+			ballIn:   BallInAuthor,
+		},
+		{
+			name:     "changes requested then approved by same reviewer",
+			timeline: []*provider.Timeline{committed("abc", 0)},
+			reviews:  []*provider.PullRequestReview{review("r", ChangesRequested, "abc", 1), review("r", Approved, "abc", 2)},
+			want:     false,
+			ballIn:   BallInReviewer,
+		},
+		{
+			name:     "approved by another reviewer",
+			timeline: []*provider.Timeline{committed("abc", 0)},
+			reviews:  []*provider.PullRequestReview{review("r", ChangesRequested, "abc", 1), review("other", Approved, "abc", 2)},
+			want:     true,
+			ballIn:   BallInAuthor,
+		},
+		{
+			name:     "commented after changes requested",
+			timeline: []*provider.Timeline{committed("abc", 0)},
+			reviews:  []*provider.PullRequestReview{review("r", ChangesRequested, "abc", 1), review("r", Commented, "abc", 2)},
+			want:     true,
+			ballIn:   BallInAuthor,
+		},
+		{
+			name:     "new commit without an ID",
+			timeline: []*provider.Timeline{committed("abc", 0), urlOnly},
+			reviews:  []*provider.PullRequestReview{review("r", ChangesRequested, "abc", 1)},
+			want:     false,
+			ballIn:   BallInReviewer,
+		},
+		{
+			name:     "changes requested on commit without an ID",
+			timeline: []*provider.Timeline{urlOnly},
+			reviews:  []*provider.PullRequestReview{review("r", ChangesRequested, "def", 3)},
+			want:     true,
+			ballIn:   BallInAuthor,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := outstandingChanges(tc.timeline, tc.reviews)
+			if got != tc.want {
+				t.Errorf("outstandingChanges() = %v, want %v", got, tc.want)
+			}
+
+			co := &Conversation{ReviewState: reviewState(&provider.PullRequest{}, tc.timeline, tc.reviews), OutstandingChanges: got}
+			if ball := prBallIn(co, tc.reviews); ball != tc.ballIn {
+				t.Errorf("prBallIn() = %q, want %q (review state %s)", ball, tc.ballIn, co.ReviewState)
+			}
+		})
+	}
+}
@@ -32,25 +32,225 @@ func (h *Engine) SearchAny(ctx context.Context, sp provider.SearchParams) ([]*Co
 		ts = pts
 	}
 
-	return append(cs, pcs...), ts, nil
+	return mergeByURL(cs, pcs), ts, nil
+}
+
+// mergeByURL appends PR results to issue results, dropping duplicates. If issues_include_prs is set, a PR can be
+// found via both searches: the PR-typed result is kept, as it was built from the PR itself.
+func mergeByURL(issues []*Conversation, prs []*Conversation) []*Conversation {
+	seen := map[string]bool{}
+	for _, co := range prs {
+		seen[co.URL] = true
+	}
+
+	merged := []*Conversation{}
+	for _, co := range issues {
+		if seen[co.URL] {
+			klog.V(1).Infof("%s was found as both an issue and a PR", co.URL)
+			continue
+		}
+		seen[co.URL] = true
+		merged = append(merged, co)
+	}
+	return append(merged, prs...)
 }
 
 // Search for GitHub issues or PR's
 func (h *Engine) SearchIssues(ctx context.Context, sp provider.SearchParams) ([]*Conversation, time.Time, error) {
+	sp.Repo = h.canonicalRepo(sp.Repo)
 	sp.Filters = openByDefault(sp)
 	klog.V(1).Infof(
-		"Gathering raw data for %s/%s issues %s - newer than %s",
+		"Gathering raw data for %s/%s issues %v - newer than %s",
 		sp.Repo.Organization,
 		sp.Repo.Project,
 		sp.Filters,
 		logu.STime(sp.NewerThan),
 	)
+	is, age := h.issueCandidates(ctx, sp)
+
+	var filtered []*Conversation
+	klog.V(1).Infof("%s/%s aggregate issue count: %d, filtering for:\n%v", sp.Repo.Organization, sp.Repo.Project, len(is), sp.Filters)
+
+	var boards map[string]map[string]string
+	if needProjects(sp.Filters) {
+		boards = h.projectStatuses(ctx, sp)
+	}
+
+	ep := evalParams{age: age, mostRecentUpdate: mostRecentUpdate(is), boards: boards}
+	for _, i := range is {
+		if co, _ := h.evalIssue(ctx, &sp, ep, i, matchStage); co != nil {
+			filtered = append(filtered, co)
+		}
+	}
+
+	return filtered, age, nil
+}
+
+// stageFunc is called as an item reaches each filter stage, with a function which matches a set of filters against
+// the item at that stage. The item is evaluated further only if it returns true.
+type stageFunc func(name string, fs []provider.Filter, match func([]provider.Filter) bool) bool
+
+// matchStage is the stageFunc of a search: an item must match every filter at every stage
+func matchStage(_ string, fs []provider.Filter, match func([]provider.Filter) bool) bool {
+	return match(fs)
+}
+
+// evalParams are shared by every item evaluated within a search
+type evalParams struct {
+	age time.Time
+
+	// the most recent update of any candidate, to which linked PR's are updated
+	mostRecentUpdate time.Time
+
+	// project board statuses, if the filters require them
+	boards map[string]map[string]string
+
+	// fetch every comment, reaction, timeline event, and review, regardless of whether the filters require them
+	fetchAll bool
+}
+
+// itemData is the raw data fetched while evaluating an item
+type itemData struct {
+	comments []*provider.Comment
+	timeline []*provider.Timeline
+	reviews  []*provider.PullRequestReview
+}
+
+// mostRecentUpdate returns the latest update time of a set of issues
+func mostRecentUpdate(is []*provider.Issue) time.Time {
+	// Avoids updating PR references on a quiet repository
+	latest := time.Time{}
+	for _, i := range is {
+		if i.GetUpdatedAt().After(latest) {
+			latest = i.GetUpdatedAt()
+		}
+	}
+	return latest
+}
+
+// evalIssue evaluates an issue stage by stage, returning nil if a stage rejected it. sp is updated as data is
+// fetched, so that later items within the same search see the freshness of earlier ones.
+func (h *Engine) evalIssue(ctx context.Context, sp *provider.SearchParams, ep evalParams, i *provider.Issue, stage stageFunc) (*Conversation, *itemData) {
+	data := &itemData{}
+
+	// Inconsistency warning: issues use a list of labels, prs a list of label pointers
+	labels := []*provider.Label{}
+	for _, l := range i.Labels {
+		l := l
+		labels = append(labels, l)
+	}
+
+	if !stage(StagePreFetch, sp.Filters, func(fs []provider.Filter) bool {
+		return preFetchMatch(i, labels, fs) && h.businessMatch(i, fs)
+	}) {
+		klog.V(1).Infof("#%d - %q did not match item filter: %v", i.GetNumber(), i.GetTitle(), sp.Filters)
+		return nil, data
+	}
+
+	klog.V(1).Infof("#%d - %q made it past pre-fetch: %v", i.GetNumber(), i.GetTitle(), sp.Filters)
+
+	fetchComments := ep.fetchAll
+	if needComments(i, sp.Filters, sp.Tags) {
+		klog.V(1).Infof("#%d - %q: need comments for final filtering", i.GetNumber(), i.GetTitle())
+		fetchComments = fetchComments || !sp.NewerThan.IsZero()
+	}
+	fetchReactions := ep.fetchAll || !sp.NewerThan.IsZero()
+
+	sp.IssueNumber = i.GetNumber()
+	sp.NewerThan = h.mtime(i)
+	sp.Fetch = fetchComments
+	sp.CommentLimit = 0
+	if !ep.fetchAll && !needFullComments(sp.Filters, sp.Tags) {
+		sp.CommentLimit = h.commentLimit
+	}
+
+	comments, _, err := h.cachedIssueComments(ctx, *sp)
+	sp.CommentLimit = 0
+	if err != nil {
+		klog.Errorf("comments: %v", err)
+	}
+	for _, c := range comments {
+		data.comments = append(data.comments, provider.NewComment(c))
+	}
+
+	co := h.IssueSummary(i, comments, ep.age)
+	h.setLabels(co, labels)
+
+	if ep.fetchAll || needReactionUsers(sp.Filters) || h.escalationNeedsReactions(sp.Filters, sp.Tags) {
+		sp.Fetch = fetchReactions
+		reactions, _, err := h.cachedReactions(ctx, *sp, false)
+		if err != nil {
+			klog.Errorf("reactions: %v", err)
+		}
+		co.MemberReactions = h.memberReactions(reactions, data.comments)
+		co.Reactors = reactors(reactions)
+	}
+
+	co.Similar = h.FindSimilar(co)
+	if len(co.Similar) > 0 {
+		co.Tags[tag.Similar] = true
+	}
+
+	if !stage(StagePostFetch, sp.Filters, func(fs []provider.Filter) bool { return postFetchMatch(co, fs) }) {
+		klog.V(1).Infof("#%d - %q did not match post-fetch filter: %v", i.GetNumber(), i.GetTitle(), sp.Filters)
+		return nil, data
+	}
+	klog.V(1).Infof("#%d - %q made it past post-fetch: %v", i.GetNumber(), i.GetTitle(), sp.Filters)
+
+	updatedAt := h.mtime(i)
+	fetchTimeline := ep.fetchAll
+	if needTimeline(i, sp.Filters, false, sp.Hidden, sp.Tags) || h.escalationNeedsTag(sp.Filters, sp.Tags, func(t tag.Tag) bool { return t.NeedsTimeline }) {
+		fetchTimeline = fetchTimeline || !sp.NewerThan.IsZero()
+	}
+
+	sp.IssueNumber = i.GetNumber()
+	sp.Fetch = fetchTimeline
+	sp.UpdateAt = updatedAt
+	sp.TimelineLimit = 0
+	if !ep.fetchAll && !needFullTimeline(sp.Filters) {
+		sp.TimelineLimit = h.maxTimelineEvents
+	}
+
+	data.timeline, err = h.cachedTimeline(ctx, *sp)
+	sp.TimelineLimit = 0
+	if err != nil {
+		klog.Errorf("timeline: %v", err)
+	}
+
+	h.addEvents(ctx, *sp, co, data.timeline)
+
+	// Some labels are judged by linked PR state. Ensure that they are updated to the same timestamp.
+	fetchReviews := ep.fetchAll
+	if needReviews(i, sp.Filters, sp.Hidden, sp.Tags) && len(co.PullRequestRefs) > 0 {
+		fetchReviews = fetchReviews || !sp.NewerThan.IsZero()
+	}
+	sp.NewerThan = ep.mostRecentUpdate
+	sp.Fetch = fetchReviews
+	co.PullRequestRefs = h.updateLinkedPRs(ctx, *sp, co)
+	h.escalate(co)
+	if ep.boards != nil {
+		setProjectStatus(co, ep.boards)
+	}
+	if needLinkCheck(sp.Filters) {
+		co.DeadLinks = h.deadLinks(ctx, i.GetBody())
+	}
+
+	if !stage(StagePostEvents, sp.Filters, func(fs []provider.Filter) bool { return postEventsMatch(co, fs) }) {
+		klog.V(1).Infof("#%d - %q did not match post-events filter: %v", i.GetNumber(), i.GetTitle(), sp.Filters)
+		return nil, data
+	}
+	klog.V(1).Infof("#%d - %q made it past post-events: %v", i.GetNumber(), i.GetTitle(), sp.Filters)
+
+	co.AttentionScore = AttentionScore(co, h.attention)
+	return co, data
+}
+
+// listIssues returns open issues, and closed issues if the filters require them
+func (h *Engine) listIssues(ctx context.Context, sp provider.SearchParams) ([]*provider.Issue, time.Time) {
 	var wg sync.WaitGroup
 
 	var open []*provider.Issue
 	var closed []*provider.Issue
-	var err error
-
 	age := time.Now()
 
 	wg.Add(1)
@@ -111,108 +311,15 @@ func (h *Engine) SearchIssues(ctx context.Context, sp provider.SearchParams) ([]
 			}
 		}
 
-		if seen[i.GetURL()] {
-			klog.Errorf("unusual: I already saw #%d", i.GetURL())
+		if seen[i.GetHTMLURL()] {
+			klog.Errorf("unusual: I already saw %s", i.GetHTMLURL())
 			continue
 		}
-		seen[i.GetURL()] = true
+		seen[i.GetHTMLURL()] = true
 		is = append(is, i)
 	}
 
-	var filtered []*Conversation
-	klog.V(1).Infof("%s/%s aggregate issue count: %d, filtering for:\n%s", sp.Repo.Organization, sp.Repo.Project, len(is), sp.Filters)
-
-	// Avoids updating PR references on a quiet repository
-	mostRecentUpdate := time.Time{}
-	for _, i := range is {
-		if i.GetUpdatedAt().After(mostRecentUpdate) {
-			mostRecentUpdate = i.GetUpdatedAt()
-		}
-	}
-
-	for _, i := range is {
-		// Inconsistency warning: issues use a list of labels, prs a list of label pointers
-		labels := []*provider.Label{}
-		for _, l := range i.Labels {
-			l := l
-			labels = append(labels, l)
-		}
-
-		if !preFetchMatch(i, labels, sp.Filters) {
-			klog.V(1).Infof("#%d - %q did not match item filter: %s", i.GetNumber(), i.GetTitle(), sp.Filters)
-			continue
-		}
-
-		klog.V(1).Infof("#%d - %q made it past pre-fetch: %s", i.GetNumber(), i.GetTitle(), sp.Filters)
-
-		comments := []*provider.IssueComment{}
-
-		fetchComments := false
-		if needComments(i, sp.Filters) && i.GetComments() > 0 {
-			klog.V(1).Infof("#%d - %q: need comments for final filtering", i.GetNumber(), i.GetTitle())
-			fetchComments = !sp.NewerThan.IsZero()
-		}
-
-		sp.IssueNumber = i.GetNumber()
-		sp.NewerThan = h.mtime(i)
-		sp.Fetch = fetchComments
-
-		comments, _, err = h.cachedIssueComments(ctx, sp)
-		if err != nil {
-			klog.Errorf("comments: %v", err)
-		}
-
-		co := h.IssueSummary(i, comments, age)
-		co.Labels = labels
-
-		co.Similar = h.FindSimilar(co)
-		if len(co.Similar) > 0 {
-			co.Tags[tag.Similar] = true
-		}
-
-		if !postFetchMatch(co, sp.Filters) {
-			klog.V(1).Infof("#%d - %q did not match post-fetch filter: %s", i.GetNumber(), i.GetTitle(), sp.Filters)
-			continue
-		}
-		klog.V(1).Infof("#%d - %q made it past post-fetch: %s", i.GetNumber(), i.GetTitle(), sp.Filters)
-
-		updatedAt := h.mtime(i)
-		var timeline []*provider.Timeline
-		fetchTimeline := false
-		if needTimeline(i, sp.Filters, false, sp.Hidden) {
-			fetchTimeline = !sp.NewerThan.IsZero()
-		}
-
-		sp.IssueNumber = i.GetNumber()
-		sp.Fetch = fetchTimeline
-		sp.UpdateAt = updatedAt
-
-		timeline, err = h.cachedTimeline(ctx, sp)
-		if err != nil {
-			klog.Errorf("timeline: %v", err)
-		}
-
-		h.addEvents(ctx, sp, co, timeline)
-
-		// Some labels are judged by linked PR state. Ensure that they are updated to the same timestamp.
-		fetchReviews := false
-		if needReviews(i, sp.Filters, sp.Hidden) && len(co.PullRequestRefs) > 0 {
-			fetchReviews = !sp.NewerThan.IsZero()
-		}
-		sp.NewerThan = mostRecentUpdate
-		sp.Fetch = fetchReviews
-		co.PullRequestRefs = h.updateLinkedPRs(ctx, sp, co)
-
-		if !postEventsMatch(co, sp.Filters) {
-			klog.V(1).Infof("#%d - %q did not match post-events filter: %s", i.GetNumber(), i.GetTitle(), sp.Filters)
-			continue
-		}
-		klog.V(1).Infof("#%d - %q made it past post-events: %s", i.GetNumber(), i.GetTitle(), sp.Filters)
-
-		filtered = append(filtered, co)
-	}
-
-	return filtered, age, nil
+	return is, age
 }
 
 // NeedsClosed returns whether or not the filters require closed items
@@ -227,6 +334,10 @@ func NeedsClosed(fs []provider.Filter) bool {
 			klog.V(1).Infof("will need closed items due to ClosedComments=%s", f.ClosedComments)
 			return true
 		}
+		if f.ClosedWithin != "" {
+			klog.V(1).Infof("will need closed items due to ClosedWithin=%s", f.ClosedWithin)
+			return true
+		}
 		if f.State != "" && ((f.State != constants.OpenState) && (f.State != constants.OpenedState)) {
 			klog.V(1).Infof("will need closed items due to State=%s", f.State)
 			return true
@@ -236,17 +347,142 @@ func NeedsClosed(fs []provider.Filter) bool {
 }
 
 func (h *Engine) SearchPullRequests(ctx context.Context, sp provider.SearchParams) ([]*Conversation, time.Time, error) {
+	sp.Repo = h.canonicalRepo(sp.Repo)
 	sp.Filters = openByDefault(sp)
 
-	klog.V(1).Infof("Gathering raw data for %s/%s PR's matching: %s - newer than %s",
+	klog.V(1).Infof("Gathering raw data for %s/%s PR's matching: %v - newer than %s",
 		sp.Repo.Organization, sp.Repo.Project, sp.Filters, logu.STime(sp.NewerThan))
 	filtered := []*Conversation{}
+	prs, age := h.pullRequestCandidates(ctx, sp)
+
+	var boards map[string]map[string]string
+	if needProjects(sp.Filters) {
+		boards = h.projectStatuses(ctx, sp)
+	}
+
+	ep := evalParams{age: age, boards: boards}
+	for _, pr := range prs {
+		if co, _ := h.evalPullRequest(ctx, &sp, ep, pr, matchStage); co != nil {
+			filtered = append(filtered, co)
+		}
+	}
+
+	return filtered, age, nil
+}
+
+// evalPullRequest evaluates a PR stage by stage, returning nil if a stage rejected it. sp is updated as data is
+// fetched, so that later items within the same search see the freshness of earlier ones.
+func (h *Engine) evalPullRequest(ctx context.Context, sp *provider.SearchParams, ep evalParams, pr *provider.PullRequest, stage stageFunc) (*Conversation, *itemData) {
+	data := &itemData{}
+
+	if !stage(StagePreFetch, sp.Filters, func(fs []provider.Filter) bool {
+		return preFetchMatch(pr, pr.Labels, fs) && h.businessMatch(pr, fs)
+	}) {
+		return nil, data
+	}
+
+	fetchComments := ep.fetchAll
+	if needComments(pr, sp.Filters, sp.Tags) {
+		fetchComments = fetchComments || !sp.NewerThan.IsZero()
+	}
+	fetchReactions := ep.fetchAll || !sp.NewerThan.IsZero()
+
+	sp.IssueNumber = pr.GetNumber()
+	sp.NewerThan = h.mtime(pr)
+	sp.Fetch = fetchComments
+	sp.CommentLimit = 0
+	if !ep.fetchAll && !needFullComments(sp.Filters, sp.Tags) {
+		sp.CommentLimit = h.commentLimit
+	}
+
+	var err error
+	data.comments, _, err = h.prComments(ctx, *sp)
+	sp.CommentLimit = 0
+	if err != nil {
+		klog.Errorf("comments: %v", err)
+	}
 
+	fetchTimeline := ep.fetchAll
+	if needTimeline(pr, sp.Filters, true, sp.Hidden, sp.Tags) || h.escalationNeedsTag(sp.Filters, sp.Tags, func(t tag.Tag) bool { return t.NeedsTimeline || t.NeedsReviews }) {
+		fetchTimeline = fetchTimeline || !sp.NewerThan.IsZero()
+	}
+
+	sp.IssueNumber = pr.GetNumber()
+	sp.NewerThan = h.mtime(pr)
+	sp.Fetch = fetchTimeline
+
+	data.timeline, err = h.cachedTimeline(ctx, *sp)
+	if err != nil {
+		klog.Errorf("timeline: %v", err)
+	}
+
+	fetchReviews := ep.fetchAll
+	if needReviews(pr, sp.Filters, sp.Hidden, sp.Tags) || h.escalationNeedsTag(sp.Filters, sp.Tags, func(t tag.Tag) bool { return t.NeedsReviews }) {
+		fetchReviews = fetchReviews || !sp.NewerThan.IsZero()
+	}
+
+	sp.IssueNumber = pr.GetNumber()
+	sp.NewerThan = h.mtime(pr)
+	sp.Fetch = fetchReviews
+
+	data.reviews, _, err = h.cachedReviews(ctx, *sp)
+	if err != nil {
+		klog.Errorf("reviews: %v", err)
+		return nil, data
+	}
+
+	if h.debug[pr.GetNumber()] {
+		klog.Errorf("*** Debug PR timeline #%d:\n%s", pr.GetNumber(), formatStruct(data.timeline))
+	}
+
+	sp.Fetch = ep.fetchAll || !sp.NewerThan.IsZero()
+	sp.Age = ep.age
+
+	co := h.PRSummary(ctx, *sp, pr, data.comments, data.timeline, data.reviews)
+	h.setLabels(co, pr.Labels)
+
+	if ep.fetchAll || needReactionUsers(sp.Filters) || h.escalationNeedsReactions(sp.Filters, sp.Tags) {
+		sp.Fetch = fetchReactions
+		reactions, _, err := h.cachedReactions(ctx, *sp, true)
+		if err != nil {
+			klog.Errorf("reactions: %v", err)
+		}
+		co.MemberReactions = h.memberReactions(reactions, data.comments)
+		co.Reactors = reactors(reactions)
+	}
+	co.Similar = h.FindSimilar(co)
+	if len(co.Similar) > 0 {
+		co.Tags[tag.Similar] = true
+	}
+
+	if !stage(StagePostFetch, sp.Filters, func(fs []provider.Filter) bool { return postFetchMatch(co, fs) }) {
+		klog.V(4).Infof("PR #%d did not pass postFetchMatch with filter: %v", pr.GetNumber(), sp.Filters)
+		return nil, data
+	}
+
+	h.escalate(co)
+	if ep.boards != nil {
+		setProjectStatus(co, ep.boards)
+	}
+	if needLinkCheck(sp.Filters) {
+		co.DeadLinks = h.deadLinks(ctx, pr.GetBody())
+	}
+
+	if !stage(StagePostEvents, sp.Filters, func(fs []provider.Filter) bool { return postEventsMatch(co, fs) }) {
+		klog.V(1).Infof("#%d - %q did not match post-events filter: %v", pr.GetNumber(), pr.GetTitle(), sp.Filters)
+		return nil, data
+	}
+
+	co.AttentionScore = AttentionScore(co, h.attention)
+	return co, data
+}
+
+// listPullRequests returns open PR's, and closed PR's if the filters require them
+func (h *Engine) listPullRequests(ctx context.Context, sp provider.SearchParams) ([]*provider.PullRequest, time.Time) {
 	var wg sync.WaitGroup
 
 	var open []*provider.PullRequest
 	var closed []*provider.PullRequest
-	var err error
 	age := time.Now()
 
 	wg.Add(1)
@@ -313,89 +549,67 @@ func (h *Engine) SearchPullRequests(ctx context.Context, sp provider.SearchParam
 		prs = append(prs, pr)
 	}
 
-	for _, pr := range prs {
-		if !preFetchMatch(pr, pr.Labels, sp.Filters) {
-			continue
-		}
-
-		var timeline []*provider.Timeline
-		var reviews []*provider.PullRequestReview
-		var comments []*provider.Comment
-
-		fetchComments := false
-		if needComments(pr, sp.Filters) {
-			fetchComments = !sp.NewerThan.IsZero()
-		}
-
-		sp.IssueNumber = pr.GetNumber()
-		sp.NewerThan = h.mtime(pr)
-		sp.Fetch = fetchComments
+	return prs, age
+}
 
-		comments, _, err = h.prComments(ctx, sp)
-		if err != nil {
-			klog.Errorf("comments: %v", err)
-		}
+// usedTagsNeed returns whether any tag a collection declares it uses requires an enrichment
+func usedTagsNeed(used []string, need func(tag.Tag) bool) bool {
+	ids := map[string]bool{}
+	for _, id := range used {
+		ids[id] = true
+	}
 
-		fetchTimeline := false
-		if needTimeline(pr, sp.Filters, true, sp.Hidden) {
-			fetchTimeline = !sp.NewerThan.IsZero()
+	for _, t := range tag.All() {
+		if ids[t.ID] && need(t) {
+			return true
 		}
+	}
+	return false
+}
 
-		sp.IssueNumber = pr.GetNumber()
-		sp.NewerThan = h.mtime(pr)
-		sp.Fetch = fetchTimeline
-
-		timeline, err = h.cachedTimeline(ctx, sp)
-		if err != nil {
-			klog.Errorf("timeline: %v", err)
-		}
+// needFullComments returns whether filters or tags need every comment, rather than only the most recent ones. used
+// is the list of tags the collection uses, or nil for all. Hold time, and the send/recv tags derived from it, need the
+// full history.
+func needFullComments(fs []provider.Filter, used []string) bool {
+	if used == nil {
+		return true
+	}
 
-		fetchReviews := false
-		if needReviews(pr, sp.Filters, sp.Hidden) {
-			fetchReviews = !sp.NewerThan.IsZero()
+	for _, f := range fs {
+		if f.Responded != "" || f.Hold != "" || f.Commenters != "" || f.CommentersPerMonth != "" || f.ClosedComments != "" || f.ClosedCommenters != "" {
+			return true
 		}
-
-		sp.IssueNumber = pr.GetNumber()
-		sp.NewerThan = h.mtime(pr)
-		sp.Fetch = fetchReviews
-
-		reviews, _, err = h.cachedReviews(ctx, sp)
-		if err != nil {
-			klog.Errorf("reviews: %v", err)
-			continue
+		if f.TopCommentReacts != "" || f.MemberReactions != "" || f.MemberCommentRatio != "" || f.UntouchedByMembers != nil {
+			return true
 		}
-
-		if h.debug[pr.GetNumber()] {
-			klog.Errorf("*** Debug PR timeline #%d:\n%s", pr.GetNumber(), formatStruct(timeline))
+		// Bounties may be posted at any point, and reactions are summed across every comment
+		if f.Bounty != "" || f.Reactions != "" || f.ReactionsPerMonth != "" {
+			return true
 		}
-
-		sp.Fetch = !sp.NewerThan.IsZero()
-		sp.Age = age
-
-		co := h.PRSummary(ctx, sp, pr, comments, timeline, reviews)
-		co.Labels = pr.Labels
-		co.Similar = h.FindSimilar(co)
-		if len(co.Similar) > 0 {
-			co.Tags[tag.Similar] = true
+		if f.TagRegex() != nil {
+			for _, t := range tag.All() {
+				if f.TagRegex().MatchString(t.ID) && t.NeedsComments && !recentCommentTag(t) {
+					return true
+				}
+			}
 		}
+	}
 
-		if !postFetchMatch(co, sp.Filters) {
-			klog.V(4).Infof("PR #%d did not pass postFetchMatch with filter: %v", pr.GetNumber(), sp.Filters)
-			continue
-		}
+	return usedTagsNeed(used, func(t tag.Tag) bool { return t.NeedsComments && !recentCommentTag(t) })
+}
 
-		if !postEventsMatch(co, sp.Filters) {
-			klog.V(1).Infof("#%d - %q did not match post-events filter: %s", pr.GetNumber(), pr.GetTitle(), sp.Filters)
-			continue
-		}
+// recentCommentTag returns whether a tag can be determined from the most recent comments alone
+func recentCommentTag(t tag.Tag) bool {
+	return strings.HasSuffix(t.ID, "-last")
+}
 
-		filtered = append(filtered, co)
+// needComments returns whether comments are required. used is the list of tags the collection uses, or nil for all.
+func needComments(i provider.IItem, fs []provider.Filter, used []string) bool {
+	// Nothing to fetch: filters see that no member has responded
+	if knownNoComments(i) {
+		return false
 	}
 
-	return filtered, age, nil
-}
-
-func needComments(i provider.IItem, fs []provider.Filter) bool {
 	for _, f := range fs {
 		if f.TagRegex() != nil {
 			if ok, t := matchTag(tag.Tags, f.TagRegex(), f.TagNegate()); ok {
@@ -411,17 +625,62 @@ func needComments(i provider.IItem, fs []provider.Filter) bool {
 			return true
 		}
 
-		if f.Responded != "" || f.Commenters != "" {
-			klog.Infof("#%d - need comments due to responded/commenters filter", i.GetNumber())
+		if f.Responded != "" || f.Hold != "" || f.Commenters != "" || f.LastTouchedBy != "" {
+			klog.Infof("#%d - need comments due to responded/hold/commenters/last-touched-by filter", i.GetNumber())
+			return true
+		}
+
+		if f.RawLastComment != "" || f.LastCommentByMember != nil || f.BotLast != nil {
+			klog.Infof("#%d - need comments due to last-comment filter", i.GetNumber())
+			return true
+		}
+
+		if f.Bounty != "" || f.TopCommentReacts != "" || f.MemberCommentRatio != "" {
+			klog.Infof("#%d - need comments due to bounty/top-comment-reactions/member-comment-ratio filter", i.GetNumber())
 			return true
 		}
+
+		if f.BallIn != "" {
+			klog.Infof("#%d - need comments due to ball-in filter", i.GetNumber())
+			return true
+		}
+
+		if f.UntouchedByMembers != nil {
+			klog.Infof("#%d - need comments due to untouched-by-members filter", i.GetNumber())
+			return true
+		}
+	}
+
+	if used != nil && !usedTagsNeed(used, func(t tag.Tag) bool { return t.NeedsComments }) {
+		return false
 	}
 
 	return (i.GetState() == constants.OpenState) || (i.GetState() == constants.OpenedState)
 }
 
-func needTimeline(i provider.IItem, fs []provider.Filter, pr bool, hidden bool) bool {
-	if i.GetMilestone() != nil {
+// knownNoComments returns whether an item is known to have no comments. PR listings omit comment counts,
+// so a PR is only known to have none if the counts are present.
+func knownNoComments(i provider.IItem) bool {
+	pr, ok := i.(*provider.PullRequest)
+	if !ok {
+		return i.GetComments() == 0
+	}
+	return pr.Comments != nil && pr.ReviewComments != nil && pr.GetComments() == 0 && pr.GetReviewComments() == 0
+}
+
+// needReactionUsers returns whether filters depend on who reacted to an item, which costs an API call per item
+func needReactionUsers(fs []provider.Filter) bool {
+	for _, f := range fs {
+		if f.MemberReactions != "" || f.ReactedBy != "" || f.UntouchedByMembers != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// needTimeline returns whether the timeline is required. used is the list of tags the collection uses, or nil for all.
+func needTimeline(i provider.IItem, fs []provider.Filter, pr bool, hidden bool, used []string) bool {
+	if i.GetMilestone() != nil && (used == nil || usedTagsNeed(used, func(t tag.Tag) bool { return t == tag.OpenMilestone })) {
 		return true
 	}
 
@@ -433,11 +692,32 @@ func needTimeline(i provider.IItem, fs []provider.Filter, pr bool, hidden bool)
 		return false
 	}
 
-	if pr {
+	if pr && used == nil {
 		return true
 	}
 
 	for _, f := range fs {
+		if f.OutstandingChanges != nil || f.BallIn != "" {
+			return true
+		}
+		if f.LinkedPRState != "" || f.LinkedPRByMember != nil || f.LinkedPRChecks != "" {
+			return true
+		}
+		if f.HasLinkedIssue != nil {
+			return true
+		}
+		if f.LastTouchedBy != "" || f.UntouchedByMembers != nil {
+			return true
+		}
+		if f.LabeledWithin != "" || f.RenamedWithin != "" {
+			return true
+		}
+		if f.ForcePushes != "" || f.ReopenCount != "" || f.StateAge != "" {
+			return true
+		}
+		if f.AbandonedAssignment != nil || f.Unpicked != nil {
+			return true
+		}
 		if f.TagRegex() != nil {
 			if ok, t := matchTag(tag.Tags, f.TagRegex(), f.TagNegate()); ok {
 				if t.NeedsTimeline {
@@ -445,15 +725,38 @@ func needTimeline(i provider.IItem, fs []provider.Filter, pr bool, hidden bool)
 				}
 			}
 		}
-		if f.Prioritized != "" {
+		if f.Prioritized != "" || f.TimeToFirstLabel != "" {
 			return true
 		}
 	}
 
+	// PR review state is calculated from the timeline
+	if used != nil {
+		return usedTagsNeed(used, func(t tag.Tag) bool { return t.NeedsTimeline || (pr && t.NeedsReviews) })
+	}
+
 	return !hidden
 }
 
-func needReviews(i provider.IItem, fs []provider.Filter, hidden bool) bool {
+// needFullTimeline returns whether filters depend on older timeline events, such as when a priority label was added
+// or how often an item was reopened
+func needFullTimeline(fs []provider.Filter) bool {
+	for _, f := range fs {
+		if f.Prioritized != "" || f.TimeToFirstLabel != "" {
+			return true
+		}
+		if f.ReopenCount != "" || f.StateAge != "" {
+			return true
+		}
+		if f.AbandonedAssignment != nil || f.Unpicked != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// needReviews returns whether reviews are required. used is the list of tags the collection uses, or nil for all.
+func needReviews(i provider.IItem, fs []provider.Filter, hidden bool, used []string) bool {
 	if (i.GetState() != constants.OpenState) && (i.GetState() != constants.OpenedState) {
 		return false
 	}
@@ -462,6 +765,17 @@ func needReviews(i provider.IItem, fs []provider.Filter, hidden bool) bool {
 		return false
 	}
 
+	for _, f := range fs {
+		if f.OutstandingChanges != nil || f.BallIn != "" {
+			klog.V(1).Infof("#%d - need reviews due to outstanding-changes/ball-in filter", i.GetNumber())
+			return true
+		}
+		if f.LinkedPRState != "" || f.LinkedPRByMember != nil || f.LinkedPRChecks != "" {
+			klog.V(1).Infof("#%d - need reviews due to linked PR filter", i.GetNumber())
+			return true
+		}
+	}
+
 	if hidden {
 		return false
 	}
@@ -477,6 +791,11 @@ func needReviews(i provider.IItem, fs []provider.Filter, hidden bool) bool {
 		}
 	}
 
+	// Cross-referenced PR tags on issues depend on the reviews of linked PR's
+	if used != nil {
+		return usedTagsNeed(used, func(t tag.Tag) bool { return t.NeedsReviews || t.NeedsTimeline })
+	}
+
 	return true
 }
 
@@ -0,0 +1,820 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hubbub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v31/github"
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/google/triage-party/pkg/similarity"
+)
+
+func TestNeedFullComments(t *testing.T) {
+	tagFilter := func(re string) provider.Filter {
+		f := provider.Filter{RawTag: re}
+		if err := f.LoadTagRegex(); err != nil {
+			t.Fatalf("load tag regex: %v", err)
+		}
+		return f
+	}
+
+	tests := []struct {
+		name string
+		fs   []provider.Filter
+		used []string
+		want bool
+	}{
+		{"all tags", nil, nil, true},
+		{"no tags", nil, []string{}, false},
+		{"recent tags", nil, []string{"author-last", "member-last"}, false},
+		{"bounty tag", nil, []string{"bountied"}, true},
+		{"bounty", []provider.Filter{{Bounty: ">0"}}, []string{}, true},
+		{"reactions", []provider.Filter{{Reactions: ">5"}}, []string{}, true},
+		{"reactions per month", []provider.Filter{{ReactionsPerMonth: ">1"}}, []string{}, true},
+		{"hold time tag", nil, []string{"recv"}, true},
+		{"last touched", []provider.Filter{{LastTouchedBy: "member"}}, []string{}, false},
+		{"responded", []provider.Filter{{Responded: "+1d"}}, []string{}, true},
+		{"recent tag filter", []provider.Filter{tagFilter("author-last")}, []string{}, false},
+		{"hold time tag filter", []provider.Filter{tagFilter("send")}, []string{}, true},
+	}
+	for _, tc := range tests {
+		if got := needFullComments(tc.fs, tc.used); got != tc.want {
+			t.Errorf("%s: needFullComments = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestNeedComments(t *testing.T) {
+	n := func(i int) *int { return &i }
+	open := "open"
+	responded := []provider.Filter{{Responded: "+1d"}}
+
+	tests := []struct {
+		name string
+		i    provider.IItem
+		want bool
+	}{
+		{"issue without comments", &provider.Issue{State: &open, Comments: n(0)}, false},
+		{"issue with comments", &provider.Issue{State: &open, Comments: n(3)}, true},
+		// PR listings omit comment counts
+		{"listed PR", &provider.PullRequest{State: &open}, true},
+		{"PR without comments", &provider.PullRequest{State: &open, Comments: n(0), ReviewComments: n(0)}, false},
+		{"PR with review comments", &provider.PullRequest{State: &open, Comments: n(0), ReviewComments: n(1)}, true},
+	}
+	for _, tc := range tests {
+		if got := needComments(tc.i, responded, []string{}); got != tc.want {
+			t.Errorf("%s: needComments = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	// Without comments, no member has responded
+	url := "https://github.com/org/project/issues/1"
+	h := New(Config{})
+	co := h.createConversation(&provider.Issue{State: &open, Comments: n(0), HTMLURL: &url}, nil, time.Now())
+	if !co.LatestMemberResponse.IsZero() {
+		t.Errorf("LatestMemberResponse = %s, want zero", co.LatestMemberResponse)
+	}
+}
+
+func TestNeedFullTimeline(t *testing.T) {
+	yes := true
+	tests := []struct {
+		name string
+		f    provider.Filter
+		want bool
+	}{
+		{"none", provider.Filter{}, false},
+		{"labeled within", provider.Filter{LabeledWithin: "bug:1d"}, false},
+		{"prioritized", provider.Filter{Prioritized: "+1d"}, true},
+		{"time to first label", provider.Filter{TimeToFirstLabel: "+3d"}, true},
+		{"reopen count", provider.Filter{ReopenCount: ">2"}, true},
+		{"state age", provider.Filter{StateAge: "+30d"}, true},
+		{"unpicked", provider.Filter{Unpicked: &yes}, true},
+		{"abandoned assignment", provider.Filter{AbandonedAssignment: &yes}, true},
+	}
+	for _, tc := range tests {
+		if got := needFullTimeline([]provider.Filter{tc.f}); got != tc.want {
+			t.Errorf("%s: needFullTimeline = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDebugTraceStages(t *testing.T) {
+	open := provider.Filter{State: "open"}
+	closed := provider.Filter{State: "closed"}
+	co := &Conversation{State: "open"}
+
+	dt := &DebugTrace{Found: true}
+	dt.addStage(StagePostFetch, []provider.Filter{open}, func(fs []provider.Filter) bool { return fs[0].State == co.State })
+	if !dt.Matched {
+		t.Errorf("matched = false after passing stage: %+v", dt.Stages[0])
+	}
+
+	dt.addStage(StagePostEvents, []provider.Filter{open, closed}, func(fs []provider.Filter) bool { return fs[0].State == co.State })
+	if dt.Matched {
+		t.Errorf("matched = true after failing stage")
+	}
+
+	st := dt.Stages[1]
+	if st.Passed || !st.Results[0].Passed || st.Results[1].Passed {
+		t.Errorf("unexpected results for %s: passed=%v, results=%v/%v", st.Name, st.Passed, st.Results[0].Passed, st.Results[1].Passed)
+	}
+}
+
+func TestExclude(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://github.com/Org/Project/issues/12", "org/project#12"},
+		{"https://gitlab.com/org/project/-/merge_requests/7", "org/project#7"},
+		{"org/project#34", "org/project#34"},
+		{"#56", "#56"},
+		{"56", ""},
+		{"org#56", ""},
+		{"https://github.com/org/project", ""},
+		{"org/project#abc", ""},
+	}
+
+	for _, tc := range tests {
+		got, err := ParseExclude(tc.in)
+		if tc.want == "" {
+			if err == nil {
+				t.Errorf("ParseExclude(%q) = %q, want error", tc.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("ParseExclude(%q) = %q, %v, want %q", tc.in, got, err, tc.want)
+		}
+	}
+
+	h := &Engine{}
+	repo := provider.Repo{Organization: "org", Project: "project"}
+	item := func(n int) *provider.Issue { return &provider.Issue{Number: &n} }
+
+	if h.excluded(repo, item(12)) {
+		t.Errorf("excluded with an empty list")
+	}
+
+	if err := h.SetExclude([]string{"https://github.com/org/project/issues/12", "#99"}); err != nil {
+		t.Fatalf("SetExclude: %v", err)
+	}
+	if !h.excluded(repo, item(12)) || !h.excluded(provider.Repo{Organization: "other", Project: "repo"}, item(99)) {
+		t.Errorf("listed items were not excluded")
+	}
+	if h.excluded(provider.Repo{Organization: "other", Project: "repo"}, item(12)) || h.excluded(repo, item(13)) {
+		t.Errorf("unlisted items were excluded")
+	}
+
+	if err := h.SetExclude([]string{"#12", "bogus"}); err == nil {
+		t.Errorf("SetExclude with an invalid entry succeeded, want error")
+	}
+	if !h.excluded(repo, item(12)) || h.excluded(repo, item(13)) {
+		t.Errorf("SetExclude with an invalid entry changed the list")
+	}
+}
+
+func TestExcludeSearchQuery(t *testing.T) {
+	m, err := persist.NewMemory(persist.Config{})
+	if err != nil {
+		t.Fatalf("new memory: %v", err)
+	}
+	if err := m.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+
+	updated := time.Now().Add(-time.Hour)
+	issue := func(num int, pr bool) *provider.Issue {
+		url := fmt.Sprintf("https://github.com/org/project/issues/%d", num)
+		i := &provider.Issue{Number: &num, HTMLURL: &url, UpdatedAt: &updated}
+		if pr {
+			i.PullRequestLinks = &provider.PullRequestLinks{URL: &url}
+		}
+		return i
+	}
+
+	// Search results, and the PR's they refer to
+	if err := m.Set("org-project-search-is:open", &provider.Thing{Created: time.Now(), Issues: []*provider.Issue{issue(1, false), issue(2, false), issue(3, true), issue(4, true)}}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	for _, n := range []int{3, 4} {
+		num := n
+		if err := m.Set(fmt.Sprintf("org-project-%d-pr", n), &provider.Thing{Created: time.Now(), PullRequests: []*provider.PullRequest{{Number: &num}}}); err != nil {
+			t.Fatalf("set: %v", err)
+		}
+	}
+
+	h := New(Config{Cache: m})
+	if err := h.SetExclude([]string{"org/project#2", "org/project#4"}); err != nil {
+		t.Fatalf("SetExclude: %v", err)
+	}
+	sp := provider.SearchParams{Repo: provider.Repo{Organization: "org", Project: "project"}, Query: "is:open"}
+
+	is, _ := h.issueCandidates(context.Background(), sp)
+	if len(is) != 1 || is[0].GetNumber() != 1 {
+		t.Errorf("issueCandidates() returned %d issues, want #1 only", len(is))
+	}
+
+	prs, _ := h.pullRequestCandidates(context.Background(), sp)
+	if len(prs) != 1 || prs[0].GetNumber() != 3 {
+		t.Errorf("pullRequestCandidates() returned %d PR's, want #3 only", len(prs))
+	}
+}
+
+func TestPaging(t *testing.T) {
+	r := provider.Repo{Organization: "org", Project: "project"}
+
+	tests := []struct {
+		name    string
+		sp      provider.SearchParams
+		perPage int
+		key     string
+		last    bool
+	}{
+		{"default", provider.SearchParams{Repo: r, State: "open"}, 100, "org-project-open-issues", false},
+		{"per page", provider.SearchParams{Repo: r, State: "open", PerPage: 20}, 20, "org-project-open-issues", false},
+		{"too large", provider.SearchParams{Repo: r, State: "open", PerPage: 500}, 100, "org-project-open-issues", false},
+		{"capped", provider.SearchParams{Repo: r, State: "open", PerPage: 20, MaxPages: 2}, 20, "org-project-open-issues-first-2x20", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := perPage(tc.sp); got != tc.perPage {
+				t.Errorf("perPage() = %d, want %d", got, tc.perPage)
+			}
+			if got := issueSearchKey(tc.sp); got != tc.key {
+				t.Errorf("issueSearchKey() = %q, want %q", got, tc.key)
+			}
+			if got := lastPage(tc.sp, &provider.Response{NextPage: 3}, 2); got != tc.last {
+				t.Errorf("lastPage() = %v, want %v", got, tc.last)
+			}
+			if !lastPage(tc.sp, &provider.Response{}, 1) {
+				t.Errorf("lastPage() = false without a next page, want true")
+			}
+		})
+	}
+}
+
+func TestRepoAliases(t *testing.T) {
+	if _, err := ParseRepoAliases(map[string]string{"org/old": "new"}); err == nil {
+		t.Errorf("ParseRepoAliases() with an invalid name succeeded, want error")
+	}
+	if _, err := ParseRepoAliases(map[string]string{"org/old": "ORG/OLD"}); err == nil {
+		t.Errorf("ParseRepoAliases() with a self-alias succeeded, want error")
+	}
+
+	for _, cycle := range []map[string]string{
+		{"a/b": "b/a", "b/a": "A/B"},
+		{"org/one": "org/two", "org/two": "org/three", "org/three": "org/one"},
+	} {
+		if _, err := ParseRepoAliases(cycle); err == nil {
+			t.Errorf("ParseRepoAliases(%v) with a cycle succeeded, want error", cycle)
+		}
+	}
+
+	aliases, err := ParseRepoAliases(map[string]string{"Org/Oldest": "org/old", "org/old": "org/new", "a/b": "b/a"})
+	if err != nil {
+		t.Fatalf("ParseRepoAliases: %v", err)
+	}
+	h := &Engine{repoAliases: aliases}
+
+	tests := []struct {
+		org     string
+		project string
+		want    string
+	}{
+		{"org", "new", "org/new"},
+		{"org", "old", "org/new"},
+		{"ORG", "OLDEST", "org/new"},
+		{"other", "old", "other/old"},
+		{"a", "b", "b/a"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.org+"/"+tc.project, func(t *testing.T) {
+			org, project := h.canonicalName(tc.org, tc.project)
+			if got := org + "/" + project; got != tc.want {
+				t.Errorf("canonicalName() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	r := h.canonicalRepo(provider.Repo{Host: "github.com", Organization: "org", Project: "old"})
+	if r.Host != "github.com" || r.Organization != "org" || r.Project != "new" {
+		t.Errorf("canonicalRepo() = %+v, want github.com org/new", r)
+	}
+}
+
+func TestMergeByURL(t *testing.T) {
+	// With issues_include_prs, PR #2 is found by both the issue and PR searches
+	issues := []*Conversation{
+		{ID: 1, Type: Issue, URL: "https://github.com/org/project/issues/1"},
+		{ID: 2, Type: Issue, URL: "https://github.com/org/project/pull/2"},
+	}
+	prs := []*Conversation{
+		{ID: 2, Type: PullRequest, URL: "https://github.com/org/project/pull/2"},
+		{ID: 3, Type: PullRequest, URL: "https://github.com/org/project/pull/3"},
+	}
+
+	got := mergeByURL(issues, prs)
+
+	want := map[int]string{1: Issue, 2: PullRequest, 3: PullRequest}
+	if len(got) != len(want) {
+		t.Fatalf("mergeByURL() returned %d conversations, want %d", len(got), len(want))
+	}
+	for _, co := range got {
+		if co.Type != want[co.ID] {
+			t.Errorf("#%d type = %v, want %v", co.ID, co.Type, want[co.ID])
+		}
+	}
+}
+
+func TestSearchAnyDedup(t *testing.T) {
+	state := constants.OpenState
+	login := "user"
+	created := time.Now().Add(-time.Hour)
+	issueURL := "https://github.com/org/project/issues/1"
+	prURL := "https://github.com/org/project/pull/2"
+	one, two := 1, 2
+	title := "title"
+
+	issue := &provider.Issue{Number: &one, State: &state, HTMLURL: &issueURL, Title: &title, User: &provider.User{Login: &login}, CreatedAt: &created, UpdatedAt: &created}
+	// As listed by the GitHub issues API, which includes PR's
+	prIssue := &provider.Issue{Number: &two, State: &state, HTMLURL: &prURL, Title: &title, User: &provider.User{Login: &login}, CreatedAt: &created, UpdatedAt: &created, PullRequestLinks: &provider.PullRequestLinks{URL: &prURL}}
+	pr := &provider.PullRequest{Number: &two, State: &state, HTMLURL: &prURL, Title: &title, User: &provider.User{Login: &login}, CreatedAt: &created, UpdatedAt: &created}
+
+	m, err := persist.NewMemory(persist.Config{})
+	if err != nil {
+		t.Fatalf("new memory: %v", err)
+	}
+	if err := m.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	if err := m.Set("org-project-open-issues", &provider.Thing{Created: time.Now(), Issues: []*provider.Issue{issue, prIssue}}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := m.Set("org-project-open-prs", &provider.Thing{Created: time.Now(), PullRequests: []*provider.PullRequest{pr}}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	h := New(Config{Cache: m, IssuesIncludePRs: true})
+	sp := provider.SearchParams{Repo: provider.Repo{Organization: "org", Project: "project"}, Filters: []provider.Filter{{State: constants.OpenState}}}
+	cos, _, err := h.SearchAny(context.Background(), sp)
+	if err != nil {
+		t.Fatalf("SearchAny: %v", err)
+	}
+
+	got := []string{}
+	for _, co := range cos {
+		got = append(got, fmt.Sprintf("%s:%s", co.URL, co.Type))
+	}
+	want := []string{issueURL + ":" + Issue, prURL + ":" + PullRequest}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("SearchAny() = %v, want %v", got, want)
+	}
+}
+
+func TestDedupByURL(t *testing.T) {
+	// The same number in two repositories, one of which was also found twice
+	api := &Conversation{ID: 12, GlobalID: 1, Organization: "org", Project: "api", URL: "https://github.com/org/api/issues/12"}
+	web := &Conversation{ID: 12, GlobalID: 2, Organization: "org", Project: "web", URL: "https://github.com/org/web/issues/12"}
+	again := &Conversation{ID: 12, GlobalID: 2, Organization: "org", Project: "web", URL: "https://github.com/org/web/issues/12"}
+
+	got := DedupByURL(MergeTransferred([]*Conversation{api, web, again}))
+	if len(got) != 2 || got[0] != api || got[1] != web {
+		t.Errorf("DedupByURL() = %v, want the org/api and org/web items", got)
+	}
+	if got[0].TransferredFrom != nil || got[1].TransferredFrom != nil {
+		t.Errorf("items with the same number were merged as transferred")
+	}
+}
+
+func TestMergeTransferred(t *testing.T) {
+	now := time.Now()
+	stale := &Conversation{ID: 7, GlobalID: 1, Type: Issue, Organization: "org", Project: "api", URL: "https://github.com/org/api/issues/7", Updated: now}
+	moved := &Conversation{ID: 3, GlobalID: 1, Type: Issue, Organization: "org", Project: "web", URL: "https://github.com/org/web/issues/3", Updated: now.Add(-time.Hour), TransferredAt: now.Add(-time.Hour)}
+	other := &Conversation{ID: 7, GlobalID: 2, Type: Issue, Organization: "org", Project: "web", URL: "https://github.com/org/web/issues/7"}
+
+	got := MergeTransferred([]*Conversation{stale, other, moved})
+	if len(got) != 2 || got[0] != other || got[1] != moved {
+		t.Fatalf("MergeTransferred() = %v, want the org/web items", got)
+	}
+
+	// Each side is linked to the other
+	if moved.TransferredFrom == nil || moved.TransferredFrom.URL != stale.URL {
+		t.Errorf("TransferredFrom = %+v, want %s", moved.TransferredFrom, stale.URL)
+	}
+	if stale.TransferredTo == nil || stale.TransferredTo.URL != moved.URL {
+		t.Errorf("TransferredTo = %+v, want %s", stale.TransferredTo, moved.URL)
+	}
+	if moved.TransferredTo != nil || stale.TransferredFrom != nil || other.TransferredFrom != nil || other.TransferredTo != nil {
+		t.Errorf("unexpected transfer links: moved.to=%v stale.from=%v other=%v/%v", moved.TransferredTo, stale.TransferredFrom, other.TransferredFrom, other.TransferredTo)
+	}
+}
+
+func TestRefKey(t *testing.T) {
+	h := New(Config{})
+	co := &Conversation{ID: 1, Organization: "org", Project: "project", URL: "https://github.com/org/project/issues/1"}
+	h.parseRefs(" see https://github.com/org/web/issues/5 and https://github.com/other/web/issues/5 and #5", co, time.Now())
+
+	got := []string{}
+	for _, rc := range co.IssueRefs {
+		got = append(got, refKey(rc))
+	}
+	want := "org/project#5 org/web#5 other/web#5"
+	if strings.Join(got, " ") != want {
+		t.Errorf("IssueRefs = %v, want %s", got, want)
+	}
+}
+
+func TestNegativeCache(t *testing.T) {
+	m, err := persist.NewMemory(persist.Config{})
+	if err != nil {
+		t.Fatalf("new memory: %v", err)
+	}
+	if err := m.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	h := &Engine{cache: m}
+
+	sp := provider.SearchParams{SearchKey: "org-project-12-pr"}
+	if h.cacheNegative(sp, errors.New("connection reset")) {
+		t.Errorf("cacheNegative() cached a genuine error")
+	}
+	if x := h.cachedThing(sp.SearchKey, time.Time{}); x != nil {
+		t.Errorf("cachedThing() = %+v after a genuine error, want nil", x)
+	}
+
+	notFound := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}
+	if !h.cacheNegative(sp, notFound) {
+		t.Fatalf("cacheNegative() did not cache a not found error")
+	}
+	x := h.cachedThing(sp.SearchKey, time.Now().Add(-time.Minute))
+	if x == nil {
+		t.Fatalf("cachedThing() = nil after a not found error, want an empty result")
+	}
+	if pr := cachedPullRequest(x); pr != nil {
+		t.Errorf("cachedPullRequest() = %+v, want nil", pr)
+	}
+
+	if err := m.Set(sp.SearchKey, &provider.Thing{Created: time.Now().Add(-2 * NegativeTTL), Negative: true}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if x := h.cachedThing(sp.SearchKey, time.Time{}); x != nil {
+		t.Errorf("cachedThing() = %+v for an expired negative result, want nil", x)
+	}
+}
+
+func TestSearchIssuesOmitsPullRequests(t *testing.T) {
+	item := func(num int, pr bool) *provider.Issue {
+		state := constants.OpenState
+		url := fmt.Sprintf("https://github.com/org/project/issues/%d", num)
+		title := fmt.Sprintf("item %d", num)
+		login := "user"
+		created := time.Now().Add(-time.Hour)
+		i := &provider.Issue{Number: &num, State: &state, HTMLURL: &url, Title: &title, User: &provider.User{Login: &login}, CreatedAt: &created, UpdatedAt: &created}
+		if pr {
+			i.PullRequestLinks = &provider.PullRequestLinks{URL: &url}
+		}
+		return i
+	}
+
+	for _, tc := range []struct {
+		name       string
+		includePRs bool
+		want       []int
+	}{
+		{"issues only", false, []int{1}},
+		{"including PR's", true, []int{1, 2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := persist.NewMemory(persist.Config{})
+			if err != nil {
+				t.Fatalf("new memory: %v", err)
+			}
+			if err := m.Initialize(); err != nil {
+				t.Fatalf("initialize: %v", err)
+			}
+
+			// As listed by the GitHub issues API, which includes PR's
+			if err := m.Set("org-project-open-issues", &provider.Thing{Created: time.Now(), Issues: []*provider.Issue{item(1, false), item(2, true)}}); err != nil {
+				t.Fatalf("set: %v", err)
+			}
+
+			h := New(Config{Cache: m, IssuesIncludePRs: tc.includePRs})
+			sp := provider.SearchParams{Repo: provider.Repo{Organization: "org", Project: "project"}, Filters: []provider.Filter{{State: constants.OpenState}}}
+			cos, _, err := h.SearchIssues(context.Background(), sp)
+			if err != nil {
+				t.Fatalf("SearchIssues: %v", err)
+			}
+
+			got := []int{}
+			for _, co := range cos {
+				got = append(got, co.ID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("SearchIssues() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMergeableStateCutoff(t *testing.T) {
+	now := time.Now()
+	updated := now.Add(-time.Hour)
+	pr := &provider.PullRequest{UpdatedAt: &updated}
+
+	// The base branch may have moved since the PR was last updated
+	if got, want := mergeableStateCutoff(pr, now), now.Add(-MergeableStateTTL); !got.Equal(want) {
+		t.Errorf("mergeableStateCutoff() = %s, want %s", got, want)
+	}
+
+	updated = now.Add(-time.Minute)
+	if got := mergeableStateCutoff(pr, now); !got.Equal(updated) {
+		t.Errorf("mergeableStateCutoff() = %s for a recently updated PR, want %s", got, updated)
+	}
+}
+
+func TestInvalidateItem(t *testing.T) {
+	m, err := persist.NewMemory(persist.Config{})
+	if err != nil {
+		t.Fatalf("new memory: %v", err)
+	}
+	if err := m.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	h := New(Config{Cache: m, MaxTimelineEvents: 10, CommentLimit: 5})
+
+	sha := "abc123"
+	created := time.Now().Add(-time.Hour)
+	pr := &provider.PullRequest{Head: &provider.PullRequestBranch{SHA: &sha}}
+	keys := []string{"org-project-12-pr", "org-project-12-timeline-last-10", "org-project-12-issue-comments-last-5", "org-project-12-checks-abc123"}
+	for _, k := range keys {
+		if err := m.Set(k, &provider.Thing{Created: created, PullRequests: []*provider.PullRequest{pr}}); err != nil {
+			t.Fatalf("set: %v", err)
+		}
+	}
+	h.setSeen("https://github.com/org/project/pull/12", &Conversation{Organization: "org", Project: "project", ID: 12})
+
+	// Invalidations may arrive while a refresh records conversations
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			h.setSeen(fmt.Sprintf("https://github.com/org/project/pull/%d", 100+i), &Conversation{ID: 100 + i})
+			h.updateMtimeLong("org", "project", 100+i, time.Now())
+		}
+		done <- true
+	}()
+	h.InvalidateItem("org", "project", 12)
+	<-done
+
+	for _, k := range keys {
+		if x := m.GetNewerThan(k, time.Time{}); x != nil {
+			t.Errorf("%s was not invalidated", k)
+		}
+	}
+	if co := h.seenConversation("https://github.com/org/project/pull/12"); co != nil {
+		t.Errorf("seen conversation was not invalidated")
+	}
+	if got := h.ConversationsTotal(); got != 100 {
+		t.Errorf("ConversationsTotal() = %d, want 100", got)
+	}
+}
+
+// bodySimilarity is a similarity index which records the body of each item
+type bodySimilarity struct {
+	bodies map[string]string
+	adds   int
+}
+
+func (s *bodySimilarity) Add(url string, _ string, body string) {
+	s.bodies[url] = body
+	s.adds++
+}
+
+func (s *bodySimilarity) Remove(url string) { delete(s.bodies, url) }
+
+func (s *bodySimilarity) Similar(string, string) []similarity.Match { return nil }
+
+func (s *bodySimilarity) Len() int { return len(s.bodies) }
+
+func TestUpdateSimilarKeepsBody(t *testing.T) {
+	s := &bodySimilarity{bodies: map[string]string{}}
+	h := New(Config{Similarity: s})
+
+	url := "https://github.com/org/project/issues/1"
+	title := "crash on start"
+	body := "panic: runtime error"
+	state := constants.OpenState
+	h.indexSimilar(&provider.Issue{HTMLURL: &url, Title: &title, Body: &body, State: &state})
+
+	// A refresh of an item with the same title leaves the indexed body alone
+	co := h.createConversation(&provider.Issue{HTMLURL: &url, Title: &title, Body: &body, State: &state}, nil, time.Now())
+	h.UpdateSimilar([]*Conversation{co}, nil)
+	if s.adds != 1 || s.bodies[url] != body {
+		t.Errorf("after refresh: %d adds, body %q, want 1 add, body %q", s.adds, s.bodies[url], body)
+	}
+
+	// A retitled item is re-added with its body
+	retitled := "crash on startup"
+	co = h.createConversation(&provider.Issue{HTMLURL: &url, Title: &retitled, Body: &body, State: &state}, nil, time.Now())
+	h.UpdateSimilar([]*Conversation{co}, nil)
+	if s.adds != 2 || s.bodies[url] != body {
+		t.Errorf("after retitle: %d adds, body %q, want 2 adds, body %q", s.adds, s.bodies[url], body)
+	}
+
+	// A new item is added with its body
+	other := "https://github.com/org/project/issues/2"
+	otherBody := "hangs forever"
+	co = h.createConversation(&provider.Issue{HTMLURL: &other, Title: &title, Body: &otherBody, State: &state}, nil, time.Now())
+	h.UpdateSimilar([]*Conversation{co}, nil)
+	if s.bodies[other] != otherBody {
+		t.Errorf("new item body = %q, want %q", s.bodies[other], otherBody)
+	}
+}
+
+func TestUpdateSimilarDuringRefresh(t *testing.T) {
+	h := New(Config{MinSimilarity: 0.5})
+	open := &Conversation{URL: "https://github.com/org/project/issues/1", Title: "crash on start", State: constants.OpenState}
+	h.UpdateSimilar([]*Conversation{open}, nil)
+
+	// A refresh may record conversations while the similarity index is updated
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			h.setSeen(fmt.Sprintf("https://github.com/org/project/issues/%d", 100+i), &Conversation{ID: 100 + i})
+		}
+		done <- true
+	}()
+	h.UpdateSimilar(nil, []string{open.URL})
+	<-done
+
+	if n, _ := h.SimilarityStatus(); n != 0 {
+		t.Errorf("similarity index has %d items, want 0", n)
+	}
+}
+
+func TestProjectStatus(t *testing.T) {
+	m, err := persist.NewMemory(persist.Config{})
+	if err != nil {
+		t.Fatalf("new memory: %v", err)
+	}
+	if err := m.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+
+	roadmap := provider.Board{Name: "Roadmap", Owner: "org", Number: 1}
+	eng := provider.Board{Name: "Engineering", Owner: "org", Number: 2, Field: "Stage"}
+	h := &Engine{cache: m, projects: []provider.Board{roadmap, eng}}
+
+	url := "https://github.com/org/project/issues/1"
+	if err := m.Set("project-org-1-Status", &provider.Thing{ProjectItems: []*provider.ProjectItem{{URL: url, Status: "In Progress"}}}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := m.Set("project-org-2-Stage", &provider.Thing{ProjectItems: []*provider.ProjectItem{{URL: "https://github.com/org/project/issues/2", Status: "Done"}}}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	sp := provider.SearchParams{Repo: provider.Repo{Host: constants.GithubProviderHost}}
+	co := &Conversation{URL: url}
+	setProjectStatus(co, h.projectStatuses(context.Background(), sp))
+
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{"Roadmap=In Progress", true},
+		{"Roadmap=in progress", true},
+		{"Roadmap=Done", false},
+		{"Roadmap!=Done", true},
+		{"Roadmap=*", true},
+		{"Roadmap=none", false},
+		{"Engineering=none", true},
+		{"Engineering!=*", true},
+		{"Engineering=Done", false},
+	}
+	for _, tc := range tests {
+		if got := matchProject(co, tc.filter); got != tc.want {
+			t.Errorf("matchProject(%q) = %v, want %v (status: %v)", tc.filter, got, tc.want, co.ProjectStatus)
+		}
+	}
+
+	for _, s := range []string{"Roadmap", "=Done", "Roadmap=", "!=Done"} {
+		if _, _, _, err := ParseProjectFilter(s); err == nil {
+			t.Errorf("ParseProjectFilter(%q) = nil error, want error", s)
+		}
+	}
+}
+
+func TestExternalLinks(t *testing.T) {
+	body := `See [the design](https://docs.example.com/design), https://github.com/org/project/issues/1, and
+https://example.org/a. Also <https://example.org/a> and ![x](https://user-images.githubusercontent.com/1.png)
+or https://example.net/b.`
+
+	got := strings.Join(externalLinks(body, 10), " ")
+	want := "https://docs.example.com/design https://example.org/a https://example.net/b"
+	if got != want {
+		t.Errorf("externalLinks() = %q, want %q", got, want)
+	}
+
+	if got := externalLinks(body, 1); len(got) != 1 {
+		t.Errorf("externalLinks(max=1) = %v, want 1 link", got)
+	}
+}
+
+func TestPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"2001:4860:4860::8888", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+	}
+	for _, tc := range tests {
+		if got := publicIP(net.ParseIP(tc.ip)); got != tc.want {
+			t.Errorf("publicIP(%s) = %v, want %v", tc.ip, got, tc.want)
+		}
+	}
+}
+
+func TestDeadLinks(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		}
+	}))
+	defer srv.Close()
+
+	m, err := persist.NewMemory(persist.Config{})
+	if err != nil {
+		t.Fatalf("new memory: %v", err)
+	}
+	if err := m.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+
+	// The test server listens on loopback, which the default link client refuses to connect to
+	h := &Engine{cache: m, linkClient: srv.Client(), maxLinks: DefaultMaxLinks}
+	body := strings.Join([]string{srv.URL + "/ok", srv.URL + "/gone", srv.URL + "/missing", srv.URL + "/broken", srv.URL + "/head"}, " ")
+
+	got := strings.Join(h.deadLinks(context.Background(), body), " ")
+	want := srv.URL + "/gone " + srv.URL + "/missing"
+	if got != want {
+		t.Errorf("deadLinks() = %q, want %q", got, want)
+	}
+
+	// Every status is cached, including those which are not considered dead
+	before := requests
+	h.deadLinks(context.Background(), body)
+	if n := requests - before; n != 0 {
+		t.Errorf("second check made %d requests, want 0", n)
+	}
+
+	_, err = newLinkClient(time.Second).Get(srv.URL + "/ok")
+	if err == nil || !strings.Contains(err.Error(), errPrivateAddress.Error()) {
+		t.Errorf("link client connected to %s: %v", srv.URL, err)
+	}
+}
@@ -1,189 +1,154 @@
 package hubbub
 
 import (
+	"github.com/google/triage-party/pkg/constants"
 	"github.com/google/triage-party/pkg/provider"
-	"regexp"
-	"strings"
+	"time"
 
-	"github.com/imjasonmiller/godice"
 	"k8s.io/klog/v2"
 )
 
-var nonLetter = regexp.MustCompile(`[^a-zA-Z]`)
-var removeWords = map[string]bool{
-	"a":       true,
-	"an":      true,
-	"and":     true,
-	"are":     true,
-	"as":      true,
-	"be":      true,
-	"by":      true,
-	"can":     true,
-	"does":    true,
-	"has":     true,
-	"have":    true,
-	"how":     true,
-	"if":      true,
-	"in":      true,
-	"is":      true,
-	"of":      true,
-	"on":      true,
-	"or":      true,
-	"the":     true,
-	"that":    true,
-	"to":      true,
-	"use":     true,
-	"very":    true,
-	"via":     true,
-	"too":     true,
-	"why":     true,
-	"add":     true,
-	"feature": true,
-	"fix":     true,
-	"bug":     true,
-	"fr":      true,
-	"it":      true,
-	"you":     true,
-	"with":    true,
-	"do":      true,
-	"we":      true,
-}
-
-// normalize titles for a higher hit-rate
-func normalizeTitle(t string) string {
-	var keep []string
-	for _, word := range strings.Split(t, " ") {
-		word = nonLetter.ReplaceAllString(word, "")
-		if len(word) == 0 {
-			continue
-		}
-		word = strings.ToLower(word)
-		if removeWords[word] {
-			continue
-		}
-		keep = append(keep, word)
-	}
-
-	klog.V(4).Infof("normalized: %s", strings.Join(keep, " "))
-	return strings.Join(keep, " ")
-}
-
 // updateSimilarIssues updates similarity tables, meant for background use
 func (h *Engine) updateSimilarIssues(key string, is []*provider.Issue) {
+	if h.similarity == nil {
+		return
+	}
+
 	klog.V(1).Infof("Updating similarity table from issue cache %q (%d items)", key, len(is))
 	for _, i := range is {
-		h.updateSimilarityTables(i.GetTitle(), i.GetHTMLURL())
+		h.indexSimilar(i)
 	}
+	h.touchSimilarity()
 }
 
 // updateSimilarPullRequests updates similarity tables, meant for background use
 func (h *Engine) updateSimilarPullRequests(key string, prs []*provider.PullRequest) {
+	if h.similarity == nil {
+		return
+	}
+
 	klog.V(1).Infof("Updating similarity table from PR cache %q (%d items)", key, len(prs))
 	for _, i := range prs {
-		h.updateSimilarityTables(i.GetTitle(), i.GetHTMLURL())
+		h.indexSimilar(i)
 	}
+	h.touchSimilarity()
 }
 
-func (h *Engine) updateSimilarityTables(rawTitle, url string) {
-	if h.MinSimilarity == 0 {
+// indexSimilar adds an open item to the similarity index, or removes a closed one
+func (h *Engine) indexSimilar(i provider.IItem) {
+	if i.GetState() == constants.ClosedState {
+		h.removeSimilar(i.GetHTMLURL())
 		return
 	}
+	h.similarity.Add(i.GetHTMLURL(), i.GetTitle(), i.GetBody())
+	h.setIndexedTitle(i.GetHTMLURL(), i.GetTitle())
+}
 
-	title := normalizeTitle(rawTitle)
-
-	result, existing := h.titleToURLs.LoadOrStore(title, []string{url})
-	if existing {
-		foundURL := false
-		otherURLs := []string{}
-		for _, v := range result.([]string) {
-			if v == url {
-				foundURL = true
-				break
-			}
-			otherURLs = append(otherURLs, v)
-		}
+// removeSimilar removes an item from the similarity index
+func (h *Engine) removeSimilar(url string) {
+	h.similarity.Remove(url)
 
-		if !foundURL {
-			klog.V(4).Infof("updating %q with %v", rawTitle, otherURLs)
-			h.titleToURLs.Store(title, append(otherURLs, url))
-		}
-		return
+	h.similarityMu.Lock()
+	defer h.similarityMu.Unlock()
+	delete(h.similarTitles, url)
+}
+
+// indexedTitle returns the title an item was last added to the similarity index with, if any
+func (h *Engine) indexedTitle(url string) (string, bool) {
+	h.similarityMu.Lock()
+	defer h.similarityMu.Unlock()
+	t, ok := h.similarTitles[url]
+	return t, ok
+}
+
+func (h *Engine) setIndexedTitle(url string, title string) {
+	h.similarityMu.Lock()
+	defer h.similarityMu.Unlock()
+	if h.similarTitles == nil {
+		h.similarTitles = map[string]string{}
 	}
+	h.similarTitles[url] = title
+}
+
+// touchSimilarity records that the similarity index was updated
+func (h *Engine) touchSimilarity() {
+	h.similarityMu.Lock()
+	defer h.similarityMu.Unlock()
+	h.similarityUpdated = time.Now()
+}
 
-	// Update us -> them title similarity
-	similarTo := []string{}
+// UpdateSimilar incrementally updates the similarity index from refreshed conversations: open ones are added, or
+// updated with their body if their title has changed, and closed ones are removed. gone are the URLs of conversations which are no
+// longer in a result; they are removed if they have since been closed, or are no longer known to the engine.
+func (h *Engine) UpdateSimilar(cs []*Conversation, gone []string) {
+	if h.similarity == nil {
+		return
+	}
 
-	h.titleToURLs.Range(func(k, v interface{}) bool {
-		otherTitle, ok := k.(string)
-		if !ok {
-			klog.V(1).Infof("key %q is not of type string", k)
+	for _, co := range cs {
+		if co.State == constants.ClosedState {
+			h.removeSimilar(co.URL)
+			continue
 		}
-		if otherTitle == title {
-			return true
+		// Re-adding an unchanged item would replace the body indexed from the listing
+		if t, ok := h.indexedTitle(co.URL); ok && t == co.Title {
+			continue
 		}
+		h.similarity.Add(co.URL, co.Title, co.body)
+		h.setIndexedTitle(co.URL, co.Title)
+	}
 
-		if godice.CompareString(title, otherTitle) > h.MinSimilarity {
-			klog.V(4).Infof("%q is similar to %q", rawTitle, otherTitle)
-			similarTo = append(similarTo, otherTitle)
+	for _, url := range gone {
+		co := h.seenConversation(url)
+		if co == nil || co.State == constants.ClosedState {
+			klog.V(1).Infof("removing %s from similarity index", url)
+			h.removeSimilar(url)
 		}
-		return true
-	})
+	}
 
-	h.similarTitles.Store(title, similarTo)
+	h.touchSimilarity()
+}
 
-	// Update them -> us title similarity
-	for _, other := range similarTo {
-		klog.V(4).Infof("updating %q to map to %s", other, title)
-		others, ok := h.similarTitles.Load(other)
-		if ok {
-			h.similarTitles.Store(other, append(others.([]string), title))
-		}
+// SimilarityStatus returns the number of items in the similarity index, and when it was last updated. The count is
+// -1 if similarity matching is disabled.
+func (h *Engine) SimilarityStatus() (int, time.Time) {
+	if h.similarity == nil {
+		return -1, time.Time{}
 	}
+
+	h.similarityMu.Lock()
+	defer h.similarityMu.Unlock()
+	return h.similarity.Len(), h.similarityUpdated
 }
 
 // FindSimilar locates similar conversations to this one
 func (h *Engine) FindSimilar(co *Conversation) []*RelatedConversation {
-	if h.MinSimilarity == 0 {
+	if h.similarity == nil {
 		return nil
 	}
 
-	simco := []*RelatedConversation{}
-	title := normalizeTitle(co.Title)
-	similarURLs := []string{}
 	klog.V(4).Infof("finding similar items to #%d (%s)", co.ID, co.Type)
-
-	tres, ok := h.similarTitles.Load(title)
-	if !ok {
+	matches := h.similarity.Similar(co.URL, co.Title)
+	if len(matches) == 0 {
 		return nil
 	}
 
-	for _, ot := range tres.([]string) {
-		ures, ok := h.titleToURLs.Load(ot)
-		if ok {
-			similarURLs = append(similarURLs, ures.([]string)...)
-		}
-	}
-
-	if len(similarURLs) == 0 {
-		return nil
-	}
-
-	klog.V(4).Infof("#%d %q is similar to %v", co.ID, co.Title, similarURLs)
+	klog.V(4).Infof("#%d %q is similar to %v", co.ID, co.Title, matches)
 
+	simco := []*RelatedConversation{}
 	added := map[string]bool{}
 
-	for _, url := range similarURLs {
-		// We found ourselves with a different title
-		if url == co.URL {
+	for _, m := range matches {
+		if m.URL == co.URL {
 			continue
 		}
 
 		// May happen if we've seen a URL with different titles
-		if added[url] {
+		if added[m.URL] {
 			continue
 		}
 
-		oco := h.seen[url]
+		oco := h.seenConversation(m.URL)
 		if oco == nil {
 			continue
 		}
@@ -192,8 +157,8 @@ func (h *Engine) FindSimilar(co *Conversation) []*RelatedConversation {
 			continue
 		}
 
-		simco = append(simco, makeRelated(h.seen[url]))
-		added[url] = true
+		simco = append(simco, makeRelated(oco))
+		added[m.URL] = true
 	}
 	return simco
 }
@@ -18,18 +18,29 @@ import (
 	"context"
 	"fmt"
 	"github.com/google/triage-party/pkg/provider"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/google/triage-party/pkg/constants"
 	"github.com/google/triage-party/pkg/tag"
 	"k8s.io/klog/v2"
 )
 
+const (
+	// churnMinChanges is how many bot-driven closes or reopens within churnWindow are considered churn
+	churnMinChanges = 4
+	churnWindow     = 30 * 24 * time.Hour
+)
+
 func (h *Engine) cachedTimeline(ctx context.Context, sp provider.SearchParams) ([]*provider.Timeline, error) {
 	sp.SearchKey = fmt.Sprintf("%s-%s-%d-timeline", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber)
+	if sp.TimelineLimit > 0 {
+		sp.SearchKey = fmt.Sprintf("%s-last-%d", sp.SearchKey, sp.TimelineLimit)
+	}
 	klog.V(1).Infof("Need timeline for %s as of %s", sp.SearchKey, sp.NewerThan)
 
-	if x := h.cache.GetNewerThan(sp.SearchKey, sp.NewerThan); x != nil {
+	if x := h.cachedThing(sp.SearchKey, sp.NewerThan); x != nil {
 		return x.Timeline, nil
 	}
 
@@ -52,6 +63,9 @@ func (h *Engine) updateTimeline(ctx context.Context, sp provider.SearchParams) (
 		pr := provider.ResolveProviderByHost(sp.Repo.Host)
 		evs, resp, err := pr.IssuesListIssueTimeline(ctx, sp)
 		if err != nil {
+			if h.cacheNegative(sp, err) {
+				return nil, nil
+			}
 			return nil, err
 		}
 		h.logRate(resp.Rate)
@@ -65,6 +79,20 @@ func (h *Engine) updateTimeline(ctx context.Context, sp provider.SearchParams) (
 			break
 		}
 		sp.ListOptions.Page = resp.NextPage
+
+		// Skip ahead to the pages containing the most recent events. One extra page is fetched, as the last may be short.
+		if sp.TimelineLimit > 0 && resp.LastPage > 0 {
+			first := resp.LastPage - (sp.TimelineLimit+sp.ListOptions.PerPage-1)/sp.ListOptions.PerPage
+			if first > sp.ListOptions.Page {
+				klog.V(1).Infof("%s: skipping to timeline page %d of %d", sp.SearchKey, first, resp.LastPage)
+				sp.ListOptions.Page = first
+				allEvents = nil
+			}
+		}
+	}
+
+	if sp.TimelineLimit > 0 && len(allEvents) > sp.TimelineLimit {
+		allEvents = allEvents[len(allEvents)-sp.TimelineLimit:]
 	}
 
 	if err := h.cache.Set(sp.SearchKey, &provider.Thing{Timeline: allEvents}); err != nil {
@@ -90,6 +118,10 @@ func (h *Engine) addEvents(ctx context.Context, sp provider.SearchParams, co *Co
 	}
 
 	thisRepo := fmt.Sprintf("%s/%s", co.Organization, co.Project)
+	botStateChanges := []time.Time{}
+	unassigned := false
+	co.LabeledAt = map[string]time.Time{}
+	co.FirstLabeled = time.Time{}
 
 	for _, t := range timeline {
 		if h.debug[co.ID] {
@@ -100,6 +132,73 @@ func (h *Engine) addEvents(ctx context.Context, sp provider.SearchParams, co *Co
 			co.Prioritized = t.GetCreatedAt()
 		}
 
+		// Relabeling replaces the earlier application
+		if t.GetEvent() == "labeled" {
+			co.LabeledAt[strings.ToLower(t.GetLabel().GetName())] = t.GetCreatedAt()
+		}
+		if t.GetEvent() == "unlabeled" {
+			delete(co.LabeledAt, strings.ToLower(t.GetLabel().GetName()))
+		}
+
+		// Automatic labels, such as needs-triage, are not a sign of triage
+		if t.GetEvent() == "labeled" && !h.isBot(t.GetActor()) && (co.FirstLabeled.IsZero() || t.GetCreatedAt().Before(co.FirstLabeled)) {
+			co.FirstLabeled = t.GetCreatedAt()
+		}
+
+		if t.GetEvent() == "renamed" && !t.GetCreatedAt().Before(co.Renamed) {
+			co.Renamed = t.GetCreatedAt()
+			co.RenamedFrom = t.GetRename().GetFrom()
+			co.RenamedTo = t.GetRename().GetTo()
+		}
+
+		if t.GetEvent() == "assigned" && co.PickedUpAt.IsZero() && h.userIsMember(co, t.GetAssignee()) {
+			co.PickedUpAt = t.GetCreatedAt()
+		}
+
+		if t.GetEvent() == "reopened" {
+			co.ReopenCount++
+		}
+
+		if t.GetEvent() == "reopened" && (co.State == constants.OpenState || co.State == constants.OpenedState) && t.GetCreatedAt().After(co.StateSince) {
+			co.StateSince = t.GetCreatedAt()
+		}
+
+		if t.GetEvent() == "unassigned" {
+			unassigned = true
+		}
+
+		if t.GetEvent() == "transferred" {
+			co.TransferredAt = t.GetCreatedAt()
+		}
+
+		if t.GetEvent() == "connected" {
+			co.DevelopmentLinks++
+		}
+		if t.GetEvent() == "disconnected" && co.DevelopmentLinks > 0 {
+			co.DevelopmentLinks--
+		}
+
+		if (t.GetEvent() == "closed" || t.GetEvent() == "reopened") && h.isChurnBot(t.GetActor()) {
+			botStateChanges = append(botStateChanges, t.GetCreatedAt())
+		}
+
+		switch t.GetEvent() {
+		case "mentioned", "subscribed", "unsubscribed":
+			// The actor is the user being notified, rather than the user taking action
+		case "committed":
+			// Commit events have no actor or creation time: credit pushes to the PR author, as of the commit date
+			if co.Type == PullRequest {
+				co.touched(co.Author, t.GetCommittedAt())
+			}
+		default:
+			if t.GetActor() != nil && !h.isBot(t.GetActor()) {
+				co.touched(t.GetActor(), t.GetCreatedAt())
+			}
+			if h.memberTouch(co, t) {
+				co.MemberTouched = true
+			}
+		}
+
 		if t.GetEvent() == "cross-referenced" {
 			if assignedTo[t.GetActor().GetLogin()] {
 				if t.GetCreatedAt().After(co.LatestAssigneeResponse) {
@@ -125,6 +224,10 @@ func (h *Engine) addEvents(ctx context.Context, sp provider.SearchParams, co *Co
 					continue
 				}
 
+				if h.refLimited(co, co.PullRequestRefs, co.Organization, co.Project, ri.GetNumber()) {
+					continue
+				}
+
 				klog.V(1).Infof("Found cross-referenced PR: #%d, updating PR ref", ri.GetNumber())
 
 				sp.Age = h.mtimeCo(co)
@@ -136,10 +239,66 @@ func (h *Engine) addEvents(ctx context.Context, sp provider.SearchParams, co *Co
 				refTag.Desc = fmt.Sprintf("cross-referenced PR: %s", refTag.Desc)
 				co.Tags[refTag] = true
 			} else {
-				co.UpdateIssueRefs(h.issueRef(t.GetSource().GetIssue(), co.Seen))
+				ref := h.issueRef(t.GetSource().GetIssue(), co.Seen)
+				if !h.refLimited(co, co.IssueRefs, ref.Organization, ref.Project, ref.ID) {
+					co.UpdateIssueRefs(ref)
+				}
 			}
 		}
 	}
+
+	co.Unpicked = h.unpicked(co)
+	co.AbandonedAssignment = unassigned && len(co.Assignees) == 0 && (co.State == constants.OpenState || co.State == constants.OpenedState)
+
+	co.TimeToFirstLabel = 0
+	if !co.FirstLabeled.IsZero() {
+		co.TimeToFirstLabel = co.FirstLabeled.Sub(co.Created)
+	}
+
+	if isChurning(botStateChanges) {
+		klog.V(1).Infof("#%d has %d bot-driven state changes", co.ID, len(botStateChanges))
+		co.Tags[tag.Churning] = true
+	}
+}
+
+// unpicked returns whether an open item has never had a member assigned. Current assignees are also checked, as their
+// assignment may predate the timeline events which were fetched.
+func (h *Engine) unpicked(co *Conversation) bool {
+	if co.State != constants.OpenState && co.State != constants.OpenedState {
+		return false
+	}
+
+	if !co.PickedUpAt.IsZero() {
+		return false
+	}
+
+	for _, a := range co.Assignees {
+		if h.userIsMember(co, a) {
+			return false
+		}
+	}
+	return true
+}
+
+// isChurnBot returns whether a user closing or reopening an item is a bot. GitHub Apps, such as stale[bot], which
+// drive most churn, have a type of "Bot" and a [bot] login suffix.
+func (h *Engine) isChurnBot(u *provider.User) bool {
+	return h.isBot(u) || u.GetType() == "Bot" || strings.HasSuffix(u.GetLogin(), "[bot]")
+}
+
+// isChurning returns whether enough state changes happened within a short enough window to be considered churn
+func isChurning(changes []time.Time) bool {
+	if len(changes) < churnMinChanges {
+		return false
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Before(changes[j]) })
+	for i := churnMinChanges - 1; i < len(changes); i++ {
+		if changes[i].Sub(changes[i-churnMinChanges+1]) <= churnWindow {
+			return true
+		}
+	}
+	return false
 }
 
 func (h *Engine) prRef(ctx context.Context, sp provider.SearchParams, pr provider.IItem) *RelatedConversation {
@@ -188,6 +347,16 @@ func (h *Engine) prRef(ctx context.Context, sp provider.SearchParams, pr provide
 
 	rel.ReviewState = reviewState(pr, timeline, reviews)
 	klog.V(1).Infof("Determined PR #%d to be in review state %q", pr.GetNumber(), rel.ReviewState)
+
+	if p, ok := pr.(*provider.PullRequest); ok && pr.GetState() != "closed" && needChecks(sp.Filters) {
+		sp.Ref = p.GetHead().GetSHA()
+		if sp.Ref != "" {
+			rel.CheckState, _, err = h.cachedCheckState(ctx, sp)
+			if err != nil {
+				klog.Errorf("checks: %v", err)
+			}
+		}
+	}
 	return rel
 }
 
@@ -238,3 +407,22 @@ func (h *Engine) issueRef(i *provider.Issue, age time.Time) *RelatedConversation
 	co := h.createConversation(i, nil, age)
 	return makeRelated(co)
 }
+
+// triageEvents require triage access to the repository, so count as member involvement whoever the actor is
+var triageEvents = map[string]bool{
+	"labeled":      true,
+	"unlabeled":    true,
+	"assigned":     true,
+	"unassigned":   true,
+	"milestoned":   true,
+	"demilestoned": true,
+}
+
+// memberTouch returns whether a timeline event is a member other than the author acting on an item
+func (h *Engine) memberTouch(co *Conversation, t *provider.Timeline) bool {
+	a := t.GetActor()
+	if a == nil || h.isBot(a) || a.GetLogin() == co.Author.GetLogin() {
+		return false
+	}
+	return triageEvents[t.GetEvent()] || h.userIsMember(co, a)
+}
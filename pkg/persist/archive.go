@@ -0,0 +1,162 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persist
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"github.com/google/triage-party/pkg/provider"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// archiveFormat identifies a stream written by Export
+	archiveFormat = "triage-party-cache"
+	// archiveVersion is incremented whenever the archive layout changes incompatibly
+	archiveVersion = 1
+)
+
+// archiveHeader begins every archive
+type archiveHeader struct {
+	Format   string
+	Version  int
+	Exported time.Time
+}
+
+// archiveEntry is a single cache entry within an archive
+type archiveEntry struct {
+	Key   string
+	Thing *provider.Thing
+}
+
+// Export writes every thing newer than MaxLoadAge to w as a portable archive, which Import can load into any backend
+func Export(c Cacher, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	ge := gob.NewEncoder(bw)
+
+	if err := ge.Encode(archiveHeader{Format: archiveFormat, Version: archiveVersion, Exported: time.Now()}); err != nil {
+		return fmt.Errorf("encode header: %w", err)
+	}
+
+	things := c.List(time.Now().Add(-1 * MaxLoadAge))
+	for k, th := range things {
+		if err := ge.Encode(archiveEntry{Key: k, Thing: th}); err != nil {
+			return fmt.Errorf("encode %s: %w", k, err)
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("flush: %w", err)
+	}
+
+	klog.Infof("exported %d items from %s", len(things), c)
+	return nil
+}
+
+// Import stores every thing newer than MaxLoadAge from an archive written by Export, preserving creation times
+func Import(c Cacher, r io.Reader) error {
+	gd := gob.NewDecoder(bufio.NewReader(r))
+
+	var h archiveHeader
+	if err := gd.Decode(&h); err != nil {
+		return fmt.Errorf("decode header: %w", err)
+	}
+
+	if h.Format != archiveFormat {
+		return fmt.Errorf("not a cache archive: format=%q", h.Format)
+	}
+
+	if h.Version != archiveVersion {
+		return fmt.Errorf("unsupported archive version %d (want %d)", h.Version, archiveVersion)
+	}
+
+	newerThan := time.Now().Add(-1 * MaxLoadAge)
+	stored := 0
+	skipped := 0
+
+	for {
+		var e archiveEntry
+		err := gd.Decode(&e)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("decode entry: %w", err)
+		}
+
+		if e.Thing == nil || e.Thing.Created.Before(newerThan) {
+			skipped++
+			continue
+		}
+
+		if err := c.Set(e.Key, e.Thing); err != nil {
+			return fmt.Errorf("set %s: %w", e.Key, err)
+		}
+		stored++
+	}
+
+	klog.Infof("imported %d items into %s from archive exported at %s (%d expired)", stored, c, h.Exported, skipped)
+	return nil
+}
+
+// WriteSnapshot exports every thing to an archive at path, such as when the backend is unavailable. The archive is
+// written to a temporary file first, so that a failed write leaves any previous snapshot intact.
+func WriteSnapshot(c Cacher, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if err := Export(c, f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// RestoreSnapshot imports a snapshot written by WriteSnapshot, if there is one, then removes it. It returns whether
+// a snapshot was found.
+func RestoreSnapshot(c Cacher, path string) (bool, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	if err := Import(c, f); err != nil {
+		return true, fmt.Errorf("import %s: %w", path, err)
+	}
+	return true, os.Remove(path)
+}
@@ -0,0 +1,205 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persist
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/jmoiron/sqlx"
+	"k8s.io/klog/v2"
+)
+
+// batch buffers writes so that they may be sent to a SQL backend together
+type batch struct {
+	mu      sync.Mutex
+	size    int
+	pending map[string]*provider.Thing
+}
+
+func newBatch(size int) *batch {
+	return &batch{size: size, pending: map[string]*provider.Thing{}}
+}
+
+// add buffers a write, returning the buffered writes if the batch is full. Later writes to a key replace earlier ones.
+func (b *batch) add(key string, th *provider.Thing) map[string]*provider.Thing {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pending[key] = th
+	if len(b.pending) < b.size {
+		return nil
+	}
+	return b.takeLocked()
+}
+
+// take returns and clears all buffered writes
+func (b *batch) take() map[string]*provider.Thing {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.takeLocked()
+}
+
+func (b *batch) takeLocked() map[string]*provider.Thing {
+	items := b.pending
+	b.pending = map[string]*provider.Thing{}
+	return items
+}
+
+// restore buffers writes which failed, so that they are retried with the next batch. Writes to a key buffered since
+// are newer, so are kept.
+func (b *batch) restore(items map[string]*provider.Thing) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for k, th := range items {
+		if _, ok := b.pending[k]; !ok {
+			b.pending[k] = th
+		}
+	}
+}
+
+// sqlWriter writes things to the persist table of a SQL database
+type sqlWriter struct {
+	db *sqlx.DB
+	// upsert is appended to multi-row INSERT statements to replace existing rows
+	upsert      string
+	maxBlobSize int
+	// gzips each value
+	compress bool
+	// timeout bounds each write
+	timeout time.Duration
+	// slots holds a token for each write in progress, bounding how many run at once
+	slots chan struct{}
+}
+
+func newSQLWriter(db *sqlx.DB, upsert string, cfg Config) *sqlWriter {
+	return &sqlWriter{
+		db:          db,
+		upsert:      upsert,
+		maxBlobSize: cfg.maxBlobSize(),
+		compress:    cfg.Compress,
+		timeout:     cfg.queryTimeout(),
+		slots:       make(chan struct{}, cfg.maxConcurrentWrites()),
+	}
+}
+
+// write replaces the rows for the given things within a single transaction. Rows are inserted using multi-row
+// INSERT statements, each holding up to maxBlobSize bytes of values.
+func (w *sqlWriter) write(items map[string]*provider.Thing) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	keys := []string{}
+	for k := range items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	rows := []row{}
+	for _, k := range keys {
+		v, err := encodeItem(items[k], w.compress)
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", k, err)
+		}
+
+		rs := chunkRows(k, v, w.maxBlobSize)
+		if len(rs) > 1 {
+			klog.Infof("%s is %d bytes, splitting into %d chunks", k, len(v), len(rs))
+		}
+		rows = append(rows, rs...)
+	}
+
+	// Wait for a slot before starting the clock, so that queued writes do not time out
+	w.slots <- struct{}{}
+	defer func() { <-w.slots }()
+
+	ctx, cancel := queryContext(w.timeout)
+	defer cancel()
+
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+
+	// Remove any chunks from a previous write, which may have been split differently
+	conds := []string{}
+	args := []interface{}{}
+	for _, k := range keys {
+		conds = append(conds, "k = ? OR k LIKE ?")
+		args = append(args, k, chunkLikePattern(k))
+	}
+	if _, err := tx.ExecContext(ctx, tx.Rebind(`DELETE FROM persist WHERE `+strings.Join(conds, " OR ")), args...); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	saved := time.Now()
+	statements := 0
+	for _, group := range insertGroups(rows, w.maxBlobSize) {
+		values := []string{}
+		args := []interface{}{}
+		for _, r := range group {
+			values = append(values, "(?, ?, ?)")
+			args = append(args, r.key, r.value, saved)
+		}
+
+		q := `INSERT INTO persist (k, v, saved) VALUES ` + strings.Join(values, ", ") + " " + w.upsert
+		if _, err := tx.ExecContext(ctx, tx.Rebind(q), args...); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("insert %d rows starting at %s: %w", len(group), group[0].key, err)
+		}
+		statements++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	klog.V(1).Infof("persisted %d items (%d rows, %d statements) in %s", len(items), len(rows), statements, time.Since(start))
+	return nil
+}
+
+// maxInsertRows bounds the rows per INSERT statement, keeping well within placeholder limits
+const maxInsertRows = 1000
+
+// insertGroups splits rows into groups which may be inserted by a single statement without exceeding max bytes,
+// unless a single row does so by itself.
+func insertGroups(rows []row, max int) [][]row {
+	groups := [][]row{}
+	var cur []row
+	size := 0
+
+	for _, r := range rows {
+		if len(cur) > 0 && (size+len(r.value) > max || len(cur) >= maxInsertRows) {
+			groups = append(groups, cur)
+			cur = nil
+			size = 0
+		}
+		cur = append(cur, r)
+		size += len(r.value)
+	}
+
+	if len(cur) > 0 {
+		groups = append(groups, cur)
+	}
+	return groups
+}
@@ -0,0 +1,58 @@
+package persist
+
+import (
+	"testing"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchAdd(t *testing.T) {
+	b := newBatch(3)
+	assert.Nil(t, b.add("a", &provider.Thing{}))
+	// Later writes to the same key replace earlier ones
+	assert.Nil(t, b.add("a", &provider.Thing{}))
+	assert.Nil(t, b.add("b", &provider.Thing{}))
+
+	items := b.add("c", &provider.Thing{})
+	assert.Len(t, items, 3)
+	assert.Empty(t, b.take())
+
+	b.add("d", &provider.Thing{})
+	assert.Len(t, b.take(), 1)
+
+	// A batch size of 1 is unbuffered
+	assert.Len(t, newBatch(1).add("a", &provider.Thing{}), 1)
+}
+
+func TestBatchRestore(t *testing.T) {
+	b := newBatch(10)
+	failed := map[string]*provider.Thing{"a": {CheckState: "old"}, "b": {CheckState: "old"}}
+
+	// A write to a key since the failure is newer, so is kept
+	b.add("a", &provider.Thing{CheckState: "new"})
+	b.restore(failed)
+
+	items := b.take()
+	assert.Len(t, items, 2)
+	assert.Equal(t, "new", items["a"].CheckState)
+	assert.Equal(t, "old", items["b"].CheckState)
+}
+
+func TestInsertGroups(t *testing.T) {
+	rows := []row{
+		{key: "a", value: make([]byte, 4)},
+		{key: "b", value: make([]byte, 4)},
+		{key: "c", value: make([]byte, 12)},
+		{key: "d", value: make([]byte, 1)},
+	}
+
+	gs := insertGroups(rows, 10)
+	assert.Len(t, gs, 3)
+	assert.Len(t, gs[0], 2)
+	// Rows larger than the maximum are inserted by themselves
+	assert.Equal(t, "c", gs[1][0].key)
+	assert.Equal(t, "d", gs[2][0].key)
+
+	assert.Empty(t, insertGroups(nil, 10))
+}
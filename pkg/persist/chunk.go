@@ -0,0 +1,133 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persist
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+// DefaultMaxBlobSize is the largest value written to a single SQL row. MySQL rejects queries larger
+// than max_allowed_packet, which defaults to 4MB in older releases.
+const DefaultMaxBlobSize = 3 * 1024 * 1024
+
+// Values larger than the maximum blob size are split across rows named <key>#chunk-<i>-of-<n>
+var chunkKeyRe = regexp.MustCompile(`^(.*)#chunk-(\d+)-of-(\d+)$`)
+
+// row is a key and value to be written to a SQL backend
+type row struct {
+	key   string
+	value []byte
+}
+
+func chunkKey(key string, i int, n int) string {
+	return fmt.Sprintf("%s#chunk-%d-of-%d", key, i, n)
+}
+
+// escapeLike escapes the wildcards within a string for use in a LIKE pattern
+func escapeLike(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(s)
+}
+
+// chunkLikePattern returns a LIKE pattern matching every chunk of a key
+func chunkLikePattern(key string) string {
+	return escapeLike(key) + "#chunk-%"
+}
+
+// chunkRows returns the rows to write for a value, splitting it if it is larger than max bytes
+func chunkRows(key string, value []byte, max int) []row {
+	if max <= 0 || len(value) <= max {
+		return []row{{key: key, value: value}}
+	}
+
+	n := (len(value) + max - 1) / max
+	rows := []row{}
+	for i := 0; i < n; i++ {
+		end := (i + 1) * max
+		if end > len(value) {
+			end = len(value)
+		}
+		rows = append(rows, row{key: chunkKey(key, i, n), value: value[i*max : end]})
+	}
+	return rows
+}
+
+// reassembler collects rows read from a SQL backend, reassembling chunked values
+type reassembler struct {
+	whole  map[string][]byte
+	chunks map[string][][]byte
+}
+
+func newReassembler() *reassembler {
+	return &reassembler{
+		whole:  map[string][]byte{},
+		chunks: map[string][][]byte{},
+	}
+}
+
+// add adds a row
+func (r *reassembler) add(key string, value []byte) {
+	m := chunkKeyRe.FindStringSubmatch(key)
+	if m == nil {
+		r.whole[key] = value
+		return
+	}
+
+	i, _ := strconv.Atoi(m[2])
+	n, _ := strconv.Atoi(m[3])
+	if i >= n {
+		klog.Warningf("ignoring invalid chunk: %s", key)
+		return
+	}
+
+	// Each write of a key removes the previous chunks, so the chunk count should be consistent
+	cs := r.chunks[m[1]]
+	if len(cs) != n {
+		cs = make([][]byte, n)
+		r.chunks[m[1]] = cs
+	}
+	cs[i] = value
+}
+
+// values returns the complete values by key
+func (r *reassembler) values() map[string][]byte {
+	vs := map[string][]byte{}
+	for k, v := range r.whole {
+		vs[k] = v
+	}
+
+	for k, cs := range r.chunks {
+		complete := true
+		for _, c := range cs {
+			if c == nil {
+				complete = false
+				break
+			}
+		}
+
+		if !complete {
+			klog.Warningf("skipping %s: missing chunks", k)
+			continue
+		}
+		vs[k] = bytes.Join(cs, nil)
+	}
+	return vs
+}
@@ -0,0 +1,46 @@
+package persist
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunkRoundTrip(t *testing.T) {
+	big := bytes.Repeat([]byte("0123456789"), 10)
+
+	rows := chunkRows("org-project-search", big, 30)
+	assert.Equal(t, 4, len(rows))
+	assert.Equal(t, "org-project-search#chunk-3-of-4", rows[3].key)
+
+	r := newReassembler()
+	// Rows may be read in any order
+	for i := len(rows) - 1; i >= 0; i-- {
+		r.add(rows[i].key, rows[i].value)
+	}
+	r.add("small", []byte("x"))
+
+	vs := r.values()
+	assert.Equal(t, big, vs["org-project-search"])
+	assert.Equal(t, []byte("x"), vs["small"])
+}
+
+func TestChunkRowsSmall(t *testing.T) {
+	assert.Equal(t, []row{{key: "k", value: []byte("abc")}}, chunkRows("k", []byte("abc"), 3))
+	assert.Equal(t, []row{{key: "k", value: []byte("abc")}}, chunkRows("k", []byte("abc"), 0))
+}
+
+func TestChunkIncomplete(t *testing.T) {
+	rows := chunkRows("k", []byte("abcdef"), 2)
+	r := newReassembler()
+	r.add(rows[0].key, rows[0].value)
+	r.add(rows[2].key, rows[2].value)
+
+	_, ok := r.values()["k"]
+	assert.False(t, ok)
+}
+
+func TestChunkLikePattern(t *testing.T) {
+	assert.Equal(t, `my\_org-project#chunk-%`, chunkLikePattern("my_org-project"))
+}
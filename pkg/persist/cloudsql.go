@@ -57,7 +57,15 @@ func newCloudMySQL(cfg Config) (*MySQL, error) {
 	}
 
 	dbx := sqlx.NewDb(db, "mysql")
-	return &MySQL{db: dbx}, nil
+	configurePool(dbx, cfg)
+
+	return &MySQL{
+		db:      dbx,
+		ns:      cfg.Namespace,
+		batch:   newBatch(cfg.batchSize()),
+		writer:  newSQLWriter(dbx, mysqlUpsert, cfg),
+		timeout: cfg.queryTimeout(),
+	}, nil
 }
 
 func newCloudPostgres(cfg Config) (*Postgres, error) {
@@ -73,5 +81,13 @@ func newCloudPostgres(cfg Config) (*Postgres, error) {
 	}
 
 	klog.Infof("opened cloudsqlpostgres db at %s", cfg.Path)
-	return &Postgres{db: dbx}, nil
+	configurePool(dbx, cfg)
+
+	return &Postgres{
+		db:      dbx,
+		ns:      cfg.Namespace,
+		batch:   newBatch(cfg.batchSize()),
+		writer:  newSQLWriter(dbx, pgUpsert, cfg),
+		timeout: cfg.queryTimeout(),
+	}, nil
 }
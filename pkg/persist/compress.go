@@ -0,0 +1,92 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persist
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/patrickmn/go-cache"
+)
+
+// gzipMagic begins every gzip stream. A gob stream never does, as it begins with a message length, and 0x8b is not
+// a valid second byte of one, so values written before compression was enabled are told apart by it.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// compress gzips bytes
+func compress(b []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	zw := gzip.NewWriter(buf)
+	if _, err := zw.Write(b); err != nil {
+		return nil, fmt.Errorf("gzip: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("gzip close: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress gunzips bytes, returning them as-is if they are not gzipped
+func decompress(b []byte) ([]byte, error) {
+	if !bytes.HasPrefix(b, gzipMagic) {
+		return b, nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("gunzip: %w", err)
+	}
+	defer zr.Close()
+
+	out, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("gunzip: %w", err)
+	}
+	return out, nil
+}
+
+// encodeItem returns the gob encoding of a thing as a cache item, gzipped if compressed is set
+func encodeItem(th *provider.Thing, compressed bool) ([]byte, error) {
+	b := new(bytes.Buffer)
+	ge := gob.NewEncoder(b)
+	if err := ge.Encode(cache.Item{Object: th}); err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+
+	if !compressed {
+		return b.Bytes(), nil
+	}
+	return compress(b.Bytes())
+}
+
+// decodeItem decodes a cache item written by encodeItem, whether or not it was gzipped
+func decodeItem(b []byte) (cache.Item, error) {
+	var item cache.Item
+
+	b, err := decompress(b)
+	if err != nil {
+		return item, err
+	}
+
+	gd := gob.NewDecoder(bytes.NewReader(b))
+	if err := gd.Decode(&item); err != nil {
+		return item, fmt.Errorf("decode: %w", err)
+	}
+	return item, nil
+}
@@ -0,0 +1,138 @@
+package persist
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func strPtr(s string) *string { return &s }
+
+// representativeThing returns a thing shaped like a cached issue timeline with comments
+func representativeThing() *provider.Thing {
+	th := &provider.Thing{Created: time.Now().Truncate(time.Second)}
+	events := []string{"labeled", "commented", "assigned", "referenced", "cross-referenced", "closed", "reopened"}
+
+	for i := 0; i < 100; i++ {
+		id := int64(1000 + i)
+		created := time.Now().Add(time.Duration(-i) * time.Hour)
+		login := fmt.Sprintf("user%d", i%7)
+		u := &provider.User{
+			Login:     strPtr(login),
+			ID:        &id,
+			AvatarURL: strPtr("https://avatars.githubusercontent.com/u/" + login + "?v=4"),
+			HTMLURL:   strPtr("https://github.com/" + login),
+			Type:      strPtr("User"),
+		}
+
+		th.IssueComments = append(th.IssueComments, &provider.IssueComment{
+			ID:                &id,
+			Body:              strPtr(fmt.Sprintf("Thanks for the report! I can reproduce this on v1.%d. Could you share the output of `kubectl get pods -A` and the logs from the failing container?", i)),
+			User:              u,
+			CreatedAt:         &created,
+			UpdatedAt:         &created,
+			AuthorAssociation: strPtr("MEMBER"),
+			URL:               strPtr(fmt.Sprintf("https://api.github.com/repos/google/triage-party/issues/comments/%d", id)),
+			HTMLURL:           strPtr(fmt.Sprintf("https://github.com/google/triage-party/issues/42#issuecomment-%d", id)),
+			IssueURL:          strPtr("https://api.github.com/repos/google/triage-party/issues/42"),
+		})
+
+		th.Timeline = append(th.Timeline, &provider.Timeline{
+			ID:        &id,
+			URL:       strPtr(fmt.Sprintf("https://api.github.com/repos/google/triage-party/issues/events/%d", id)),
+			Actor:     u,
+			Event:     strPtr(events[i%len(events)]),
+			CreatedAt: &created,
+		})
+	}
+	return th
+}
+
+func TestCompressSize(t *testing.T) {
+	gob.Register(&provider.Thing{})
+	th := representativeThing()
+
+	plain, err := encodeItem(th, false)
+	assert.NoError(t, err)
+	gz, err := encodeItem(th, true)
+	assert.NoError(t, err)
+
+	t.Logf("representative thing: %d bytes uncompressed, %d bytes compressed (%.1f%% smaller)", len(plain), len(gz), 100*(1-float64(len(gz))/float64(len(plain))))
+	assert.Less(t, len(gz), len(plain)/3)
+
+	for _, b := range [][]byte{plain, gz} {
+		item, err := decodeItem(b)
+		if assert.NoError(t, err) {
+			got := item.Object.(*provider.Thing)
+			assert.True(t, th.Created.Equal(got.Created))
+			assert.Len(t, got.IssueComments, 100)
+			assert.Equal(t, th.Timeline[3].GetEvent(), got.Timeline[3].GetEvent())
+		}
+	}
+
+	_, err = decodeItem(append([]byte{}, gzipMagic...))
+	assert.Error(t, err)
+}
+
+func TestDiskCompress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "compress")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, tc := range []struct {
+		name  string
+		write bool
+		read  bool
+	}{
+		{"compressed", true, true},
+		{"uncompressed file, compressed reader", false, true},
+		{"compressed file, uncompressed reader", true, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.Replace(tc.name, " ", "_", -1)+".pc")
+			w, err := New(Config{Type: "disk", Path: path, Compress: tc.write})
+			assert.NoError(t, err)
+			assert.NoError(t, w.Initialize())
+			assert.NoError(t, w.Set("k", representativeThing()))
+			assert.NoError(t, w.Cleanup())
+
+			r, err := New(Config{Type: "disk", Path: path, Compress: tc.read})
+			assert.NoError(t, err)
+			assert.NoError(t, r.Initialize())
+			if got := r.GetNewerThan("k", time.Time{}); assert.NotNil(t, got) {
+				assert.Len(t, got.Timeline, 100)
+			}
+		})
+	}
+}
+
+func TestSQLiteCompress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "compress")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tp.db")
+
+	// Rows written before compression was enabled are still read
+	old := newTestSQLite(t, path, Config{})
+	assert.NoError(t, old.Set("old", &provider.Thing{CheckState: "old"}))
+
+	m := newTestSQLite(t, path, Config{Compress: true})
+	assert.NoError(t, m.Set("new", &provider.Thing{CheckState: "new"}))
+
+	for _, c := range []*SQLite{old, m} {
+		for _, k := range []string{"old", "new"} {
+			if got := c.GetNewerThan(k, time.Time{}); assert.NotNil(t, got) {
+				assert.Equal(t, k, got.CheckState)
+			}
+		}
+	}
+}
@@ -16,7 +16,6 @@
 package persist
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/gob"
 	"fmt"
@@ -35,11 +34,15 @@ import (
 type Disk struct {
 	path  string
 	cache *cache.Cache
+	ns    string
+
+	// gzips the file
+	compress bool
 }
 
 // NewDisk returns a new disk cache
 func NewDisk(cfg Config) (*Disk, error) {
-	return &Disk{path: cfg.Path}, nil
+	return &Disk{path: cfg.Path, ns: cfg.Namespace, compress: cfg.Compress}, nil
 }
 
 func (d *Disk) String() string {
@@ -59,14 +62,18 @@ func (d *Disk) Initialize() error {
 }
 
 func (d *Disk) load() error {
-	f, err := os.Open(d.path)
+	b, err := ioutil.ReadFile(d.path)
 	if err != nil {
 		return fmt.Errorf("open: %w", err)
 	}
-	defer f.Close()
+
+	b, err = decompress(b)
+	if err != nil {
+		return err
+	}
 
 	decoded := map[string]cache.Item{}
-	gd := gob.NewDecoder(bufio.NewReader(f))
+	gd := gob.NewDecoder(bytes.NewReader(b))
 
 	err = gd.Decode(&decoded)
 	if err != nil && err != io.EOF {
@@ -84,20 +91,30 @@ func (d *Disk) load() error {
 
 // Set stores a thing into memory
 func (d *Disk) Set(key string, t *provider.Thing) error {
-	setMem(d.cache, key, t)
+	setMem(d.cache, namespaced(d.ns, key), t)
 	// Implementation quirk: the disk driver does not persist until Cleanup() is called
 	return nil
 }
 
+// Tombstone marks a thing as absent until it is set again
+func (d *Disk) Tombstone(key string) error {
+	return d.Set(key, tombstone())
+}
+
 // DeleteOlderThan deletes a thing older than a timestamp
 func (d *Disk) DeleteOlderThan(key string, t time.Time) error {
-	deleteOlderMem(d.cache, key, t)
+	deleteOlderMem(d.cache, namespaced(d.ns, key), t)
 	return nil
 }
 
 // GetNewerThan returns a thing older than a timestamp
 func (d *Disk) GetNewerThan(key string, t time.Time) *provider.Thing {
-	return newerThanMem(d.cache, key, t)
+	return newerThanMem(d.cache, namespaced(d.ns, key), t)
+}
+
+// List returns all things created after a timestamp, keyed without the namespace
+func (d *Disk) List(t time.Time) map[string]*provider.Thing {
+	return listMem(d.cache, d.ns, t)
 }
 
 func (d *Disk) Cleanup() error {
@@ -110,11 +127,20 @@ func (d *Disk) Cleanup() error {
 		return fmt.Errorf("encode: %w", err)
 	}
 
+	out := b.Bytes()
+	if d.compress {
+		var err error
+		out, err = compress(out)
+		if err != nil {
+			return err
+		}
+	}
+
 	if err := os.MkdirAll(filepath.Dir(d.path), 0700); err != nil {
 		return err
 	}
 
-	return ioutil.WriteFile(d.path, b.Bytes(), 0644)
+	return ioutil.WriteFile(d.path, out, 0644)
 }
 
 func findCacheRoot() string {
@@ -16,6 +16,7 @@ package persist
 
 import (
 	"github.com/google/triage-party/pkg/provider"
+	"strings"
 	"time"
 
 	"github.com/patrickmn/go-cache"
@@ -51,6 +52,12 @@ func setMem(c *cache.Cache, key string, th *provider.Thing) {
 	c.Set(key, th, MaxLoadAge)
 }
 
+// tombstone returns a thing which marks a key as absent. It is stored like any other thing, so that it is
+// persisted and expires in the same way.
+func tombstone() *provider.Thing {
+	return &provider.Thing{Created: time.Now(), Tombstone: true}
+}
+
 func newerThanMem(c *cache.Cache, key string, t time.Time) *provider.Thing {
 	x, ok := c.Get(key)
 	if !ok {
@@ -71,9 +78,39 @@ func newerThanMem(c *cache.Cache, key string, t time.Time) *provider.Thing {
 		return nil
 	}
 
+	if th.Tombstone {
+		klog.V(1).Infof("%s is tombstoned", key)
+		return nil
+	}
+
 	return th
 }
 
+// listMem returns things within a namespace created after a timestamp, keyed without the namespace. Tombstones
+// are included, so that copying every listed thing reproduces the cache.
+func listMem(c *cache.Cache, ns string, t time.Time) map[string]*provider.Thing {
+	prefix := namespaced(ns, "")
+	found := map[string]*provider.Thing{}
+
+	for key, v := range c.Items() {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		th, ok := v.Object.(*provider.Thing)
+		if !ok {
+			klog.V(1).Infof("%s is not of type Thing", key)
+			continue
+		}
+
+		if th.Created.Before(t) {
+			continue
+		}
+		found[strings.TrimPrefix(key, prefix)] = th
+	}
+	return found
+}
+
 func deleteOlderMem(c *cache.Cache, key string, t time.Time) {
 	i := newerThanMem(c, key, t)
 
@@ -25,11 +25,12 @@ import (
 
 type Memory struct {
 	cache *cache.Cache
+	ns    string
 }
 
 // NewMemory returns a new Memory cache
 func NewMemory(cfg Config) (*Memory, error) {
-	return &Memory{}, nil
+	return &Memory{ns: cfg.Namespace}, nil
 }
 
 func (m *Memory) String() string {
@@ -43,19 +44,29 @@ func (m *Memory) Initialize() error {
 
 // Set stores a thing into memory
 func (m *Memory) Set(key string, t *provider.Thing) error {
-	setMem(m.cache, key, t)
+	setMem(m.cache, namespaced(m.ns, key), t)
 	return nil
 }
 
+// Tombstone marks a thing as absent until it is set again
+func (m *Memory) Tombstone(key string) error {
+	return m.Set(key, tombstone())
+}
+
 // DeleteOlderThan deletes a thing older than a timestamp
 func (m *Memory) DeleteOlderThan(key string, t time.Time) error {
-	deleteOlderMem(m.cache, key, t)
+	deleteOlderMem(m.cache, namespaced(m.ns, key), t)
 	return nil
 }
 
 // GetNewerThan returns a thing older than a timestamp
 func (m *Memory) GetNewerThan(key string, t time.Time) *provider.Thing {
-	return newerThanMem(m.cache, key, t)
+	return newerThanMem(m.cache, namespaced(m.ns, key), t)
+}
+
+// List returns all things created after a timestamp, keyed without the namespace
+func (m *Memory) List(t time.Time) map[string]*provider.Thing {
+	return listMem(m.cache, m.ns, t)
 }
 
 func (m *Memory) Cleanup() error {
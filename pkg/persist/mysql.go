@@ -16,8 +16,6 @@
 package persist
 
 import (
-	"bytes"
-	"encoding/gob"
 	"fmt"
 	"github.com/google/triage-party/pkg/provider"
 	"time"
@@ -37,6 +35,9 @@ CREATE TABLE IF NOT EXISTS persist (
 	INDEX saved_idx (saved)
 );`
 
+// mysqlUpsert replaces existing rows within a multi-row INSERT
+var mysqlUpsert = `ON DUPLICATE KEY UPDATE k=VALUES(k), v=VALUES(v), saved=VALUES(saved)`
+
 // sqlItem maps to schema
 type sqlItem struct {
 	ID    int64     `db:"id"`
@@ -49,6 +50,15 @@ type MySQL struct {
 	cache *cache.Cache
 	db    *sqlx.DB
 	path  string
+
+	// prefixed to keys, so that multiple instances may share a database
+	ns string
+
+	batch  *batch
+	writer *sqlWriter
+
+	// bounds each delete during cleanup
+	timeout time.Duration
 }
 
 // NewMySQL returns a new MySQL cache
@@ -58,9 +68,15 @@ func NewMySQL(cfg Config) (*MySQL, error) {
 		return nil, err
 	}
 
+	configurePool(dbx, cfg)
+
 	m := &MySQL{
-		db:   dbx,
-		path: cfg.Path,
+		db:      dbx,
+		path:    cfg.Path,
+		ns:      cfg.Namespace,
+		batch:   newBatch(cfg.batchSize()),
+		writer:  newSQLWriter(dbx, mysqlUpsert, cfg),
+		timeout: cfg.queryTimeout(),
 	}
 
 	return m, nil
@@ -86,27 +102,29 @@ func (m *MySQL) loadItems() error {
 	newerThan := time.Now().Add(-1 * MaxLoadAge)
 
 	klog.Infof("loading items from persist table newer than %s ...", newerThan)
-	rows, err := m.db.Queryx(`SELECT * FROM persist WHERE saved > ?`, newerThan)
+	rows, err := m.db.Queryx(`SELECT * FROM persist WHERE saved > ? AND k LIKE ?`, newerThan, escapeLike(namespaced(m.ns, ""))+"%")
 	if err != nil {
 		return fmt.Errorf("query: %w", err)
 	}
 
-	decoded := map[string]cache.Item{}
-
+	r := newReassembler()
 	for rows.Next() {
 		var mi sqlItem
 		err = rows.StructScan(&mi)
 		if err != nil {
 			return fmt.Errorf("structscan: %w", err)
 		}
+		r.add(mi.Key, mi.Value)
+	}
 
-		var item cache.Item
-		gd := gob.NewDecoder(bytes.NewBuffer(mi.Value))
-		if err := gd.Decode(&item); err != nil {
-			klog.Errorf("decode failed for %s (saved %s, bytes: %d): %v", mi.Key, mi.Saved, len(mi.Value), err)
+	decoded := map[string]cache.Item{}
+	for k, v := range r.values() {
+		item, err := decodeItem(v)
+		if err != nil {
+			klog.Errorf("decode failed for %s (bytes: %d): %v", k, len(v), err)
 			continue
 		}
-		decoded[mi.Key] = item
+		decoded[k] = item
 	}
 
 	klog.Infof("%d items loaded from MySQL", len(decoded))
@@ -116,52 +134,69 @@ func (m *MySQL) loadItems() error {
 
 // Set stores a thing
 func (m *MySQL) Set(key string, th *provider.Thing) error {
+	key = namespaced(m.ns, key)
 	setMem(m.cache, key, th)
 
+	items := m.batch.add(key, th)
+	if len(items) == 0 {
+		return nil
+	}
+
 	go func() {
-		err := m.persist(key, th)
-		if err != nil {
-			klog.Errorf("failed to persist %s: %s", key, err)
+		if err := m.writer.write(items); err != nil {
+			klog.Errorf("failed to persist %d items, will retry: %s", len(items), err)
+			m.batch.restore(items)
 		}
 	}()
 
 	return nil
 }
 
+// Tombstone marks a thing as absent until it is set again
+func (m *MySQL) Tombstone(key string) error {
+	return m.Set(key, tombstone())
+}
+
 // DeleteOlderThan deletes a thing older than a timestamp
 func (m *MySQL) DeleteOlderThan(key string, t time.Time) error {
-	deleteOlderMem(m.cache, key, t)
+	deleteOlderMem(m.cache, namespaced(m.ns, key), t)
 	return nil
 }
 
 // GetNewerThan returns a Item older than a timestamp
 func (m *MySQL) GetNewerThan(key string, t time.Time) *provider.Thing {
-	return newerThanMem(m.cache, key, t)
+	return newerThanMem(m.cache, namespaced(m.ns, key), t)
 }
 
-// persist writes an thing to MySQL
-func (m *MySQL) persist(key string, th *provider.Thing) error {
-	b := new(bytes.Buffer)
-	ge := gob.NewEncoder(b)
-
-	item := cache.Item{Object: th}
-	if err := ge.Encode(item); err != nil {
-		return fmt.Errorf("encode: %w", err)
-	}
-
-	_, err := m.db.Exec(`
-		INSERT INTO persist (k, v, saved) VALUES (?, ?, ?)
-		ON DUPLICATE KEY UPDATE k=VALUES(k), v=VALUES(v)`, key, b.Bytes(), time.Now())
-
-	return err
+// List returns all things created after a timestamp, keyed without the namespace
+func (m *MySQL) List(t time.Time) map[string]*provider.Thing {
+	return listMem(m.cache, m.ns, t)
 }
 
 // Cleanup deletes older cache items
 func (m *MySQL) Cleanup() error {
+	// Flush buffered writes first, so that they are not lost if we are about to exit
+	items := m.batch.take()
+	if err := m.writer.write(items); err != nil {
+		m.batch.restore(items)
+		return fmt.Errorf("flush: %w", err)
+	}
+
 	start := time.Now()
 	maxAge := start.Add(-1 * MaxSaveAge)
 
-	res, err := m.db.Exec(`DELETE FROM persist WHERE saved < ?`, maxAge)
+	ctx, cancel := queryContext(m.timeout)
+	defer cancel()
+
+	query := `DELETE FROM persist WHERE saved < ?`
+	args := []interface{}{maxAge}
+	// Leave the rows of other namespaces to their own instances
+	if m.ns != "" {
+		query += ` AND k LIKE ?`
+		args = append(args, escapeLike(namespaced(m.ns, ""))+"%")
+	}
+
+	res, err := m.db.ExecContext(ctx, query, args...)
 
 	if err != nil {
 		return fmt.Errorf("delete exec: %w", err)
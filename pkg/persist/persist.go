@@ -20,6 +20,10 @@ import (
 	"fmt"
 	"github.com/google/triage-party/pkg/provider"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -34,6 +38,45 @@ var (
 type Config struct {
 	Type string
 	Path string
+
+	// MaxBlobSize is the largest value written to a single SQL row, or sent in a single Redis command
+	// (default: DefaultMaxBlobSize)
+	MaxBlobSize int
+
+	// Compress gzips each value before it is written. Values written without it are still read.
+	Compress bool
+
+	// Namespace is prefixed to every key, so that multiple instances may share a backend
+	Namespace string
+
+	// BatchSize is how many writes SQL backends buffer before sending them together (default: 1, unbuffered)
+	BatchSize int
+
+	// MaxOpenConns is the most connections SQL backends open to the database (default: DefaultMaxOpenConns)
+	MaxOpenConns int
+	// MaxIdleConns is the most idle connections SQL backends keep open (default: DefaultMaxIdleConns)
+	MaxIdleConns int
+	// ConnMaxLifetime is how long SQL backends reuse a connection (default: DefaultConnMaxLifetime)
+	ConnMaxLifetime time.Duration
+	// QueryTimeout bounds each SQL write or delete (default: DefaultQueryTimeout)
+	QueryTimeout time.Duration
+	// MaxConcurrentWrites is how many write transactions SQL and Redis backends run at once
+	// (default: DefaultMaxConcurrentWrites)
+	MaxConcurrentWrites int
+}
+
+func (c Config) batchSize() int {
+	if c.BatchSize > 1 {
+		return c.BatchSize
+	}
+	return 1
+}
+
+func (c Config) maxBlobSize() int {
+	if c.MaxBlobSize > 0 {
+		return c.MaxBlobSize
+	}
+	return DefaultMaxBlobSize
 }
 
 // Cacher is the cache interface we support
@@ -41,29 +84,84 @@ type Cacher interface {
 	String() string
 
 	Set(string, *provider.Thing) error
+	Tombstone(string) error
 	DeleteOlderThan(string, time.Time) error
 	GetNewerThan(string, time.Time) *provider.Thing
+	List(time.Time) map[string]*provider.Thing
 
 	Initialize() error
 	Cleanup() error
 }
 
+// namespaced returns a key within a namespace
+func namespaced(ns string, key string) string {
+	if ns == "" {
+		return key
+	}
+	return ns + ":" + key
+}
+
+// Factory creates a Cacher from configuration
+type Factory func(Config) (Cacher, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{
+		"mysql":    func(cfg Config) (Cacher, error) { return NewMySQL(cfg) },
+		"cloudsql": func(cfg Config) (Cacher, error) { return NewCloudSQL(cfg) },
+		"postgres": func(cfg Config) (Cacher, error) { return NewPostgres(cfg) },
+		"redis":    func(cfg Config) (Cacher, error) { return NewRedis(cfg) },
+		"sqlite":   func(cfg Config) (Cacher, error) { return NewSQLite(cfg) },
+		"disk":     func(cfg Config) (Cacher, error) { return NewDisk(cfg) },
+		"memory":   func(cfg Config) (Cacher, error) { return NewMemory(cfg) },
+	}
+)
+
+// Register makes a backend available to New by name, so that out-of-tree Cacher implementations can be used
+// without modifying this package. It panics if the name is empty, already registered, or the factory is nil.
+func Register(name string, f Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if name == "" {
+		panic("persist: Register with empty name")
+	}
+	if f == nil {
+		panic(fmt.Sprintf("persist: Register factory for %q is nil", name))
+	}
+	if _, dup := factories[name]; dup {
+		panic(fmt.Sprintf("persist: Register called twice for %q", name))
+	}
+	factories[name] = f
+}
+
+// Backends returns the sorted names of all registered backends
+func Backends() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := []string{}
+	for n := range factories {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func New(cfg Config) (Cacher, error) {
 	gob.Register(&provider.Thing{})
-	switch cfg.Type {
-	case "mysql":
-		return NewMySQL(cfg)
-	case "cloudsql":
-		return NewCloudSQL(cfg)
-	case "postgres":
-		return NewPostgres(cfg)
-	case "disk", "":
-		return NewDisk(cfg)
-	case "memory":
-		return NewMemory(cfg)
-	default:
-		return nil, fmt.Errorf("unknown backend: %q", cfg.Type)
+	if cfg.Type == "" {
+		cfg.Type = "disk"
+	}
+
+	factoriesMu.RLock()
+	f, ok := factories[cfg.Type]
+	factoriesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown backend: %q (available: %v)", cfg.Type, Backends())
 	}
+	return f(cfg)
 }
 
 // FromEnv is shared magic between binaries
@@ -83,10 +181,54 @@ func FromEnv(backend string, path string, configPath string, reposOverride strin
 		path = DefaultDiskPath(configPath, reposOverride)
 	}
 
-	c, err := New(Config{
-		Type: backend,
-		Path: path,
-	})
+	if backend == "sqlite" && path == "" {
+		path = strings.TrimSuffix(DefaultDiskPath(configPath, reposOverride), ".pc") + ".db"
+	}
+
+	cfg := Config{
+		Type:      backend,
+		Path:      path,
+		Namespace: os.Getenv("PERSIST_NAMESPACE"),
+	}
+
+	if s := os.Getenv("PERSIST_COMPRESS"); s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("PERSIST_COMPRESS: %w", err)
+		}
+		cfg.Compress = b
+	}
+
+	for name, dst := range map[string]*int{
+		"PERSIST_MAX_BLOB_SIZE":         &cfg.MaxBlobSize,
+		"PERSIST_BATCH_SIZE":            &cfg.BatchSize,
+		"PERSIST_MAX_OPEN_CONNS":        &cfg.MaxOpenConns,
+		"PERSIST_MAX_IDLE_CONNS":        &cfg.MaxIdleConns,
+		"PERSIST_MAX_CONCURRENT_WRITES": &cfg.MaxConcurrentWrites,
+	} {
+		if s := os.Getenv(name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			*dst = n
+		}
+	}
+
+	for name, dst := range map[string]*time.Duration{
+		"PERSIST_CONN_MAX_LIFETIME": &cfg.ConnMaxLifetime,
+		"PERSIST_QUERY_TIMEOUT":     &cfg.QueryTimeout,
+	} {
+		if s := os.Getenv(name); s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			*dst = d
+		}
+	}
+
+	c, err := New(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("new from %s: %s: %w", backend, path, err)
 	}
@@ -0,0 +1,109 @@
+package persist
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	called := false
+	Register("test-kv", func(cfg Config) (Cacher, error) {
+		called = true
+		return NewMemory(cfg)
+	})
+
+	c, err := New(Config{Type: "test-kv"})
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+	assert.True(t, called)
+	assert.Contains(t, Backends(), "test-kv")
+
+	assert.Panics(t, func() { Register("test-kv", NewCloudSQL) })
+	assert.Panics(t, func() { Register("memory", NewCloudSQL) })
+
+	_, err = New(Config{Type: "no-such-backend"})
+	assert.Error(t, err)
+}
+
+func TestTombstone(t *testing.T) {
+	m, err := NewMemory(Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, m.Initialize())
+
+	assert.NoError(t, m.Set("k", &provider.Thing{}))
+	assert.NotNil(t, m.GetNewerThan("k", time.Time{}))
+
+	assert.NoError(t, m.Tombstone("k"))
+	assert.Nil(t, m.GetNewerThan("k", time.Time{}))
+
+	// A later write replaces the tombstone
+	assert.NoError(t, m.Set("k", &provider.Thing{}))
+	assert.NotNil(t, m.GetNewerThan("k", time.Time{}))
+}
+
+func TestExportImport(t *testing.T) {
+	src, err := NewMemory(Config{Namespace: "old"})
+	assert.NoError(t, err)
+	assert.NoError(t, src.Initialize())
+
+	created := time.Now().Add(-1 * time.Hour).Truncate(time.Second)
+	assert.NoError(t, src.Set("k", &provider.Thing{Created: created, CheckState: "success"}))
+	assert.NoError(t, src.Set("expired", &provider.Thing{Created: time.Now().Add(-2 * MaxLoadAge)}))
+	assert.NoError(t, src.Tombstone("gone"))
+
+	var buf bytes.Buffer
+	assert.NoError(t, Export(src, &buf))
+
+	dst, err := NewMemory(Config{Namespace: "new"})
+	assert.NoError(t, err)
+	assert.NoError(t, dst.Initialize())
+	assert.NoError(t, Import(dst, &buf))
+
+	got := dst.GetNewerThan("k", time.Time{})
+	if assert.NotNil(t, got) {
+		assert.True(t, created.Equal(got.Created))
+		assert.Equal(t, "success", got.CheckState)
+	}
+	assert.Nil(t, dst.GetNewerThan("expired", time.Time{}))
+	assert.Nil(t, dst.GetNewerThan("gone", time.Time{}))
+	assert.Len(t, dst.List(time.Time{}), 2)
+
+	assert.Error(t, Import(dst, bytes.NewBufferString("garbage")))
+}
+
+func TestSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fallback", "snapshot.gob")
+
+	src, err := NewMemory(Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, src.Initialize())
+	assert.NoError(t, src.Set("k", &provider.Thing{CheckState: "success"}))
+	assert.NoError(t, WriteSnapshot(src, path))
+
+	dst, err := NewMemory(Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, dst.Initialize())
+
+	found, err := RestoreSnapshot(dst, path)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	if got := dst.GetNewerThan("k", time.Time{}); assert.NotNil(t, got) {
+		assert.Equal(t, "success", got.CheckState)
+	}
+
+	// Restored snapshots are removed, so that they are not imported again
+	found, err = RestoreSnapshot(dst, path)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
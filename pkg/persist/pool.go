@@ -0,0 +1,93 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persist
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultMaxOpenConns is the most connections a SQL backend opens. Writes happen in the background, so a
+	// small pool is plenty, and keeps several instances from exhausting a shared database.
+	DefaultMaxOpenConns = 8
+	// DefaultMaxIdleConns is the most idle connections a SQL backend keeps open
+	DefaultMaxIdleConns = 4
+	// DefaultConnMaxLifetime is how long a SQL connection is reused, which should be shorter than any server or
+	// proxy idle timeout
+	DefaultConnMaxLifetime = 30 * time.Minute
+	// DefaultQueryTimeout bounds each SQL write or delete
+	DefaultQueryTimeout = 2 * time.Minute
+	// DefaultMaxConcurrentWrites is the most write transactions a SQL backend runs at once: half of the default
+	// pool, leaving connections free for cleanup
+	DefaultMaxConcurrentWrites = 4
+)
+
+func (c Config) maxOpenConns() int {
+	if c.MaxOpenConns > 0 {
+		return c.MaxOpenConns
+	}
+	return DefaultMaxOpenConns
+}
+
+func (c Config) maxIdleConns() int {
+	if c.MaxIdleConns > 0 {
+		return c.MaxIdleConns
+	}
+	return DefaultMaxIdleConns
+}
+
+func (c Config) connMaxLifetime() time.Duration {
+	if c.ConnMaxLifetime > 0 {
+		return c.ConnMaxLifetime
+	}
+	return DefaultConnMaxLifetime
+}
+
+func (c Config) maxConcurrentWrites() int {
+	if c.MaxConcurrentWrites > 0 {
+		return c.MaxConcurrentWrites
+	}
+	return DefaultMaxConcurrentWrites
+}
+
+func (c Config) queryTimeout() time.Duration {
+	if c.QueryTimeout > 0 {
+		return c.QueryTimeout
+	}
+	return DefaultQueryTimeout
+}
+
+// configurePool applies connection pool settings to a SQL database
+func configurePool(db *sqlx.DB, cfg Config) {
+	db.SetMaxOpenConns(cfg.maxOpenConns())
+	db.SetMaxIdleConns(cfg.maxIdleConns())
+	db.SetConnMaxLifetime(cfg.connMaxLifetime())
+	klog.Infof("sql pool: max open=%d, max idle=%d, max lifetime=%s, query timeout=%s, max concurrent writes=%d", cfg.maxOpenConns(), cfg.maxIdleConns(), cfg.connMaxLifetime(), cfg.queryTimeout(), cfg.maxConcurrentWrites())
+	if cfg.maxConcurrentWrites() >= cfg.maxOpenConns() {
+		klog.Warningf("max concurrent writes (%d) is not below max open connections (%d): writes may starve cleanup of connections", cfg.maxConcurrentWrites(), cfg.maxOpenConns())
+	}
+}
+
+// queryContext returns a context which expires after a query timeout, or never if the timeout is 0
+func queryContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package persist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolDefaults(t *testing.T) {
+	var c Config
+	assert.Equal(t, DefaultMaxOpenConns, c.maxOpenConns())
+	assert.Equal(t, DefaultMaxIdleConns, c.maxIdleConns())
+	assert.Equal(t, DefaultConnMaxLifetime, c.connMaxLifetime())
+	assert.Equal(t, DefaultQueryTimeout, c.queryTimeout())
+	assert.Equal(t, DefaultMaxConcurrentWrites, c.maxConcurrentWrites())
+
+	c = Config{MaxOpenConns: 20, MaxIdleConns: 10, ConnMaxLifetime: time.Minute, QueryTimeout: time.Second, MaxConcurrentWrites: 2}
+	assert.Equal(t, 20, c.maxOpenConns())
+	assert.Equal(t, 10, c.maxIdleConns())
+	assert.Equal(t, time.Minute, c.connMaxLifetime())
+	assert.Equal(t, time.Second, c.queryTimeout())
+	assert.Equal(t, 2, c.maxConcurrentWrites())
+	assert.Equal(t, 2, cap(newSQLWriter(nil, "", c).slots))
+}
+
+func TestQueryContext(t *testing.T) {
+	ctx, cancel := queryContext(time.Minute)
+	defer cancel()
+	_, ok := ctx.Deadline()
+	assert.True(t, ok)
+
+	ctx, cancel = queryContext(0)
+	defer cancel()
+	_, ok = ctx.Deadline()
+	assert.False(t, ok)
+}
+
+func TestFromEnvPool(t *testing.T) {
+	t.Setenv("PERSIST_MAX_OPEN_CONNS", "x")
+	_, err := FromEnv("memory", "", "", "")
+	assert.Error(t, err)
+
+	t.Setenv("PERSIST_MAX_OPEN_CONNS", "4")
+	t.Setenv("PERSIST_QUERY_TIMEOUT", "10")
+	_, err = FromEnv("memory", "", "", "")
+	assert.Error(t, err)
+}
@@ -16,8 +16,6 @@
 package persist
 
 import (
-	"bytes"
-	"encoding/gob"
 	"fmt"
 	"github.com/google/triage-party/pkg/provider"
 	"time"
@@ -39,10 +37,22 @@ CREATE TABLE IF NOT EXISTS persist (
 CREATE INDEX IF NOT EXISTS saved_idx ON persist (saved);
 `
 
+// pgUpsert replaces existing rows within a multi-row INSERT
+var pgUpsert = `ON CONFLICT (k) DO UPDATE SET v=EXCLUDED.v, saved=EXCLUDED.saved`
+
 type Postgres struct {
 	cache *cache.Cache
 	db    *sqlx.DB
 	path  string
+
+	// prefixed to keys, so that multiple instances may share a database
+	ns string
+
+	batch  *batch
+	writer *sqlWriter
+
+	// bounds each delete during cleanup
+	timeout time.Duration
 }
 
 // NewPostgres returns a new Postgres cache
@@ -52,9 +62,15 @@ func NewPostgres(cfg Config) (*Postgres, error) {
 		return nil, fmt.Errorf("connect: %w", err)
 	}
 
+	configurePool(dbx, cfg)
+
 	m := &Postgres{
-		db:   dbx,
-		path: cfg.Path,
+		db:      dbx,
+		path:    cfg.Path,
+		ns:      cfg.Namespace,
+		batch:   newBatch(cfg.batchSize()),
+		writer:  newSQLWriter(dbx, pgUpsert, cfg),
+		timeout: cfg.queryTimeout(),
 	}
 
 	return m, nil
@@ -81,27 +97,29 @@ func (m *Postgres) loadItems() error {
 	newerThan := time.Now().Add(-1 * MaxLoadAge)
 
 	klog.Infof("loading items from persist table newer than %s ...", newerThan)
-	rows, err := m.db.Queryx(`SELECT * FROM persist WHERE saved > $1`, newerThan)
+	rows, err := m.db.Queryx(`SELECT * FROM persist WHERE saved > $1 AND k LIKE $2`, newerThan, escapeLike(namespaced(m.ns, ""))+"%")
 	if err != nil {
 		return fmt.Errorf("query: %w", err)
 	}
 
-	decoded := map[string]cache.Item{}
-
+	r := newReassembler()
 	for rows.Next() {
 		var mi sqlItem
 		err = rows.StructScan(&mi)
 		if err != nil {
 			return fmt.Errorf("structscan: %w", err)
 		}
+		r.add(mi.Key, mi.Value)
+	}
 
-		var item cache.Item
-		gd := gob.NewDecoder(bytes.NewBuffer(mi.Value))
-		if err := gd.Decode(&item); err != nil {
-			klog.Errorf("decode failed for %s (saved %s, bytes: %d): %v", mi.Key, mi.Saved, len(mi.Value), err)
+	decoded := map[string]cache.Item{}
+	for k, v := range r.values() {
+		item, err := decodeItem(v)
+		if err != nil {
+			klog.Errorf("decode failed for %s (bytes: %d): %v", k, len(v), err)
 			continue
 		}
-		decoded[mi.Key] = item
+		decoded[k] = item
 	}
 
 	klog.Infof("%d items loaded from Postgres", len(decoded))
@@ -111,53 +129,69 @@ func (m *Postgres) loadItems() error {
 
 // Set stores a thing
 func (m *Postgres) Set(key string, th *provider.Thing) error {
+	key = namespaced(m.ns, key)
 	setMem(m.cache, key, th)
 
+	items := m.batch.add(key, th)
+	if len(items) == 0 {
+		return nil
+	}
+
 	go func() {
-		err := m.persist(key, th)
-		if err != nil {
-			klog.Errorf("failed to persist %s: %s", key, err)
+		if err := m.writer.write(items); err != nil {
+			klog.Errorf("failed to persist %d items, will retry: %s", len(items), err)
+			m.batch.restore(items)
 		}
 	}()
 
 	return nil
 }
 
+// Tombstone marks a thing as absent until it is set again
+func (m *Postgres) Tombstone(key string) error {
+	return m.Set(key, tombstone())
+}
+
 // DeleteOlderThan deletes a thing older than a timestamp
 func (m *Postgres) DeleteOlderThan(key string, t time.Time) error {
-	deleteOlderMem(m.cache, key, t)
+	deleteOlderMem(m.cache, namespaced(m.ns, key), t)
 	return nil
 }
 
 // GetNewerThan returns a Item older than a timestamp
 func (m *Postgres) GetNewerThan(key string, t time.Time) *provider.Thing {
-	return newerThanMem(m.cache, key, t)
+	return newerThanMem(m.cache, namespaced(m.ns, key), t)
 }
 
-// persist writes an thing to MySQL
-func (m *Postgres) persist(key string, th *provider.Thing) error {
-	b := new(bytes.Buffer)
-	ge := gob.NewEncoder(b)
-
-	item := cache.Item{Object: th}
-	if err := ge.Encode(item); err != nil {
-		return fmt.Errorf("encode: %w", err)
-	}
-
-	_, err := m.db.Exec(`
-			INSERT INTO persist (k, v, saved) VALUES ($1, $2, $3)
-			ON CONFLICT (k)
-			DO UPDATE SET v=EXCLUDED.v, saved=EXCLUDED.saved`, key, b.Bytes(), time.Now())
-
-	return err
+// List returns all things created after a timestamp, keyed without the namespace
+func (m *Postgres) List(t time.Time) map[string]*provider.Thing {
+	return listMem(m.cache, m.ns, t)
 }
 
 // Cleanup deletes older cache items
 func (m *Postgres) Cleanup() error {
+	// Flush buffered writes first, so that they are not lost if we are about to exit
+	items := m.batch.take()
+	if err := m.writer.write(items); err != nil {
+		m.batch.restore(items)
+		return fmt.Errorf("flush: %w", err)
+	}
+
 	start := time.Now()
 	maxAge := start.Add(-1 * MaxSaveAge)
 
-	res, err := m.db.Exec(`DELETE FROM persist WHERE saved < $1`, maxAge)
+	ctx, cancel := queryContext(m.timeout)
+	defer cancel()
+
+	query := `DELETE FROM persist WHERE saved < $1`
+	args := []interface{}{maxAge}
+	// Leave the rows of other namespaces to their own instances
+	if m.ns != "" {
+		query += ` AND k LIKE $2`
+		args = append(args, escapeLike(namespaced(m.ns, ""))+"%")
+	}
+
+	res, err := m.db.ExecContext(ctx, query, args...)
 
 	if err != nil {
 		return fmt.Errorf("delete exec: %w", err)
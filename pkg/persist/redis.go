@@ -0,0 +1,246 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package persist provides a persistence layer for the in-memory cache
+package persist
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/patrickmn/go-cache"
+	"k8s.io/klog/v2"
+)
+
+// redisPrefix is prefixed to every Redis key, so that a database shared with other applications only loads ours
+const redisPrefix = "persist:"
+
+// redisScanCount is how many keys are requested per SCAN, and values per MGET, while loading
+const redisScanCount = 1000
+
+type Redis struct {
+	cache  *cache.Cache
+	client *redis.Client
+
+	// prefixed to keys, so that multiple instances may share a database
+	ns string
+
+	batch *batch
+
+	// gzips each value
+	compress bool
+
+	// maxBlobSize bounds the bytes of a value sent in a single command
+	maxBlobSize int
+
+	// slots holds a token for each write in progress, bounding how many run at once
+	slots chan struct{}
+}
+
+// NewRedis returns a new Redis cache. The path is a URL, such as redis://:password@host:6379/0
+func NewRedis(cfg Config) (*Redis, error) {
+	opts, err := redis.ParseURL(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+	if err := client.Ping().Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("ping: %w", err)
+	}
+
+	m := &Redis{
+		client:      client,
+		ns:          cfg.Namespace,
+		batch:       newBatch(cfg.batchSize()),
+		compress:    cfg.Compress,
+		maxBlobSize: cfg.maxBlobSize(),
+		slots:       make(chan struct{}, cfg.maxConcurrentWrites()),
+	}
+
+	return m, nil
+}
+
+// String returns the address of the database, omitting any password
+func (m *Redis) String() string {
+	opts := m.client.Options()
+	return fmt.Sprintf("redis://%s/%d", opts.Addr, opts.DB)
+}
+
+func (m *Redis) Initialize() error {
+	if err := m.loadItems(); err != nil {
+		return fmt.Errorf("load items: %w", err)
+	}
+	return nil
+}
+
+func (m *Redis) loadItems() error {
+	prefix := redisPrefix + namespaced(m.ns, "")
+	klog.Infof("loading items from redis matching %s* ...", prefix)
+
+	decoded := map[string]cache.Item{}
+	var cursor uint64
+	for {
+		keys, next, err := m.client.Scan(cursor, escapeGlob(prefix)+"*", redisScanCount).Result()
+		if err != nil {
+			return fmt.Errorf("scan: %w", err)
+		}
+
+		if len(keys) > 0 {
+			vals, err := m.client.MGet(keys...).Result()
+			if err != nil {
+				return fmt.Errorf("mget: %w", err)
+			}
+
+			for i, v := range vals {
+				// Expired between SCAN and MGET
+				s, ok := v.(string)
+				if !ok {
+					continue
+				}
+
+				item, err := decodeItem([]byte(s))
+				if err != nil {
+					klog.Errorf("decode failed for %s (bytes: %d): %v", keys[i], len(s), err)
+					continue
+				}
+				decoded[strings.TrimPrefix(keys[i], redisPrefix)] = item
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	klog.Infof("%d items loaded from Redis", len(decoded))
+	m.cache = loadMem(decoded)
+	return nil
+}
+
+// Set stores a thing
+func (m *Redis) Set(key string, th *provider.Thing) error {
+	key = namespaced(m.ns, key)
+	setMem(m.cache, key, th)
+
+	items := m.batch.add(key, th)
+	if len(items) == 0 {
+		return nil
+	}
+
+	go func() {
+		if err := m.write(items); err != nil {
+			klog.Errorf("failed to persist %d items, will retry: %s", len(items), err)
+			m.batch.restore(items)
+		}
+	}()
+
+	return nil
+}
+
+// write stores things within a single transaction. Each key expires after MaxSaveAge, so that Redis removes stale
+// data, rather than Cleanup. Values larger than maxBlobSize are sent as a SET followed by APPENDs, so that each
+// value is still stored under a single key.
+func (m *Redis) write(items map[string]*provider.Thing) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	keys := []string{}
+	for k := range items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pipe := m.client.TxPipeline()
+	for _, k := range keys {
+		v, err := encodeItem(items[k], m.compress)
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", k, err)
+		}
+
+		rs := chunkRows(k, v, m.maxBlobSize)
+		if len(rs) > 1 {
+			klog.Infof("%s is %d bytes, sending in %d parts", k, len(v), len(rs))
+		}
+		// APPEND keeps the expiry set by SET
+		pipe.Set(redisPrefix+k, rs[0].value, redisTTL())
+		for _, r := range rs[1:] {
+			pipe.Append(redisPrefix+k, string(r.value))
+		}
+	}
+
+	m.slots <- struct{}{}
+	defer func() { <-m.slots }()
+
+	if _, err := pipe.Exec(); err != nil {
+		return fmt.Errorf("exec: %w", err)
+	}
+
+	klog.V(1).Infof("persisted %d items in %s", len(items), time.Since(start))
+	return nil
+}
+
+// redisTTL is how long a key is kept: as with SQL backends, entries are deleted after MaxSaveAge, and never loaded
+// after MaxLoadAge.
+func redisTTL() time.Duration {
+	if MaxLoadAge < MaxSaveAge {
+		return MaxLoadAge
+	}
+	return MaxSaveAge
+}
+
+// escapeGlob escapes the special characters of a Redis glob pattern
+func escapeGlob(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+	return r.Replace(s)
+}
+
+// Tombstone marks a thing as absent until it is set again
+func (m *Redis) Tombstone(key string) error {
+	return m.Set(key, tombstone())
+}
+
+// DeleteOlderThan deletes a thing older than a timestamp. Persisted keys expire by themselves.
+func (m *Redis) DeleteOlderThan(key string, t time.Time) error {
+	deleteOlderMem(m.cache, namespaced(m.ns, key), t)
+	return nil
+}
+
+// GetNewerThan returns a Item older than a timestamp
+func (m *Redis) GetNewerThan(key string, t time.Time) *provider.Thing {
+	return newerThanMem(m.cache, namespaced(m.ns, key), t)
+}
+
+// List returns all things created after a timestamp, keyed without the namespace
+func (m *Redis) List(t time.Time) map[string]*provider.Thing {
+	return listMem(m.cache, m.ns, t)
+}
+
+// Cleanup flushes buffered writes. Older keys do not need to be deleted, as they expire.
+func (m *Redis) Cleanup() error {
+	items := m.batch.take()
+	if err := m.write(items); err != nil {
+		m.batch.restore(items)
+		return fmt.Errorf("flush: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package persist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/triage-party/pkg/provider"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRedis(t *testing.T, s *miniredis.Miniredis, cfg Config) *Redis {
+	t.Helper()
+	cfg.Type = "redis"
+	cfg.Path = "redis://" + s.Addr() + "/0"
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("new redis: %v", err)
+	}
+	if err := c.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	return c.(*Redis)
+}
+
+// waitForKey waits for a background write to reach Redis
+func waitForKey(t *testing.T, s *miniredis.Miniredis, key string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !s.Exists(key) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s was not written", key)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRedis(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	defer s.Close()
+
+	m := newTestRedis(t, s, Config{})
+	assert.Equal(t, "redis://"+s.Addr()+"/0", m.String())
+
+	created := time.Now().Add(-1 * time.Hour).Truncate(time.Second)
+	assert.NoError(t, m.Set("k", &provider.Thing{Created: created, CheckState: "success"}))
+	assert.NoError(t, m.Tombstone("gone"))
+	waitForKey(t, s, "persist:k")
+	waitForKey(t, s, "persist:gone")
+	assert.Equal(t, MaxSaveAge, s.TTL("persist:k"))
+
+	// Keys which are not ours are ignored
+	assert.NoError(t, s.Set("other", "not a gob"))
+
+	// A second replica loads what the first wrote
+	r := newTestRedis(t, s, Config{})
+	got := r.GetNewerThan("k", time.Time{})
+	if assert.NotNil(t, got) {
+		assert.True(t, created.Equal(got.Created))
+		assert.Equal(t, "success", got.CheckState)
+	}
+	assert.Nil(t, r.GetNewerThan("k", time.Now()))
+	assert.Nil(t, r.GetNewerThan("gone", time.Time{}))
+	assert.Len(t, r.List(time.Time{}), 2)
+
+	// Redis expires keys, rather than Cleanup
+	assert.NoError(t, r.Cleanup())
+	s.FastForward(MaxSaveAge + time.Second)
+	r = newTestRedis(t, s, Config{})
+	assert.Nil(t, r.GetNewerThan("k", time.Time{}))
+}
+
+func TestRedisNamespace(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	defer s.Close()
+
+	staging := newTestRedis(t, s, Config{Namespace: "staging*"})
+	prod := newTestRedis(t, s, Config{Namespace: "prod"})
+	assert.NoError(t, staging.Set("k", &provider.Thing{CheckState: "staging"}))
+	assert.NoError(t, prod.Set("k", &provider.Thing{CheckState: "prod"}))
+	waitForKey(t, s, "persist:staging*:k")
+	waitForKey(t, s, "persist:prod:k")
+
+	r := newTestRedis(t, s, Config{Namespace: "staging*"})
+	assert.Len(t, r.List(time.Time{}), 1)
+	if got := r.GetNewerThan("k", time.Time{}); assert.NotNil(t, got) {
+		assert.Equal(t, "staging", got.CheckState)
+	}
+}
+
+func TestRedisBatch(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	defer s.Close()
+
+	m := newTestRedis(t, s, Config{BatchSize: 10})
+	assert.NoError(t, m.Set("k", &provider.Thing{}))
+	assert.False(t, s.Exists("persist:k"))
+
+	assert.NoError(t, m.Cleanup())
+	assert.True(t, s.Exists("persist:k"))
+}
+
+func TestRedisMaxBlobSize(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	defer s.Close()
+
+	m := newTestRedis(t, s, Config{BatchSize: 10, MaxBlobSize: 1024, MaxConcurrentWrites: 2})
+	assert.Equal(t, 2, cap(m.slots))
+
+	th := representativeThing()
+	assert.NoError(t, m.Set("big", th))
+	assert.NoError(t, m.Cleanup())
+
+	// Sent in parts, but stored whole under one key, with its expiry
+	v, err := s.Get("persist:big")
+	assert.NoError(t, err)
+	assert.Greater(t, len(v), 1024)
+	assert.Equal(t, MaxSaveAge, s.TTL("persist:big"))
+
+	r := newTestRedis(t, s, Config{})
+	if got := r.GetNewerThan("big", time.Time{}); assert.NotNil(t, got) {
+		assert.Len(t, got.Timeline, len(th.Timeline))
+	}
+}
+
+func TestNewRedisErrors(t *testing.T) {
+	_, err := NewRedis(Config{Path: "mysql://localhost"})
+	assert.Error(t, err)
+
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	addr := s.Addr()
+	s.Close()
+
+	_, err = NewRedis(Config{Path: "redis://" + addr + "/0"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package persist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// newStandInDB returns an in-memory database holding an old row and a new row per namespace.
+// SQLite accepts the MySQL and Postgres DELETE syntax used by Cleanup.
+func newStandInDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	db, err := sqlx.Connect("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE persist (k TEXT, saved TIMESTAMP)`); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	old := time.Now().UTC().Add(-2 * MaxSaveAge)
+	for _, ns := range []string{"a", "b"} {
+		for k, saved := range map[string]time.Time{"old": old, "new": time.Now().UTC()} {
+			if _, err := db.Exec(`INSERT INTO persist (k, saved) VALUES (?, ?)`, namespaced(ns, k), saved); err != nil {
+				t.Fatalf("insert: %v", err)
+			}
+		}
+	}
+	return db
+}
+
+func storedKeys(t *testing.T, db *sqlx.DB) []string {
+	t.Helper()
+	ks := []string{}
+	if err := db.Select(&ks, `SELECT k FROM persist ORDER BY k`); err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	return ks
+}
+
+func TestMySQLCleanupNamespace(t *testing.T) {
+	db := newStandInDB(t)
+	defer db.Close()
+
+	m := &MySQL{db: db, ns: "a", batch: newBatch(1), writer: newSQLWriter(db, mysqlUpsert, Config{})}
+	assert.NoError(t, m.Cleanup())
+	assert.Equal(t, []string{"a:new", "b:new", "b:old"}, storedKeys(t, db))
+}
+
+func TestPostgresCleanupNamespace(t *testing.T) {
+	db := newStandInDB(t)
+	defer db.Close()
+
+	m := &Postgres{db: db, ns: "b", batch: newBatch(1), writer: newSQLWriter(db, pgUpsert, Config{})}
+	assert.NoError(t, m.Cleanup())
+	assert.Equal(t, []string{"a:new", "a:old", "b:new"}, storedKeys(t, db))
+}
@@ -0,0 +1,266 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package persist provides a persistence layer for the in-memory cache
+package persist
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/jmoiron/sqlx"
+	"k8s.io/klog/v2"
+
+	// register the sqlite3 driver
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var sqliteSchema = `
+CREATE TABLE IF NOT EXISTS persist (
+	k TEXT PRIMARY KEY,
+	created INTEGER NOT NULL,
+	v BLOB
+);
+CREATE INDEX IF NOT EXISTS created_idx ON persist (created);`
+
+// SQLite stores things in a local database file. Unlike other SQL backends, it is queried directly rather than
+// loaded into memory, as reads are local.
+type SQLite struct {
+	db   *sqlx.DB
+	path string
+
+	// prefixed to keys, so that multiple instances may share a database
+	ns string
+
+	// bounds each delete during cleanup
+	timeout time.Duration
+
+	// gzips each value
+	compress bool
+}
+
+// NewSQLite returns a new SQLite cache. The path is a file, which is created if it does not exist.
+func NewSQLite(cfg Config) (*SQLite, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("sqlite requires a path")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0700); err != nil {
+		return nil, err
+	}
+
+	// Writers wait for one another for up to the query timeout, rather than failing with "database is locked"
+	sep := "?"
+	if strings.Contains(cfg.Path, "?") {
+		sep = "&"
+	}
+	dsn := fmt.Sprintf("%s%s_busy_timeout=%d", cfg.Path, sep, cfg.queryTimeout().Milliseconds())
+
+	dbx, err := sqlx.Connect("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &SQLite{
+		db:       dbx,
+		path:     cfg.Path,
+		ns:       cfg.Namespace,
+		timeout:  cfg.queryTimeout(),
+		compress: cfg.Compress,
+	}
+
+	return m, nil
+}
+
+func (m *SQLite) String() string {
+	return fmt.Sprintf("sqlite://%s", m.path)
+}
+
+// Initialize enables write-ahead logging, so that reads do not block writes, and creates the schema
+func (m *SQLite) Initialize() error {
+	var mode string
+	if err := m.db.Get(&mode, `PRAGMA journal_mode=WAL`); err != nil {
+		return fmt.Errorf("journal mode: %w", err)
+	}
+	if mode != "wal" {
+		return fmt.Errorf("journal mode is %q, want wal", mode)
+	}
+
+	if _, err := m.db.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf("exec schema: %w", err)
+	}
+
+	return nil
+}
+
+// sqliteTime returns the value stored in the created column for a timestamp. Anything before the epoch, such as
+// the zero time, is stored as the epoch.
+func sqliteTime(t time.Time) int64 {
+	if t.Before(time.Unix(0, 0)) {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// decodeThing decodes a thing written by encodeItem
+func decodeThing(v []byte) (*provider.Thing, error) {
+	item, err := decodeItem(v)
+	if err != nil {
+		return nil, err
+	}
+
+	th, ok := item.Object.(*provider.Thing)
+	if !ok {
+		return nil, fmt.Errorf("%T is not of type Thing", item.Object)
+	}
+	return th, nil
+}
+
+// Set stores a thing
+func (m *SQLite) Set(key string, th *provider.Thing) error {
+	key = namespaced(m.ns, key)
+	if th.Created.IsZero() {
+		th.Created = time.Now()
+	}
+
+	v, err := encodeItem(th, m.compress)
+	if err != nil {
+		return err
+	}
+
+	klog.V(1).Infof("Storing %s within sqlite (created: %s)", key, th.Created)
+	_, err = m.db.Exec(`INSERT OR REPLACE INTO persist (k, created, v) VALUES (?, ?, ?)`, key, sqliteTime(th.Created), v)
+	if err != nil {
+		return fmt.Errorf("insert: %w", err)
+	}
+	return nil
+}
+
+// Tombstone marks a thing as absent until it is set again
+func (m *SQLite) Tombstone(key string) error {
+	return m.Set(key, tombstone())
+}
+
+// DeleteOlderThan deletes a thing older than a timestamp
+func (m *SQLite) DeleteOlderThan(key string, t time.Time) error {
+	_, err := m.db.Exec(`DELETE FROM persist WHERE k = ? AND created < ?`, namespaced(m.ns, key), sqliteTime(t))
+	if err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+	return nil
+}
+
+// GetNewerThan returns a Item older than a timestamp
+func (m *SQLite) GetNewerThan(key string, t time.Time) *provider.Thing {
+	key = namespaced(m.ns, key)
+
+	var v []byte
+	err := m.db.Get(&v, `SELECT v FROM persist WHERE k = ? AND created >= ?`, key, sqliteTime(t))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			klog.Errorf("get %s: %v", key, err)
+		}
+		return nil
+	}
+
+	th, err := decodeThing(v)
+	if err != nil {
+		klog.Errorf("%s (bytes: %d): %v", key, len(v), err)
+		return nil
+	}
+
+	if th.Tombstone {
+		klog.V(1).Infof("%s is tombstoned", key)
+		return nil
+	}
+	return th
+}
+
+// List returns all things created after a timestamp, keyed without the namespace
+func (m *SQLite) List(t time.Time) map[string]*provider.Thing {
+	prefix := namespaced(m.ns, "")
+	found := map[string]*provider.Thing{}
+
+	rows, err := m.db.Queryx(`SELECT k, v FROM persist WHERE created >= ?`, sqliteTime(t))
+	if err != nil {
+		klog.Errorf("list: %v", err)
+		return found
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var k string
+		var v []byte
+		if err := rows.Scan(&k, &v); err != nil {
+			klog.Errorf("scan: %v", err)
+			continue
+		}
+
+		// Filtered here, as LIKE is case-insensitive within SQLite
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+
+		th, err := decodeThing(v)
+		if err != nil {
+			klog.Errorf("%s (bytes: %d): %v", k, len(v), err)
+			continue
+		}
+		found[strings.TrimPrefix(k, prefix)] = th
+	}
+
+	if err := rows.Err(); err != nil {
+		klog.Errorf("list: %v", err)
+	}
+	return found
+}
+
+// Cleanup deletes older cache items
+func (m *SQLite) Cleanup() error {
+	maxAge := time.Now().Add(-1 * MaxSaveAge)
+
+	ctx, cancel := queryContext(m.timeout)
+	defer cancel()
+
+	query := `DELETE FROM persist WHERE created < ?`
+	args := []interface{}{sqliteTime(maxAge)}
+	// Leave the rows of other namespaces to their own instances. Compared by substr, as LIKE is case-insensitive.
+	if m.ns != "" {
+		prefix := namespaced(m.ns, "")
+		query += ` AND substr(k, 1, length(?)) = ?`
+		args = append(args, prefix, prefix)
+	}
+
+	res, err := m.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("delete exec: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+
+	if rows > 0 {
+		klog.Infof("Deleted %d rows of stale data", rows)
+	}
+
+	return nil
+}
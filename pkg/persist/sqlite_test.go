@@ -0,0 +1,139 @@
+package persist
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/provider"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSQLite(t *testing.T, path string, cfg Config) *SQLite {
+	t.Helper()
+	cfg.Type = "sqlite"
+	cfg.Path = path
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("new sqlite: %v", err)
+	}
+	if err := c.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	return c.(*SQLite)
+}
+
+func TestSQLite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqlite")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sub", "tp.db")
+
+	m := newTestSQLite(t, path, Config{})
+	assert.Equal(t, "sqlite://"+path, m.String())
+
+	created := time.Now().Add(-1 * time.Hour).Truncate(time.Second)
+	assert.NoError(t, m.Set("k", &provider.Thing{Created: created, CheckState: "success"}))
+	assert.NoError(t, m.Set("old", &provider.Thing{Created: time.Now().Add(-2 * MaxSaveAge)}))
+	assert.NoError(t, m.Tombstone("gone"))
+
+	// A second instance reads what the first wrote
+	r := newTestSQLite(t, path, Config{})
+	got := r.GetNewerThan("k", time.Time{})
+	if assert.NotNil(t, got) {
+		assert.True(t, created.Equal(got.Created))
+		assert.Equal(t, "success", got.CheckState)
+	}
+	assert.NotNil(t, r.GetNewerThan("k", created))
+	assert.Nil(t, r.GetNewerThan("k", created.Add(time.Nanosecond)))
+	assert.Nil(t, r.GetNewerThan("gone", time.Time{}))
+	assert.Nil(t, r.GetNewerThan("missing", time.Time{}))
+	assert.Len(t, r.List(time.Time{}), 3)
+	assert.Len(t, r.List(created), 2)
+
+	// Not deleted, as it is newer
+	assert.NoError(t, r.DeleteOlderThan("k", created.Add(-1*time.Second)))
+	assert.NotNil(t, r.GetNewerThan("k", time.Time{}))
+	assert.NoError(t, r.DeleteOlderThan("k", time.Now()))
+	assert.Nil(t, r.GetNewerThan("k", time.Time{}))
+
+	assert.NoError(t, r.Cleanup())
+	assert.Nil(t, r.GetNewerThan("old", time.Time{}))
+	assert.Len(t, r.List(time.Time{}), 1)
+}
+
+func TestSQLiteNamespace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqlite")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tp.db")
+
+	staging := newTestSQLite(t, path, Config{Namespace: "Prod%"})
+	prod := newTestSQLite(t, path, Config{Namespace: "prod"})
+	assert.NoError(t, staging.Set("k", &provider.Thing{CheckState: "staging"}))
+	assert.NoError(t, prod.Set("k", &provider.Thing{CheckState: "prod"}))
+
+	assert.Len(t, staging.List(time.Time{}), 1)
+	if got := staging.GetNewerThan("k", time.Time{}); assert.NotNil(t, got) {
+		assert.Equal(t, "staging", got.CheckState)
+	}
+	if got := prod.List(time.Time{})["k"]; assert.NotNil(t, got) {
+		assert.Equal(t, "prod", got.CheckState)
+	}
+
+	// Cleanup only removes stale rows within its own namespace
+	old := time.Now().Add(-2 * MaxSaveAge)
+	assert.NoError(t, staging.Set("old", &provider.Thing{Created: old}))
+	assert.NoError(t, prod.Set("old", &provider.Thing{Created: old}))
+	assert.NoError(t, staging.Cleanup())
+	assert.Nil(t, staging.GetNewerThan("old", time.Time{}))
+	assert.NotNil(t, prod.GetNewerThan("old", time.Time{}))
+	assert.NotNil(t, staging.GetNewerThan("k", time.Time{}))
+}
+
+func TestSQLiteConcurrentSet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqlite")
+	if err != nil {
+		t.Fatalf("tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tp.db")
+
+	// Two instances, so that writes contend for the file lock as well as connections
+	dbs := []*SQLite{newTestSQLite(t, path, Config{}), newTestSQLite(t, path, Config{})}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 200)
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m := dbs[i%len(dbs)]
+			if err := m.Set(fmt.Sprintf("k%d", i), &provider.Thing{}); err != nil {
+				errs <- err
+			}
+			m.GetNewerThan(fmt.Sprintf("k%d", i), time.Time{})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("set: %v", err)
+	}
+	assert.Len(t, dbs[0].List(time.Time{}), 200)
+}
+
+func TestNewSQLiteErrors(t *testing.T) {
+	_, err := NewSQLite(Config{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,23 @@
+package provider
+
+// Check states summarize the CI status of a commit
+const (
+	CheckSuccess = "success"
+	CheckPending = "pending"
+	CheckFailure = "failure"
+)
+
+var checkSeverity = map[string]int{
+	"":           0,
+	CheckSuccess: 1,
+	CheckPending: 2,
+	CheckFailure: 3,
+}
+
+// worstCheckState returns the more severe of two check states, where an empty state means that there were no checks
+func worstCheckState(a string, b string) string {
+	if checkSeverity[b] > checkSeverity[a] {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v31/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorstCheckState(t *testing.T) {
+	assert.Equal(t, "", worstCheckState("", ""))
+	assert.Equal(t, CheckSuccess, worstCheckState("", CheckSuccess))
+	assert.Equal(t, CheckPending, worstCheckState(CheckSuccess, CheckPending))
+	assert.Equal(t, CheckFailure, worstCheckState(CheckFailure, CheckPending))
+	assert.Equal(t, CheckFailure, worstCheckState(CheckSuccess, CheckFailure))
+}
+
+func TestGithubCheckRunState(t *testing.T) {
+	run := func(status, conclusion string) *github.CheckRun {
+		return &github.CheckRun{Status: &status, Conclusion: &conclusion}
+	}
+	assert.Equal(t, CheckPending, githubCheckRunState(run("in_progress", "")))
+	assert.Equal(t, CheckSuccess, githubCheckRunState(run("completed", "neutral")))
+	assert.Equal(t, CheckFailure, githubCheckRunState(run("completed", "timed_out")))
+	assert.Equal(t, CheckFailure, githubStatusState("error"))
+}
+
+func TestGithubChecksGetStatePaginates(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/project/commits/abc/status":
+			fmt.Fprint(w, `{"state": "pending", "total_count": 0}`)
+		case "/repos/org/project/commits/abc/check-runs":
+			// The failing run is only on the second page
+			if r.URL.Query().Get("page") == "2" {
+				fmt.Fprint(w, `{"total_count": 2, "check_runs": [{"status": "completed", "conclusion": "failure"}]}`)
+				return
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=2>; rel="next"`, srv.URL, r.URL.Path))
+			fmt.Fprint(w, `{"total_count": 2, "check_runs": [{"status": "completed", "conclusion": "success"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cl := github.NewClient(srv.Client())
+	u, err := url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	cl.BaseURL = u
+	p := &GithubProvider{client: cl}
+
+	state, _, err := p.ChecksGetState(context.Background(), SearchParams{Repo: Repo{Organization: "org", Project: "project"}, Ref: "abc"})
+	assert.NoError(t, err)
+	assert.Equal(t, CheckFailure, state)
+}
@@ -0,0 +1,24 @@
+package provider
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/go-github/v31/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+// IsNotFound returns whether an error is a provider reporting that something does not exist, or is not visible to
+// the token in use, as opposed to a failure to find out
+func IsNotFound(err error) bool {
+	var gh *github.ErrorResponse
+	if errors.As(err, &gh) {
+		return gh.Response != nil && gh.Response.StatusCode == http.StatusNotFound
+	}
+
+	var gl *gitlab.ErrorResponse
+	if errors.As(err, &gl) {
+		return gl.Response != nil && gl.Response.StatusCode == http.StatusNotFound
+	}
+	return false
+}
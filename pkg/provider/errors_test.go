@@ -0,0 +1,26 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v31/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestIsNotFound(t *testing.T) {
+	gh := func(code int) error { return &github.ErrorResponse{Response: &http.Response{StatusCode: code}} }
+	gl := func(code int) error { return &gitlab.ErrorResponse{Response: &http.Response{StatusCode: code}} }
+
+	assert.True(t, IsNotFound(gh(http.StatusNotFound)))
+	assert.True(t, IsNotFound(fmt.Errorf("get: %w", gh(http.StatusNotFound))))
+	assert.True(t, IsNotFound(gl(http.StatusNotFound)))
+	assert.False(t, IsNotFound(gh(http.StatusInternalServerError)))
+	assert.False(t, IsNotFound(gl(http.StatusForbidden)))
+	assert.False(t, IsNotFound(&github.ErrorResponse{}))
+	assert.False(t, IsNotFound(errors.New("connection reset")))
+	assert.False(t, IsNotFound(nil))
+}
@@ -28,19 +28,97 @@ type Filter struct {
 	milestoneRegex  *regexp.Regexp
 	milestoneNegate bool
 
+	RawAuthor    string `yaml:"author,omitempty"`
+	authorRegex  *regexp.Regexp
+	authorNegate bool
+
+	Team string `yaml:"team,omitempty"`
+
+	// AuthorAssociation is a comma-separated list of the author's association with the repository, such as "NONE"
+	AuthorAssociation string `yaml:"author-association,omitempty"`
+
+	RawIssueType    string `yaml:"issue-type,omitempty"`
+	issueTypeRegex  *regexp.Regexp
+	issueTypeNegate bool
+
+	// RawAttachment overrides how has-attachment detects attachments
+	RawAttachment   string `yaml:"attachment-regex,omitempty"`
+	attachmentRegex *regexp.Regexp
+
+	// RawLastComment is matched anywhere within the most recent human comment, such as a status note
+	RawLastComment    string `yaml:"last-comment,omitempty"`
+	lastCommentRegex  *regexp.Regexp
+	lastCommentNegate bool
+	// LastCommentByMember matches whether the most recent human comment was left by a project member
+	LastCommentByMember *bool `yaml:"last-comment-by-member,omitempty"`
+	// BotLast matches whether the most recent comment, including those by bots, was left by a bot
+	BotLast *bool `yaml:"bot-last,omitempty"`
+
 	Created            string `yaml:"created,omitempty"`
+	TitleLength        string `yaml:"title-length,omitempty"`
 	Updated            string `yaml:"updated,omitempty"`
+	UpdatedBusiness    string `yaml:"updated-business,omitempty"`
+	UpdatedOffHours    *bool  `yaml:"updated-off-hours,omitempty"`
 	Closed             string `yaml:"closed,omitempty"`
+	ClosedWithin       string `yaml:"closed-within,omitempty"`
+	StateAge           string `yaml:"state-age,omitempty"`
+	LabeledWithin      string `yaml:"labeled-within,omitempty"`
+	RenamedWithin      string `yaml:"renamed-within,omitempty"`
 	Prioritized        string `yaml:"prioritized,omitempty"`
+	TimeToFirstLabel   string `yaml:"time-to-first-label,omitempty"`
 	Responded          string `yaml:"responded,omitempty"`
+	Hold               string `yaml:"hold,omitempty"`
 	Reactions          string `yaml:"reactions,omitempty"`
 	ReactionsPerMonth  string `yaml:"reactions-per-month,omitempty"`
+	MemberReactions    string `yaml:"member-reactions,omitempty"`
+	TopCommentReacts   string `yaml:"top-comment-reactions,omitempty"`
 	Comments           string `yaml:"comments,omitempty"`
+	AssigneeCount      string `yaml:"assignee-count,omitempty"`
 	Commenters         string `yaml:"commenters,omitempty"`
 	CommentersPerMonth string `yaml:"commenters-per-month,omitempty"`
+	MemberCommentRatio string `yaml:"member-comment-ratio,omitempty"`
+	TaskCompletion     string `yaml:"task-completion,omitempty"`
 	ClosedComments     string `yaml:"comments-while-closed,omitempty"`
 	ClosedCommenters   string `yaml:"commenters-while-closed,omitempty"`
+	Bounty             string `yaml:"bounty,omitempty"`
+	LastTouchedBy      string `yaml:"last-touched-by,omitempty"`
+	ReactedBy          string `yaml:"reacted-by,omitempty"`
+	ForcePushes        string `yaml:"force-pushes,omitempty"`
+	ReopenCount        string `yaml:"reopen-count,omitempty"`
 	State              string `yaml:"state,omitempty"`
+	LockReason         string `yaml:"lock-reason,omitempty"`
+	MergeableState     string `yaml:"mergeable-state,omitempty"`
+	BallIn             string `yaml:"ball-in,omitempty"`
+
+	OutstandingChanges *bool `yaml:"outstanding-changes,omitempty"`
+	HasMilestone       *bool `yaml:"has-milestone,omitempty"`
+	MilestoneOverdue   *bool `yaml:"milestone-overdue,omitempty"`
+	HasLinkedIssue     *bool `yaml:"has-linked-issue,omitempty"`
+	SelfInflicted      *bool `yaml:"self-inflicted,omitempty"`
+	AuthorListedMember *bool `yaml:"author-listed-member,omitempty"`
+	HasAttachment      *bool `yaml:"has-attachment,omitempty"`
+	ConflictingLabels  *bool `yaml:"conflicting-labels,omitempty"`
+	Unpicked           *bool `yaml:"unpicked,omitempty"`
+
+	// UnlabeledInfinite treats items which were never labeled as having an infinite time-to-first-label
+	UnlabeledInfinite bool `yaml:"unlabeled-infinite,omitempty"`
+
+	// Open items whose assignees have all been unassigned
+	AbandonedAssignment *bool `yaml:"abandoned-assignment,omitempty"`
+
+	// Items which no member other than the author has commented on, been assigned to, acted on, or reacted to
+	UntouchedByMembers *bool `yaml:"untouched-by-members,omitempty"`
+
+	// Linked PR filters within the same filter entry must be satisfied by the same PR
+	LinkedPRState    string `yaml:"linked-pr-state,omitempty"`
+	LinkedPRByMember *bool  `yaml:"linked-pr-by-member,omitempty"`
+	LinkedPRChecks   string `yaml:"linked-pr-checks,omitempty"`
+
+	// Project matches the status of items on a configured project board, such as "Roadmap=In Progress"
+	Project string `yaml:"project,omitempty"`
+
+	// DeadLinks matches the number of external links in the description which no longer exist, such as ">0"
+	DeadLinks string `yaml:"dead-links,omitempty"`
 }
 
 // LoadLabelRegex loads a new label reegx
@@ -131,6 +209,102 @@ func (f *Filter) MilestoneNegate() bool {
 	return f.milestoneNegate
 }
 
+// LoadIssueTypeRegex loads a new issue type regex
+func (f *Filter) LoadIssueTypeRegex() error {
+	r, negate := negativeMatch(f.RawIssueType)
+
+	re, err := regex(r)
+	if err != nil {
+		return err
+	}
+
+	f.issueTypeRegex = re
+	f.issueTypeNegate = negate
+	return nil
+}
+
+func (f *Filter) IssueTypeRegex() *regexp.Regexp {
+	return f.issueTypeRegex
+}
+
+func (f *Filter) IssueTypeNegate() bool {
+	return f.issueTypeNegate
+}
+
+// LoadAuthorRegex loads a new author regex, accepting a comma-separated list of logins
+func (f *Filter) LoadAuthorRegex() error {
+	r, negateState := negativeMatch(f.RawAuthor)
+
+	if strings.Contains(r, ",") {
+		logins := []string{}
+		for _, l := range strings.Split(r, ",") {
+			logins = append(logins, regexp.QuoteMeta(strings.TrimSpace(l)))
+		}
+		r = fmt.Sprintf("^(%s)$", strings.Join(logins, "|"))
+	}
+
+	re, err := regex(r)
+	if err != nil {
+		return err
+	}
+
+	// GitHub logins are case-insensitive
+	re, err = regexp.Compile("(?i)" + re.String())
+	if err != nil {
+		return err
+	}
+
+	f.authorRegex = re
+	f.authorNegate = negateState
+	return nil
+}
+
+func (f *Filter) AuthorRegex() *regexp.Regexp {
+	return f.authorRegex
+}
+
+func (f *Filter) AuthorNegate() bool {
+	return f.authorNegate
+}
+
+// LoadAttachmentRegex loads a new attachment regex. Unlike other regexes, it is matched anywhere within the body.
+func (f *Filter) LoadAttachmentRegex() error {
+	re, err := regexp.Compile(f.RawAttachment)
+	if err != nil {
+		return err
+	}
+
+	f.attachmentRegex = re
+	return nil
+}
+
+func (f *Filter) AttachmentRegex() *regexp.Regexp {
+	return f.attachmentRegex
+}
+
+// LoadLastCommentRegex loads a new last comment regex. Like the attachment regex, it is matched anywhere within the
+// comment.
+func (f *Filter) LoadLastCommentRegex() error {
+	r, negate := negativeMatch(f.RawLastComment)
+
+	re, err := regexp.Compile(r)
+	if err != nil {
+		return err
+	}
+
+	f.lastCommentRegex = re
+	f.lastCommentNegate = negate
+	return nil
+}
+
+func (f *Filter) LastCommentRegex() *regexp.Regexp {
+	return f.lastCommentRegex
+}
+
+func (f *Filter) LastCommentNegate() bool {
+	return f.lastCommentNegate
+}
+
 // negativeMatch parses a match string and returns the underlying string and negation bool
 func negativeMatch(s string) (string, bool) {
 	if strings.HasPrefix(s, "!") {
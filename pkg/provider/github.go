@@ -9,7 +9,11 @@ import (
 	"golang.org/x/oauth2"
 	"k8s.io/klog/v2"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type GithubProvider struct {
@@ -23,23 +27,6 @@ func (p *GithubProvider) getListOptions(m ListOptions) github.ListOptions {
 	}
 }
 
-func (p *GithubProvider) getIssues(i []*github.Issue) []*Issue {
-	r := make([]*Issue, len(i))
-	for k, v := range i {
-		m := Issue{}
-		b, err := json.Marshal(v)
-		if err != nil {
-			fmt.Println(err)
-		}
-		err = json.Unmarshal(b, &m)
-		if err != nil {
-			fmt.Println(err)
-		}
-		r[k] = &m
-	}
-	return r
-}
-
 func (p *GithubProvider) getRate(i *github.Rate) Rate {
 	r := Rate{}
 	b, err := json.Marshal(i)
@@ -65,18 +52,69 @@ func (p *GithubProvider) getResponse(i *github.Response) *Response {
 	return &r
 }
 
-func (p *GithubProvider) getIssueListByRepoOptions(sp SearchParams) *github.IssueListByRepoOptions {
-	return &github.IssueListByRepoOptions{
-		ListOptions: p.getListOptions(sp.IssueListByRepoOptions.ListOptions),
-		State:       sp.IssueListByRepoOptions.State,
-		Since:       sp.IssueListByRepoOptions.Since,
+func (p *GithubProvider) getIssueListByRepoQuery(sp SearchParams) url.Values {
+	opt := sp.IssueListByRepoOptions
+	q := url.Values{}
+	if opt.State != "" {
+		q.Set("state", opt.State)
+	}
+	if !opt.Since.IsZero() {
+		q.Set("since", opt.Since.Format(time.RFC3339))
 	}
+	if opt.Sort != "" {
+		q.Set("sort", opt.Sort)
+	}
+	if opt.Direction != "" {
+		q.Set("direction", opt.Direction)
+	}
+	if opt.ListOptions.Page != 0 {
+		q.Set("page", strconv.Itoa(opt.ListOptions.Page))
+	}
+	if opt.ListOptions.PerPage != 0 {
+		q.Set("per_page", strconv.Itoa(opt.ListOptions.PerPage))
+	}
+	return q
 }
 
+// IssuesListByRepo decodes issues directly, as go-github does not yet know about fields such as the issue type
 func (p *GithubProvider) IssuesListByRepo(ctx context.Context, sp SearchParams) (i []*Issue, r *Response, err error) {
-	opt := p.getIssueListByRepoOptions(sp)
-	gi, gr, err := p.client.Issues.ListByRepo(ctx, sp.Repo.Organization, sp.Repo.Project, opt)
-	i = p.getIssues(gi)
+	u := fmt.Sprintf("repos/%s/%s/issues?%s", sp.Repo.Organization, sp.Repo.Project, p.getIssueListByRepoQuery(sp).Encode())
+	req, err := p.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gr, err := p.client.Do(ctx, req, &i)
+	r = p.getResponse(gr)
+	return
+}
+
+// IssuesSearch returns issues and PR's within a repository matching sp.Query, using the GitHub search API
+func (p *GithubProvider) IssuesSearch(ctx context.Context, sp SearchParams) (i []*Issue, r *Response, err error) {
+	q := url.Values{}
+	q.Set("q", fmt.Sprintf("repo:%s/%s %s", sp.Repo.Organization, sp.Repo.Project, sp.Query))
+	// Results are ordered by relevance by default, but a capped search should return the most recently active
+	if sp.MaxPages > 0 {
+		q.Set("sort", constants.UpdatedSortOption)
+		q.Set("order", constants.DescDirectionOption)
+	}
+	if sp.ListOptions.Page != 0 {
+		q.Set("page", strconv.Itoa(sp.ListOptions.Page))
+	}
+	if sp.ListOptions.PerPage != 0 {
+		q.Set("per_page", strconv.Itoa(sp.ListOptions.PerPage))
+	}
+
+	req, err := p.client.NewRequest("GET", "search/issues?"+q.Encode(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := struct {
+		Items []*Issue `json:"items"`
+	}{}
+	gr, err := p.client.Do(ctx, req, &result)
+	i = result.Items
 	r = p.getResponse(gr)
 	return
 }
@@ -112,34 +150,27 @@ func (p *GithubProvider) IssuesListComments(ctx context.Context, sp SearchParams
 	return
 }
 
-func (p *GithubProvider) getIssuesListIssueTimelineOptions(sp SearchParams) *github.ListOptions {
-	return &github.ListOptions{
-		PerPage: sp.ListOptions.PerPage,
+// IssuesListIssueTimeline decodes events directly, as go-github does not yet know about the author and committer of
+// committed events, which carry their only timestamps
+func (p *GithubProvider) IssuesListIssueTimeline(ctx context.Context, sp SearchParams) (i []*Timeline, r *Response, err error) {
+	q := url.Values{}
+	if sp.ListOptions.Page != 0 {
+		q.Set("page", strconv.Itoa(sp.ListOptions.Page))
+	}
+	if sp.ListOptions.PerPage != 0 {
+		q.Set("per_page", strconv.Itoa(sp.ListOptions.PerPage))
 	}
-}
 
-func (p *GithubProvider) getIssueTimeline(i []*github.Timeline) []*Timeline {
-	r := make([]*Timeline, len(i))
-	for k, v := range i {
-		m := Timeline{}
-		b, err := json.Marshal(v)
-		if err != nil {
-			fmt.Println(err)
-		}
-		err = json.Unmarshal(b, &m)
-		if err != nil {
-			fmt.Println(err)
-		}
-		r[k] = &m
+	u := fmt.Sprintf("repos/%s/%s/issues/%d/timeline?%s", sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber, q.Encode())
+	req, err := p.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
 	}
-	return r
-}
+	// The timeline and the project card details within it are previews
+	req.Header.Set("Accept", "application/vnd.github.mockingbird-preview+json, application/vnd.github.starfox-preview+json")
 
-func (p *GithubProvider) IssuesListIssueTimeline(ctx context.Context, sp SearchParams) (i []*Timeline, r *Response, err error) {
-	opt := p.getIssuesListIssueTimelineOptions(sp)
-	it, ir, err := p.client.Issues.ListIssueTimeline(ctx, sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber, opt)
-	i = p.getIssueTimeline(it)
-	r = p.getResponse(ir)
+	gr, err := p.client.Do(ctx, req, &i)
+	r = p.getResponse(gr)
 	return
 }
 
@@ -253,6 +284,36 @@ func (p *GithubProvider) PullRequestsListReviews(ctx context.Context, sp SearchP
 	return
 }
 
+func (p *GithubProvider) getReactions(i []*github.Reaction) []*Reaction {
+	r := make([]*Reaction, len(i))
+	for k, v := range i {
+		m := Reaction{}
+		b, err := json.Marshal(v)
+		if err != nil {
+			fmt.Println(err)
+		}
+		err = json.Unmarshal(b, &m)
+		if err != nil {
+			fmt.Println(err)
+		}
+		r[k] = &m
+	}
+	return r
+}
+
+func (p *GithubProvider) IssuesListReactions(ctx context.Context, sp SearchParams) (i []*Reaction, r *Response, err error) {
+	opt := p.getListOptions(sp.ListOptions)
+	gr, gresp, err := p.client.Reactions.ListIssueReactions(ctx, sp.Repo.Organization, sp.Repo.Project, sp.IssueNumber, &opt)
+	i = p.getReactions(gr)
+	r = p.getResponse(gresp)
+	return
+}
+
+// PullRequestsListReactions lists reactions to a PR, which GitHub treats as an issue
+func (p *GithubProvider) PullRequestsListReactions(ctx context.Context, sp SearchParams) (i []*Reaction, r *Response, err error) {
+	return p.IssuesListReactions(ctx, sp)
+}
+
 func MustCreateGithubClient(githubAPIRawURL string, httpClient *http.Client) *github.Client {
 	if githubAPIRawURL != "" {
 		client, err := github.NewEnterpriseClient(githubAPIRawURL, githubAPIRawURL, httpClient)
@@ -277,3 +338,169 @@ func initGithub(ctx context.Context, c Config) {
 		client: cl,
 	}
 }
+
+// ChecksGetState summarizes the commit statuses and check runs for sp.Ref as a single check state
+func (p *GithubProvider) ChecksGetState(ctx context.Context, sp SearchParams) (state string, r *Response, err error) {
+	opt := &github.ListOptions{PerPage: 100}
+	cs, gresp, err := p.client.Repositories.GetCombinedStatus(ctx, sp.Repo.Organization, sp.Repo.Project, sp.Ref, opt)
+	if err != nil {
+		return "", nil, err
+	}
+	// The combined state is "pending" if there are no statuses at all
+	if cs.GetTotalCount() > 0 {
+		state = githubStatusState(cs.GetState())
+	}
+
+	copt := &github.ListCheckRunsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		runs, cresp, err := p.client.Checks.ListCheckRunsForRef(ctx, sp.Repo.Organization, sp.Repo.Project, sp.Ref, copt)
+		if err != nil {
+			return "", nil, err
+		}
+		for _, run := range runs.CheckRuns {
+			state = worstCheckState(state, githubCheckRunState(run))
+		}
+
+		gresp = cresp
+		if cresp.NextPage == 0 {
+			break
+		}
+		copt.Page = cresp.NextPage
+	}
+
+	r = p.getResponse(gresp)
+	return
+}
+
+// githubStatusState converts a combined commit status state to a check state
+func githubStatusState(s string) string {
+	switch s {
+	case "success":
+		return CheckSuccess
+	case "failure", "error":
+		return CheckFailure
+	default:
+		return CheckPending
+	}
+}
+
+// githubCheckRunState converts the status and conclusion of a check run to a check state
+func githubCheckRunState(run *github.CheckRun) string {
+	if run.GetStatus() != "completed" {
+		return CheckPending
+	}
+	switch run.GetConclusion() {
+	case "success", "neutral", "skipped":
+		return CheckSuccess
+	default:
+		return CheckFailure
+	}
+}
+
+// githubProjectItemsQuery lists the items on a project board, with the value of its status field
+const githubProjectItemsQuery = `query($owner: String!, $number: Int!, $field: String!, $cursor: String) {
+  repositoryOwner(login: $owner) {
+    ... on ProjectV2Owner {
+      projectV2(number: $number) {
+        items(first: 100, after: $cursor) {
+          pageInfo { hasNextPage endCursor }
+          nodes {
+            content {
+              ... on Issue { url }
+              ... on PullRequest { url }
+            }
+            fieldValueByName(name: $field) {
+              ... on ProjectV2ItemFieldSingleSelectValue { name }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+type githubProjectItemsResponse struct {
+	Data struct {
+		RepositoryOwner *struct {
+			ProjectV2 *struct {
+				Items struct {
+					PageInfo struct {
+						HasNextPage bool
+						EndCursor   string
+					}
+					Nodes []struct {
+						Content *struct {
+							URL string
+						}
+						FieldValueByName *struct {
+							Name string
+						}
+					}
+				}
+			}
+		}
+	}
+	Errors []struct {
+		Message string
+	}
+}
+
+// graphqlPath returns the path of the GraphQL endpoint, relative to the REST API
+func (p *GithubProvider) graphqlPath() string {
+	// GitHub Enterprise serves REST from /api/v3/, and GraphQL from /api/graphql
+	if strings.HasSuffix(p.client.BaseURL.Path, "/v3/") {
+		return "../graphql"
+	}
+	return "graphql"
+}
+
+// ProjectItemsList returns the issues and PR's on the project board sp.Board, with their status. Draft items, and
+// items the token can not see, are skipped.
+func (p *GithubProvider) ProjectItemsList(ctx context.Context, sp SearchParams) (items []*ProjectItem, r *Response, err error) {
+	vars := map[string]interface{}{
+		"owner":  sp.Board.Owner,
+		"number": sp.Board.Number,
+		"field":  sp.Board.StatusField(),
+	}
+
+	for {
+		req, err := p.client.NewRequest("POST", p.graphqlPath(), map[string]interface{}{
+			"query":     githubProjectItemsQuery,
+			"variables": vars,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var out githubProjectItemsResponse
+		gresp, err := p.client.Do(ctx, req, &out)
+		if err != nil {
+			return nil, nil, err
+		}
+		r = p.getResponse(gresp)
+
+		if len(out.Errors) > 0 {
+			return nil, r, fmt.Errorf("project %s/%d: %s", sp.Board.Owner, sp.Board.Number, out.Errors[0].Message)
+		}
+		if out.Data.RepositoryOwner == nil || out.Data.RepositoryOwner.ProjectV2 == nil {
+			return nil, r, fmt.Errorf("project %s/%d: not found", sp.Board.Owner, sp.Board.Number)
+		}
+
+		page := out.Data.RepositoryOwner.ProjectV2.Items
+		for _, n := range page.Nodes {
+			if n.Content == nil || n.Content.URL == "" {
+				continue
+			}
+			item := &ProjectItem{URL: n.Content.URL}
+			if n.FieldValueByName != nil {
+				item.Status = n.FieldValueByName.Name
+			}
+			items = append(items, item)
+		}
+
+		if !page.PageInfo.HasNextPage {
+			return items, r, nil
+		}
+		vars["cursor"] = page.PageInfo.EndCursor
+	}
+}
@@ -36,11 +36,17 @@ func (p *GitlabProvider) getListProjectIssuesOptions(sp SearchParams) *gitlab.Li
 		s := constants.OpenedState
 		state = &s
 	}
-	return &gitlab.ListProjectIssuesOptions{
+	opt := &gitlab.ListProjectIssuesOptions{
 		ListOptions:  p.getListOptions(sp.IssueListByRepoOptions.ListOptions),
 		State:        state,
 		CreatedAfter: &sp.IssueListByRepoOptions.Since,
 	}
+	if sp.IssueListByRepoOptions.Sort == constants.UpdatedSortOption {
+		orderBy := constants.UpdatedAtSortOption
+		opt.OrderBy = &orderBy
+		opt.Sort = &sp.IssueListByRepoOptions.Direction
+	}
+	return opt
 }
 
 func (p *GitlabProvider) getListOptions(m ListOptions) gitlab.ListOptions {
@@ -79,8 +85,16 @@ func (p *GitlabProvider) getIssues(i []*gitlab.Issue) []*Issue {
 	r := make([]*Issue, len(i))
 	for k, v := range i {
 		id := int64(v.ID)
+		assignees := []*User{}
+		for _, a := range v.Assignees {
+			if a != nil {
+				assignees = append(assignees, p.getUserFromIssueAssignee(a))
+			}
+		}
 		m := Issue{
 			Assignee:  p.getUserFromIssueAssignee(v.Assignee),
+			Assignees: assignees,
+			Locked:    &v.DiscussionLocked,
 			HTMLURL:   &v.WebURL,
 			Title:     &v.Title,
 			URL:       &v.WebURL,
@@ -168,6 +182,10 @@ func (p *GitlabProvider) getIssueComments(i []*gitlab.Note) []*IssueComment {
 	return r
 }
 
+func (p *GitlabProvider) IssuesSearch(ctx context.Context, sp SearchParams) (i []*Issue, r *Response, err error) {
+	return nil, nil, fmt.Errorf("search queries are not supported for GitLab")
+}
+
 // https://docs.gitlab.com/ce/api/notes.html#list-project-issue-notes
 func (p *GitlabProvider) IssuesListComments(ctx context.Context, sp SearchParams) (i []*IssueComment, r *Response, err error) {
 	opt := p.getListIssueNotesOptions(sp)
@@ -252,8 +270,16 @@ func (p *GitlabProvider) getMilestone(i *gitlab.Milestone) *Milestone {
 
 func (p *GitlabProvider) getPullRequest(v *gitlab.MergeRequest) *PullRequest {
 	id := int64(v.ID)
+	assignees := []*User{}
+	for _, a := range v.Assignees {
+		if a != nil {
+			assignees = append(assignees, p.getUserFromBasicUser(a, false))
+		}
+	}
 	m := &PullRequest{
 		Assignee:  p.getUserFromBasicUser(v.Assignee, true),
+		Assignees: assignees,
+		Locked:    &v.DiscussionLocked,
 		User:      p.getUserFromBasicUser(v.Author, false),
 		Body:      &v.Description,
 		CreatedAt: v.CreatedAt,
@@ -266,6 +292,7 @@ func (p *GitlabProvider) getPullRequest(v *gitlab.MergeRequest) *PullRequest {
 		Number:    &v.IID,
 		Milestone: p.getMilestone(v.Milestone),
 		HTMLURL:   &v.WebURL,
+		Head:      &PullRequestBranch{Ref: &v.SourceBranch, SHA: &v.SHA},
 	}
 	return m
 }
@@ -352,3 +379,75 @@ func (p *GitlabProvider) getProjectId(repo Repo) string {
 	}
 	return u
 }
+
+func (p *GitlabProvider) getReactions(i []*gitlab.AwardEmoji) []*Reaction {
+	r := make([]*Reaction, len(i))
+	for k, v := range i {
+		id := int64(v.ID)
+		uid := int64(v.User.ID)
+		r[k] = &Reaction{
+			ID: &id,
+			User: &User{
+				ID:        &uid,
+				Name:      &v.User.Name,
+				Login:     &v.User.Username,
+				AvatarURL: &v.User.AvatarURL,
+				HTMLURL:   &v.User.WebURL,
+			},
+			Content: &v.Name,
+		}
+	}
+	return r
+}
+
+// https://docs.gitlab.com/ce/api/award_emoji.html#list-an-awardables-award-emoji
+func (p *GitlabProvider) IssuesListReactions(ctx context.Context, sp SearchParams) (i []*Reaction, r *Response, err error) {
+	opt := gitlab.ListAwardEmojiOptions(p.getListOptions(sp.ListOptions))
+	in, gr, err := p.client.AwardEmoji.ListIssueAwardEmoji(p.getProjectId(sp.Repo), sp.IssueNumber, &opt)
+	i = p.getReactions(in)
+	r = p.getResponse(gr)
+	return
+}
+
+func (p *GitlabProvider) PullRequestsListReactions(ctx context.Context, sp SearchParams) (i []*Reaction, r *Response, err error) {
+	opt := gitlab.ListAwardEmojiOptions(p.getListOptions(sp.ListOptions))
+	in, gr, err := p.client.AwardEmoji.ListMergeRequestAwardEmoji(p.getProjectId(sp.Repo), sp.IssueNumber, &opt)
+	i = p.getReactions(in)
+	r = p.getResponse(gr)
+	return
+}
+
+// ChecksGetState returns the check state of the most recent pipeline for sp.Ref
+// https://docs.gitlab.com/ee/api/pipelines.html#list-project-pipelines
+func (p *GitlabProvider) ChecksGetState(ctx context.Context, sp SearchParams) (state string, r *Response, err error) {
+	opt := &gitlab.ListProjectPipelinesOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 1},
+		SHA:         &sp.Ref,
+	}
+	ps, gr, err := p.client.Pipelines.ListProjectPipelines(p.getProjectId(sp.Repo), opt)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(ps) > 0 {
+		state = gitlabPipelineState(ps[0].Status)
+	}
+	r = p.getResponse(gr)
+	return
+}
+
+// gitlabPipelineState converts a pipeline status to a check state
+func gitlabPipelineState(s string) string {
+	switch s {
+	case "success", "skipped":
+		return CheckSuccess
+	case "failed", "canceled":
+		return CheckFailure
+	default:
+		return CheckPending
+	}
+}
+
+// ProjectItemsList is unsupported: project boards are a GitHub feature
+func (p *GitlabProvider) ProjectItemsList(ctx context.Context, sp SearchParams) ([]*ProjectItem, *Response, error) {
+	return nil, nil, fmt.Errorf("project boards are not supported on GitLab")
+}
@@ -27,6 +27,7 @@ type Issue struct {
 	LabelsURL         *string           `json:"labels_url,omitempty"`
 	RepositoryURL     *string           `json:"repository_url,omitempty"`
 	Milestone         *Milestone        `json:"milestone,omitempty"`
+	Type              *IssueType        `json:"type,omitempty"`
 	PullRequestLinks  *PullRequestLinks `json:"pull_request,omitempty"`
 	Repository        *Repository       `json:"repository,omitempty"`
 	Reactions         *Reactions        `json:"reactions,omitempty"`
@@ -46,6 +47,22 @@ func (i *Issue) GetAssignee() *User {
 	return i.Assignee
 }
 
+// GetAssignees returns the Assignees field.
+func (i *Issue) GetAssignees() []*User {
+	if i == nil {
+		return nil
+	}
+	return i.Assignees
+}
+
+// GetActiveLockReason returns the ActiveLockReason field if it's non-nil, zero value otherwise.
+func (i *Issue) GetActiveLockReason() string {
+	if i == nil || i.ActiveLockReason == nil {
+		return ""
+	}
+	return *i.ActiveLockReason
+}
+
 // GetAuthorAssociation returns the AuthorAssociation field if it's non-nil, zero value otherwise.
 func (i *Issue) GetAuthorAssociation() string {
 	if i == nil || i.AuthorAssociation == nil {
@@ -110,6 +127,14 @@ func (i *Issue) GetID() int64 {
 	return *i.ID
 }
 
+// GetLocked returns the Locked field if it's non-nil, zero value otherwise.
+func (i *Issue) GetLocked() bool {
+	if i == nil || i.Locked == nil {
+		return false
+	}
+	return *i.Locked
+}
+
 // GetMilestone returns the Milestone field.
 func (i *Issue) GetMilestone() *Milestone {
 	if i == nil {
@@ -118,6 +143,19 @@ func (i *Issue) GetMilestone() *Milestone {
 	return i.Milestone
 }
 
+// GetType returns the Type field.
+func (i *Issue) GetType() *IssueType {
+	if i == nil {
+		return nil
+	}
+	return i.Type
+}
+
+// GetTypeName returns the name of the issue type, or an empty string if none is set.
+func (i *Issue) GetTypeName() string {
+	return i.GetType().GetName()
+}
+
 // GetNumber returns the Number field if it's non-nil, zero value otherwise.
 func (i *Issue) GetNumber() int {
 	if i == nil || i.Number == nil {
@@ -189,3 +227,29 @@ func (i Issue) String() string {
 func (i Issue) IsPullRequest() bool {
 	return i.PullRequestLinks != nil
 }
+
+// IssuesOnly returns the issues which are not pull requests. The GitHub issues API returns PR's as issues.
+func IssuesOnly(is []*Issue) []*Issue {
+	filtered := []*Issue{}
+	for _, i := range is {
+		if !i.IsPullRequest() {
+			filtered = append(filtered, i)
+		}
+	}
+	return filtered
+}
+
+// IssueType represents a native GitHub issue type, such as Bug or Feature
+type IssueType struct {
+	ID          *int64  `json:"id,omitempty"`
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// GetName returns the Name field if it's non-nil, zero value otherwise.
+func (t *IssueType) GetName() string {
+	if t == nil || t.Name == nil {
+		return ""
+	}
+	return *t.Name
+}
@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssuesOnly(t *testing.T) {
+	// As returned by the GitHub issues API for a repository with one open PR
+	listing := `[
+		{"number": 3, "title": "an issue", "html_url": "https://github.com/org/project/issues/3"},
+		{"number": 2, "title": "a PR", "html_url": "https://github.com/org/project/pull/2",
+		 "pull_request": {"url": "https://api.github.com/repos/org/project/pulls/2", "html_url": "https://github.com/org/project/pull/2"}},
+		{"number": 1, "title": "another issue", "html_url": "https://github.com/org/project/issues/1"}
+	]`
+
+	var is []*Issue
+	if err := json.Unmarshal([]byte(listing), &is); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	got := []int{}
+	for _, i := range IssuesOnly(is) {
+		got = append(got, i.GetNumber())
+	}
+	assert.Equal(t, []int{3, 1}, got)
+	assert.Empty(t, IssuesOnly([]*Issue{is[1]}))
+	assert.Empty(t, IssuesOnly(nil))
+}
@@ -4,18 +4,22 @@ import "time"
 
 // Item is an interface that matches both Issues and PullRequests
 type IItem interface {
+	GetActiveLockReason() string
 	GetAssignee() *User
+	GetAssignees() []*User
 	GetAuthorAssociation() string
 	GetBody() string
 	GetComments() int
 	GetHTMLURL() string
 	GetCreatedAt() time.Time
 	GetID() int64
+	GetLocked() bool
 	GetMilestone() *Milestone
 	GetNumber() int
 	GetClosedAt() time.Time
 	GetState() string
 	GetTitle() string
+	GetTypeName() string
 	GetURL() string
 	GetUpdatedAt() time.Time
 	GetUser() *User
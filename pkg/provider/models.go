@@ -56,6 +56,33 @@ type SearchParams struct {
 	IssueNumber int
 	Fetch       bool
 
+	// Query is a raw search query used to select candidate items, such as "label:bug comments:>10"
+	Query string
+
+	// Tags are the tag ID's a collection uses, allowing unneeded enrichment to be skipped (nil for all)
+	Tags []string
+
+	// DefaultState is the state to filter by if no filter sets one (default is open)
+	DefaultState string
+
+	// TimelineLimit is the maximum number of recent timeline events to fetch (0 for all)
+	TimelineLimit int
+
+	// CommentLimit is the maximum number of recent issue comments to fetch (0 for all)
+	CommentLimit int
+
+	// PerPage is how many items to request per page when listing or searching items (0 for the maximum of 100)
+	PerPage int
+
+	// MaxPages is the most pages of items to list or search, most recently updated first (0 for all)
+	MaxPages int
+
+	// Ref is the commit SHA to look up check state for
+	Ref string
+
+	// Board is the project board to list the items of
+	Board Board
+
 	IssueListByRepoOptions   IssueListByRepoOptions
 	IssueListCommentsOptions IssueListCommentsOptions
 	ListOptions              ListOptions
@@ -0,0 +1,45 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+// DefaultProjectField is the single-select field that holds an item's status on a project board
+const DefaultProjectField = "Status"
+
+// Board is a GitHub project (Projects v2) to look up the status of items on
+type Board struct {
+	// Name is how filters refer to the board, such as "Roadmap"
+	Name string
+	// Owner is the organization or user which owns the board
+	Owner string
+	// Number is the board number, as seen in its URL
+	Number int
+	// Field is the single-select field holding each item's status (DefaultProjectField if empty)
+	Field string
+}
+
+// StatusField returns the field holding each item's status
+func (b Board) StatusField() string {
+	if b.Field != "" {
+		return b.Field
+	}
+	return DefaultProjectField
+}
+
+// ProjectItem is an issue or PR on a project board
+type ProjectItem struct {
+	URL string
+	// Status is the value of the board's status field, or empty if it is unset
+	Status string
+}
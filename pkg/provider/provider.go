@@ -11,12 +11,17 @@ import (
 
 type Provider interface {
 	IssuesListByRepo(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error)
+	IssuesSearch(ctx context.Context, sp SearchParams) ([]*Issue, *Response, error)
 	IssuesListComments(ctx context.Context, sp SearchParams) ([]*IssueComment, *Response, error)
 	IssuesListIssueTimeline(ctx context.Context, sp SearchParams) ([]*Timeline, *Response, error)
 	PullRequestsList(ctx context.Context, sp SearchParams) ([]*PullRequest, *Response, error)
 	PullRequestsGet(ctx context.Context, sp SearchParams) (*PullRequest, *Response, error)
 	PullRequestsListComments(ctx context.Context, sp SearchParams) ([]*PullRequestComment, *Response, error)
 	PullRequestsListReviews(ctx context.Context, sp SearchParams) ([]*PullRequestReview, *Response, error)
+	IssuesListReactions(ctx context.Context, sp SearchParams) ([]*Reaction, *Response, error)
+	PullRequestsListReactions(ctx context.Context, sp SearchParams) ([]*Reaction, *Response, error)
+	ChecksGetState(ctx context.Context, sp SearchParams) (string, *Response, error)
+	ProjectItemsList(ctx context.Context, sp SearchParams) ([]*ProjectItem, *Response, error)
 }
 
 var (
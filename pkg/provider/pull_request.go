@@ -48,13 +48,10 @@ type PullRequest struct {
 	AuthorAssociation   *string    `json:"author_association,omitempty"`
 	NodeID              *string    `json:"node_id,omitempty"`
 	RequestedReviewers  []*User    `json:"requested_reviewers,omitempty"`
+	RequestedTeams      []*Team    `json:"requested_teams,omitempty"`
 
-	// RequestedTeams is populated as part of the PullRequestEvent.
-	// See, https://developer.github.com/v3/activity/events/types/#pullrequestevent for an example.
-	//RequestedTeams []*Team `json:"requested_teams,omitempty"`
-	//
 	//Links *PRLinks           `json:"_links,omitempty"`
-	//Head  *PullRequestBranch `json:"head,omitempty"`
+	Head *PullRequestBranch `json:"head,omitempty"`
 	//Base  *PullRequestBranch `json:"base,omitempty"`
 
 	// ActiveLockReason is populated only when LockReason is provided while locking the pull request.
@@ -70,6 +67,22 @@ func (p *PullRequest) GetAssignee() *User {
 	return p.Assignee
 }
 
+// GetAssignees returns the Assignees field.
+func (p *PullRequest) GetAssignees() []*User {
+	if p == nil {
+		return nil
+	}
+	return p.Assignees
+}
+
+// GetActiveLockReason returns the ActiveLockReason field if it's non-nil, zero value otherwise.
+func (p *PullRequest) GetActiveLockReason() string {
+	if p == nil || p.ActiveLockReason == nil {
+		return ""
+	}
+	return *p.ActiveLockReason
+}
+
 // GetAuthorAssociation returns the AuthorAssociation field if it's non-nil, zero value otherwise.
 func (p *PullRequest) GetAuthorAssociation() string {
 	if p == nil || p.AuthorAssociation == nil {
@@ -110,6 +123,14 @@ func (p *PullRequest) GetComments() int {
 	return *p.Comments
 }
 
+// GetReviewComments returns the ReviewComments field if it's non-nil, zero value otherwise.
+func (p *PullRequest) GetReviewComments() int {
+	if p == nil || p.ReviewComments == nil {
+		return 0
+	}
+	return *p.ReviewComments
+}
+
 // GetCreatedAt returns the CreatedAt field if it's non-nil, zero value otherwise.
 func (p *PullRequest) GetCreatedAt() time.Time {
 	if p == nil || p.CreatedAt == nil {
@@ -127,12 +148,12 @@ func (p *PullRequest) GetDraft() bool {
 }
 
 // GetHead returns the Head field.
-//func (p *PullRequest) GetHead() *PullRequestBranch {
-//	if p == nil {
-//		return nil
-//	}
-//	return p.Head
-//}
+func (p *PullRequest) GetHead() *PullRequestBranch {
+	if p == nil {
+		return nil
+	}
+	return p.Head
+}
 
 // GetHTMLURL returns the HTMLURL field if it's non-nil, zero value otherwise.
 func (p *PullRequest) GetHTMLURL() string {
@@ -150,6 +171,14 @@ func (p *PullRequest) GetID() int64 {
 	return *p.ID
 }
 
+// GetLocked returns the Locked field if it's non-nil, zero value otherwise.
+func (p *PullRequest) GetLocked() bool {
+	if p == nil || p.Locked == nil {
+		return false
+	}
+	return *p.Locked
+}
+
 // GetMerged returns the Merged field if it's non-nil, zero value otherwise.
 func (p *PullRequest) GetMerged() bool {
 	if p == nil || p.Merged == nil {
@@ -158,6 +187,14 @@ func (p *PullRequest) GetMerged() bool {
 	return *p.Merged
 }
 
+// GetMergeableState returns the MergeableState field if it's non-nil, zero value otherwise.
+func (p *PullRequest) GetMergeableState() string {
+	if p == nil || p.MergeableState == nil {
+		return ""
+	}
+	return *p.MergeableState
+}
+
 // GetMergedBy returns the MergedBy field.
 func (p *PullRequest) GetMergedBy() *User {
 	if p == nil {
@@ -174,6 +211,11 @@ func (p *PullRequest) GetMilestone() *Milestone {
 	return p.Milestone
 }
 
+// GetTypeName returns an empty string, as pull requests do not have an issue type.
+func (p *PullRequest) GetTypeName() string {
+	return ""
+}
+
 // GetNumber returns the Number field if it's non-nil, zero value otherwise.
 func (p *PullRequest) GetNumber() int {
 	if p == nil || p.Number == nil {
@@ -225,3 +267,26 @@ func (p *PullRequest) GetUser() *User {
 func (p PullRequest) String() string {
 	return Stringify(p)
 }
+
+// PullRequestBranch represents a base or head branch in a GitHub pull request.
+type PullRequestBranch struct {
+	Label *string `json:"label,omitempty"`
+	Ref   *string `json:"ref,omitempty"`
+	SHA   *string `json:"sha,omitempty"`
+}
+
+// GetRef returns the Ref field if it's non-nil, zero value otherwise.
+func (b *PullRequestBranch) GetRef() string {
+	if b == nil || b.Ref == nil {
+		return ""
+	}
+	return *b.Ref
+}
+
+// GetSHA returns the SHA field if it's non-nil, zero value otherwise.
+func (b *PullRequestBranch) GetSHA() string {
+	if b == nil || b.SHA == nil {
+		return ""
+	}
+	return *b.SHA
+}
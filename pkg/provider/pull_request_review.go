@@ -40,3 +40,11 @@ func (p *PullRequestReview) GetSubmittedAt() time.Time {
 	}
 	return *p.SubmittedAt
 }
+
+// GetUser returns the User field.
+func (p *PullRequestReview) GetUser() *User {
+	if p == nil {
+		return nil
+	}
+	return p.User
+}
@@ -66,3 +66,26 @@ func (r *Reactions) GetTotalCount() int {
 	}
 	return *r.TotalCount
 }
+
+// Reaction represents a single user reaction to an issue or pull request
+type Reaction struct {
+	ID      *int64  `json:"id,omitempty"`
+	User    *User   `json:"user,omitempty"`
+	Content *string `json:"content,omitempty"`
+}
+
+// GetUser returns the User field.
+func (r *Reaction) GetUser() *User {
+	if r == nil {
+		return nil
+	}
+	return r.User
+}
+
+// GetContent returns the Content field if it's non-nil, zero value otherwise.
+func (r *Reaction) GetContent() string {
+	if r == nil || r.Content == nil {
+		return ""
+	}
+	return *r.Content
+}
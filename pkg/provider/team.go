@@ -0,0 +1,26 @@
+package provider
+
+// Team represents a team within a GitHub organization, such as a requested reviewer
+type Team struct {
+	ID      *int64  `json:"id,omitempty"`
+	NodeID  *string `json:"node_id,omitempty"`
+	Name    *string `json:"name,omitempty"`
+	Slug    *string `json:"slug,omitempty"`
+	HTMLURL *string `json:"html_url,omitempty"`
+}
+
+// GetName returns the Name field if it's non-nil, zero value otherwise.
+func (t *Team) GetName() string {
+	if t == nil || t.Name == nil {
+		return ""
+	}
+	return *t.Name
+}
+
+// GetSlug returns the Slug field if it's non-nil, zero value otherwise.
+func (t *Team) GetSlug() string {
+	if t == nil || t.Slug == nil {
+		return ""
+	}
+	return *t.Slug
+}
@@ -11,5 +11,16 @@ type Thing struct {
 	IssueComments       []*IssueComment
 	Timeline            []*Timeline
 	Reviews             []*PullRequestReview
+	Reactions           []*Reaction
+	CheckState          string
+	ProjectItems        []*ProjectItem
+	LinkStatus          int
 	StringBool          map[string]bool
+
+	// Tombstone marks a key as absent, such as for an item deleted upstream, until it is set again or expires
+	Tombstone bool
+
+	// Negative marks an empty result cached because the provider reported it as not found. It is kept for a shorter
+	// time than other results, as the item may be restored, or made visible to us.
+	Negative bool
 }
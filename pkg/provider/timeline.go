@@ -101,6 +101,51 @@ type Timeline struct {
 	// The 'id', 'actor', and 'url' for the source of a reference from another issue.
 	// Only provided for 'cross-referenced' events.
 	Source *Source `json:"source,omitempty"`
+
+	// An object containing rename details including 'from' and 'to' attributes.
+	// Only provided for 'renamed' events.
+	Rename *Rename `json:"rename,omitempty"`
+
+	// The git author and committer of a commit. Only provided for 'committed' events, which have no 'created_at'.
+	Author    *CommitAuthor `json:"author,omitempty"`
+	Committer *CommitAuthor `json:"committer,omitempty"`
+}
+
+// CommitAuthor is the git identity of a commit's author or committer, which may not be a GitHub user
+type CommitAuthor struct {
+	Name  *string    `json:"name,omitempty"`
+	Email *string    `json:"email,omitempty"`
+	Date  *time.Time `json:"date,omitempty"`
+}
+
+// GetDate returns the Date field if it's non-nil, zero value otherwise.
+func (c *CommitAuthor) GetDate() time.Time {
+	if c == nil || c.Date == nil {
+		return time.Time{}
+	}
+	return *c.Date
+}
+
+// Rename contains details for 'renamed' events.
+type Rename struct {
+	From *string `json:"from,omitempty"`
+	To   *string `json:"to,omitempty"`
+}
+
+// GetFrom returns the From field if it's non-nil, zero value otherwise.
+func (r *Rename) GetFrom() string {
+	if r == nil || r.From == nil {
+		return ""
+	}
+	return *r.From
+}
+
+// GetTo returns the To field if it's non-nil, zero value otherwise.
+func (r *Rename) GetTo() string {
+	if r == nil || r.To == nil {
+		return ""
+	}
+	return *r.To
 }
 
 // GetActor returns the Actor field.
@@ -111,6 +156,39 @@ func (t *Timeline) GetActor() *User {
 	return t.Actor
 }
 
+// GetAssignee returns the Assignee field.
+func (t *Timeline) GetAssignee() *User {
+	if t == nil {
+		return nil
+	}
+	return t.Assignee
+}
+
+// GetAuthor returns the Author field.
+func (t *Timeline) GetAuthor() *CommitAuthor {
+	if t == nil {
+		return nil
+	}
+	return t.Author
+}
+
+// GetCommitter returns the Committer field.
+func (t *Timeline) GetCommitter() *CommitAuthor {
+	if t == nil {
+		return nil
+	}
+	return t.Committer
+}
+
+// GetCommittedAt returns when a 'committed' event was committed, falling back to when it was authored. Commits may be
+// pushed long after either, but GitHub does not record when they were pushed.
+func (t *Timeline) GetCommittedAt() time.Time {
+	if d := t.GetCommitter().GetDate(); !d.IsZero() {
+		return d
+	}
+	return t.GetAuthor().GetDate()
+}
+
 // GetCommitID returns the CommitID field if it's non-nil, zero value otherwise.
 func (t *Timeline) GetCommitID() string {
 	if t == nil || t.CommitID == nil {
@@ -151,6 +229,14 @@ func (t *Timeline) GetLabel() *Label {
 	return t.Label
 }
 
+// GetRename returns the Rename field.
+func (t *Timeline) GetRename() *Rename {
+	if t == nil {
+		return nil
+	}
+	return t.Rename
+}
+
 // GetSource returns the Source field.
 func (t *Timeline) GetSource() *Source {
 	if t == nil {
@@ -0,0 +1,166 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package reply renders canned triage responses, such as asking for more information. It does not post them.
+package reply
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/google/triage-party/pkg/hubbub"
+)
+
+var (
+	// headingRe matches a markdown heading, or a line which is entirely bold, as issue templates often use either
+	headingRe = regexp.MustCompile(`^\s*(?:#{1,6}\s+(.*?)\s*#*|\*\*(.*?)\*\*:?)\s*$`)
+	// placeholderRe matches template text which does not count as filling in a section
+	placeholderRe = regexp.MustCompile(`(?s)<!--.*?-->|_No response_`)
+)
+
+// Template is a configurable canned response
+type Template struct {
+	// Text is a Go text/template. See Data for the available fields.
+	Text string `yaml:"text"`
+	// Sections are headings which a complete report fills in, such as "Steps to reproduce"
+	Sections []string `yaml:"sections"`
+}
+
+// Data is what a template is rendered with
+type Data struct {
+	// Author is the login of the item's author
+	Author string
+	Title  string
+	URL    string
+	Number int
+	// Missing are the template's sections which the description does not fill in
+	Missing []string
+	// Links are the configured links, such as to a contributing guide
+	Links map[string]string
+
+	Conversation *hubbub.Conversation
+}
+
+// Renderer renders named templates
+type Renderer struct {
+	templates map[string]*template.Template
+	sections  map[string][]string
+	links     map[string]string
+}
+
+// New parses templates, returning an error if any are invalid
+func New(ts map[string]Template, links map[string]string) (*Renderer, error) {
+	r := &Renderer{
+		templates: map[string]*template.Template{},
+		sections:  map[string][]string{},
+		links:     links,
+	}
+
+	for name, t := range ts {
+		if strings.TrimSpace(t.Text) == "" {
+			return nil, fmt.Errorf("%q has no text", name)
+		}
+
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(t.Text)
+		if err != nil {
+			return nil, fmt.Errorf("parse %q: %w", name, err)
+		}
+
+		r.templates[name] = tmpl
+		r.sections[name] = t.Sections
+	}
+
+	return r, nil
+}
+
+// Names returns the names of every template, sorted
+func (r *Renderer) Names() []string {
+	names := []string{}
+	for n := range r.templates {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render renders a named template for a conversation. body is the item's description, which conversations do not
+// retain, and is used to find missing sections.
+func (r *Renderer) Render(name string, co *hubbub.Conversation, body string) (string, error) {
+	tmpl, ok := r.templates[name]
+	if !ok {
+		return "", fmt.Errorf("unknown reply: %q", name)
+	}
+
+	d := Data{
+		Title:        co.Title,
+		URL:          co.URL,
+		Number:       co.ID,
+		Missing:      MissingSections(body, r.sections[name]),
+		Links:        r.links,
+		Conversation: co,
+	}
+	if co.Author != nil {
+		d.Author = co.Author.GetLogin()
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, d); err != nil {
+		return "", fmt.Errorf("execute %q: %w", name, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// MissingSections returns the sections which body does not fill in, in the order given. A section is missing if
+// there is no heading for it, or if it only contains HTML comments or GitHub's "_No response_" placeholder.
+// Headings are matched case-insensitively, ignoring a trailing colon.
+func MissingSections(body string, sections []string) []string {
+	filled := map[string]bool{}
+	current := ""
+	content := ""
+
+	finish := func() {
+		if current != "" && strings.TrimSpace(placeholderRe.ReplaceAllString(content, "")) != "" {
+			filled[current] = true
+		}
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		m := headingRe.FindStringSubmatch(line)
+		if m == nil {
+			content += line + "\n"
+			continue
+		}
+
+		finish()
+		current = normalizeHeading(m[1] + m[2])
+		content = ""
+	}
+	finish()
+
+	missing := []string{}
+	for _, s := range sections {
+		if !filled[normalizeHeading(s)] {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+func normalizeHeading(s string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), ":")))
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reply
+
+import (
+	"testing"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMissingSections(t *testing.T) {
+	body := `### Steps to reproduce
+
+1. Run it
+
+### Expected behavior
+
+<!-- What did you expect? -->
+
+**Version:**
+v1.2
+
+### Logs
+
+_No response_
+`
+
+	sections := []string{"Steps to reproduce", "Expected behavior", "version", "Logs", "Environment"}
+	want := []string{"Expected behavior", "Logs", "Environment"}
+	assert.Equal(t, want, MissingSections(body, sections))
+}
+
+func TestRender(t *testing.T) {
+	login := "octocat"
+	co := &hubbub.Conversation{ID: 7, URL: "https://github.com/o/p/issues/7", Author: &provider.User{Login: &login}}
+
+	r, err := New(map[string]Template{
+		"needs-info": {
+			Text:     "Hi @{{.Author}}! Please add:\n{{range .Missing}}* {{.}}\n{{end}}See {{.Links.contributing}}",
+			Sections: []string{"Steps to reproduce", "Logs"},
+		},
+		"typo": {Text: "{{.Links.missing}}"},
+	}, map[string]string{"contributing": "https://example.com/CONTRIBUTING.md"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	got, err := r.Render("needs-info", co, "### Steps to reproduce\nRun it\n")
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	want := "Hi @octocat! Please add:\n* Logs\nSee https://example.com/CONTRIBUTING.md"
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	if _, err := r.Render("typo", co, ""); err == nil {
+		t.Errorf("Render() with an unknown link succeeded, want error")
+	}
+	if _, err := r.Render("unknown", co, ""); err == nil {
+		t.Errorf("Render() of an unknown template succeeded, want error")
+	}
+	if _, err := New(map[string]Template{"bad": {Text: "{{.Author"}}, nil); err == nil {
+		t.Errorf("New() with an invalid template succeeded, want error")
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package similarity provides pluggable algorithms for finding similar issues & PR's
+package similarity
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/imjasonmiller/godice"
+	"k8s.io/klog/v2"
+)
+
+// Match is a scored similarity match
+type Match struct {
+	URL   string
+	Score float64
+}
+
+// Similarity is the interface for similarity algorithms
+type Similarity interface {
+	// Add indexes an item. It may be called repeatedly for the same URL, and from multiple goroutines. If the title
+	// of a URL has changed, the previous title is forgotten.
+	Add(url string, title string, body string)
+	// Remove forgets an item, such as one which has been closed or deleted
+	Remove(url string)
+	// Similar returns items similar to the one given. Implementations may use the body previously passed to Add.
+	Similar(url string, title string) []Match
+	// Len returns the number of items indexed
+	Len() int
+}
+
+var nonLetter = regexp.MustCompile(`[^a-zA-Z]`)
+var removeWords = map[string]bool{
+	"a":       true,
+	"an":      true,
+	"and":     true,
+	"are":     true,
+	"as":      true,
+	"be":      true,
+	"by":      true,
+	"can":     true,
+	"does":    true,
+	"has":     true,
+	"have":    true,
+	"how":     true,
+	"if":      true,
+	"in":      true,
+	"is":      true,
+	"of":      true,
+	"on":      true,
+	"or":      true,
+	"the":     true,
+	"that":    true,
+	"to":      true,
+	"use":     true,
+	"very":    true,
+	"via":     true,
+	"too":     true,
+	"why":     true,
+	"add":     true,
+	"feature": true,
+	"fix":     true,
+	"bug":     true,
+	"fr":      true,
+	"it":      true,
+	"you":     true,
+	"with":    true,
+	"do":      true,
+	"we":      true,
+}
+
+// normalize titles for a higher hit-rate
+func normalizeTitle(t string) string {
+	var keep []string
+	for _, word := range strings.Split(t, " ") {
+		word = nonLetter.ReplaceAllString(word, "")
+		if len(word) == 0 {
+			continue
+		}
+		word = strings.ToLower(word)
+		if removeWords[word] {
+			continue
+		}
+		keep = append(keep, word)
+	}
+
+	klog.V(4).Infof("normalized: %s", strings.Join(keep, " "))
+	return strings.Join(keep, " ")
+}
+
+// scoredTitle is a normalized title and how similar it is
+type scoredTitle struct {
+	title string
+	score float64
+}
+
+// Title is the default algorithm: it compares normalized titles using the Sørensen–Dice coefficient
+type Title struct {
+	// MinSimilarity is how close two titles need to be to each other to be called similar
+	MinSimilarity float64
+
+	// serializes Add and Remove, so that titles are not indexed twice or removed while being added
+	mu sync.Mutex
+
+	titleToURLs   sync.Map
+	similarTitles sync.Map
+	urlToTitle    sync.Map
+	count         int
+}
+
+// NewTitle returns a new title-based similarity index
+func NewTitle(minSimilarity float64) *Title {
+	return &Title{MinSimilarity: minSimilarity}
+}
+
+// Len returns the number of URLs indexed
+func (s *Title) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+// Add updates the similarity tables with an item
+func (s *Title) Add(url string, rawTitle string, _ string) {
+	title := normalizeTitle(rawTitle)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if old, ok := s.urlToTitle.Load(url); ok {
+		if old.(string) == title {
+			return
+		}
+		klog.V(4).Infof("%s was retitled to %q", url, rawTitle)
+		s.remove(url)
+	}
+
+	s.urlToTitle.Store(url, title)
+	s.count++
+
+	result, existing := s.titleToURLs.Load(title)
+	if existing {
+		klog.V(4).Infof("updating %q with %v", rawTitle, result)
+		s.titleToURLs.Store(title, append(append([]string{}, result.([]string)...), url))
+		return
+	}
+	s.titleToURLs.Store(title, []string{url})
+
+	// Update us -> them title similarity
+	similarTo := []scoredTitle{}
+
+	s.titleToURLs.Range(func(k, v interface{}) bool {
+		otherTitle, ok := k.(string)
+		if !ok {
+			klog.V(1).Infof("key %q is not of type string", k)
+		}
+		if otherTitle == title {
+			return true
+		}
+
+		score := godice.CompareString(title, otherTitle)
+		if score > s.MinSimilarity {
+			klog.V(4).Infof("%q is similar to %q", rawTitle, otherTitle)
+			similarTo = append(similarTo, scoredTitle{title: otherTitle, score: score})
+		}
+		return true
+	})
+
+	s.similarTitles.Store(title, similarTo)
+
+	// Update them -> us title similarity
+	for _, other := range similarTo {
+		klog.V(4).Infof("updating %q to map to %s", other.title, title)
+		others, ok := s.similarTitles.Load(other.title)
+		if ok {
+			updated := append([]scoredTitle{}, others.([]scoredTitle)...)
+			s.similarTitles.Store(other.title, append(updated, scoredTitle{title: title, score: other.score}))
+		}
+	}
+}
+
+// Remove forgets a URL, and its title if no other URL shares it
+func (s *Title) Remove(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(url)
+}
+
+// remove forgets a URL. The caller must hold s.mu.
+func (s *Title) remove(url string) {
+	x, ok := s.urlToTitle.Load(url)
+	if !ok {
+		return
+	}
+	title := x.(string)
+	s.urlToTitle.Delete(url)
+	s.count--
+
+	urls, _ := s.titleToURLs.Load(title)
+	remaining := []string{}
+	for _, u := range urls.([]string) {
+		if u != url {
+			remaining = append(remaining, u)
+		}
+	}
+
+	if len(remaining) > 0 {
+		s.titleToURLs.Store(title, remaining)
+		return
+	}
+
+	// Nobody else has this title: remove it, and references to it from similar titles
+	s.titleToURLs.Delete(title)
+	similar, ok := s.similarTitles.Load(title)
+	s.similarTitles.Delete(title)
+	if !ok {
+		return
+	}
+
+	for _, other := range similar.([]scoredTitle) {
+		others, ok := s.similarTitles.Load(other.title)
+		if !ok {
+			continue
+		}
+		kept := []scoredTitle{}
+		for _, st := range others.([]scoredTitle) {
+			if st.title != title {
+				kept = append(kept, st)
+			}
+		}
+		s.similarTitles.Store(other.title, kept)
+	}
+}
+
+// Similar returns items with titles similar to this one
+func (s *Title) Similar(url string, rawTitle string) []Match {
+	title := normalizeTitle(rawTitle)
+
+	tres, ok := s.similarTitles.Load(title)
+	if !ok {
+		return nil
+	}
+
+	matches := []Match{}
+	for _, ot := range tres.([]scoredTitle) {
+		ures, ok := s.titleToURLs.Load(ot.title)
+		if !ok {
+			continue
+		}
+		for _, u := range ures.([]string) {
+			// We found ourselves with a different title
+			if u == url {
+				continue
+			}
+			matches = append(matches, Match{URL: u, Score: ot.score})
+		}
+	}
+
+	return matches
+}
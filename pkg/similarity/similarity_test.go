@@ -0,0 +1,119 @@
+package similarity
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func urls(ms []Match) []string {
+	us := []string{}
+	for _, m := range ms {
+		us = append(us, m.URL)
+	}
+	return us
+}
+
+func TestTitleParity(t *testing.T) {
+	items := [][2]string{
+		{"u1", "minikube start fails on hyperkit"},
+		{"u2", "minikube start fails with hyperkit"},
+		{"u3", "Minikube start fails on HyperKit!"},
+		{"u4", "add support for podman driver"},
+		{"u5", "support for the podman driver"},
+		{"u6", "dashboard crashes on windows"},
+		{"u7", "minikube start fails on hyperv"},
+	}
+
+	s := NewTitle(0.75)
+	for _, i := range items {
+		s.Add(i[0], i[1], "")
+	}
+
+	// Results generated by the algorithm previously embedded within hubbub
+	want := map[string][]string{
+		"u1": {"u7"},
+		"u2": {"u7"},
+		"u3": {"u7"},
+		"u4": {},
+		"u5": {},
+		"u6": {},
+		"u7": {"u1", "u2", "u3"},
+	}
+
+	for _, i := range items {
+		assert.Equal(t, want[i[0]], urls(s.Similar(i[0], i[1])), "similar to %s", i[0])
+	}
+
+	for _, m := range s.Similar("u7", "minikube start fails on hyperv") {
+		assert.True(t, m.Score > 0.75, "score for %s: %f", m.URL, m.Score)
+	}
+}
+
+func TestNormalizeTitle(t *testing.T) {
+	assert.Equal(t, "minikube start fails hyperkit", normalizeTitle("Minikube start fails on HyperKit!"))
+	assert.Equal(t, "support for podman", normalizeTitle("Add support for podman"))
+}
+
+func TestTitleRetitleAndRemove(t *testing.T) {
+	s := NewTitle(0.75)
+	s.Add("u1", "minikube start fails on hyperkit", "")
+	s.Add("u2", "minikube start fails on hyperv", "")
+	assert.Equal(t, []string{"u2"}, urls(s.Similar("u1", "minikube start fails on hyperkit")))
+
+	s.Add("u2", "dashboard crashes on windows", "")
+	assert.Equal(t, []string{}, urls(s.Similar("u1", "minikube start fails on hyperkit")))
+	assert.Equal(t, 2, s.Len())
+
+	s.Add("u3", "minikube start fails on hyperv", "")
+	s.Remove("u3")
+	s.Remove("u3")
+	assert.Equal(t, []string{}, urls(s.Similar("u1", "minikube start fails on hyperkit")))
+	assert.Equal(t, 2, s.Len())
+}
+
+func TestTitleIncrementalConsistency(t *testing.T) {
+	titles := []string{
+		"minikube start fails on hyperkit",
+		"minikube start fails with hyperkit",
+		"minikube start fails on hyperv",
+		"add support for podman driver",
+		"support for the podman driver",
+		"dashboard crashes on windows",
+		"dashboard crashes on macos",
+		"docker driver is slow",
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	incremental := NewTitle(0.75)
+	final := map[string]string{}
+
+	for i := 0; i < 2000; i++ {
+		url := fmt.Sprintf("u%d", rnd.Intn(30))
+		if rnd.Intn(4) == 0 {
+			incremental.Remove(url)
+			delete(final, url)
+			continue
+		}
+		title := titles[rnd.Intn(len(titles))]
+		incremental.Add(url, title, "")
+		final[url] = title
+	}
+
+	rebuilt := NewTitle(0.75)
+	for url, title := range final {
+		rebuilt.Add(url, title, "")
+	}
+
+	assert.Equal(t, rebuilt.Len(), incremental.Len())
+	for url, title := range final {
+		want := urls(rebuilt.Similar(url, title))
+		got := urls(incremental.Similar(url, title))
+		sort.Strings(want)
+		sort.Strings(got)
+		assert.Equal(t, want, got, "similar to %s (%q)", url, title)
+	}
+}
@@ -77,6 +77,14 @@ func (h *Handlers) Collection() http.HandlerFunc {
 			p.UniqueItems = uniqueItems(p.CollectionResult.RuleResults)
 		}
 
+		if r.URL.Query().Get("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			if err := StreamConversations(w, p.CollectionResult, RequestedFields(r)); err != nil {
+				klog.Errorf("stream %s: %v", id, err)
+			}
+			return
+		}
+
 		getVars := ""
 		if players > 0 {
 			getVars = fmt.Sprintf("?player=%d&players=%d", player, players)
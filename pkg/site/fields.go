@@ -0,0 +1,79 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RequestedFields returns the JSON fields requested via ?fields=a,b,c, or nil for all fields
+func RequestedFields(r *http.Request) []string {
+	var fields []string
+	for _, f := range strings.Split(r.URL.Query().Get("fields"), ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// SelectFields projects the serialized form of v, such as a Conversation or a list of them, down to the
+// requested top-level JSON fields. If no fields are requested, v is returned as-is.
+func SelectFields(v interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return v, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+
+	want := map[string]bool{}
+	for _, f := range fields {
+		want[f] = true
+	}
+	return project(raw, want), nil
+}
+
+// project filters objects, or each object within a list, to the wanted keys
+func project(v interface{}, want map[string]bool) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for k, v := range t {
+			if want[k] {
+				m[k] = v
+			}
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, v := range t {
+			l[i] = project(v, want)
+		}
+		return l
+	default:
+		return v
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/tag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectFields(t *testing.T) {
+	cs := []*hubbub.Conversation{
+		{ID: 1, Title: "one", URL: "https://github.com/org/project/issues/1", Tags: map[tag.Tag]bool{}},
+		{ID: 2, Title: "two", URL: "https://github.com/org/project/issues/2", Tags: map[tag.Tag]bool{}},
+	}
+
+	r := httptest.NewRequest("GET", "/json?fields=title,%20url,", nil)
+	got, err := SelectFields(cs, RequestedFields(r))
+	if err != nil {
+		t.Fatalf("SelectFields: %v", err)
+	}
+
+	b, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	assert.JSONEq(t, `[
+		{"title": "one", "url": "https://github.com/org/project/issues/1"},
+		{"title": "two", "url": "https://github.com/org/project/issues/2"}
+	]`, string(b))
+
+	// No fields means the full object
+	full, err := SelectFields(cs, RequestedFields(httptest.NewRequest("GET", "/json", nil)))
+	if err != nil {
+		t.Fatalf("SelectFields: %v", err)
+	}
+	assert.Equal(t, cs, full)
+}
@@ -143,11 +143,20 @@ func (h *Handlers) Kanban() http.HandlerFunc {
 			klog.Infof("milestones chosen: %d, choices: %+v", milestoneID, milestones)
 
 			p.Description = p.Collection.Description
-			p.Swimlanes = groupByUser(p.CollectionResult.RuleResults, chosen.GetNumber(), p.Collection.Dedup)
+			laneKey := fmt.Sprintf("lanes:%s:%d:%v", id, chosen.GetNumber(), p.Collection.Dedup)
+			p.Swimlanes = h.memo.get(laneKey, p.CollectionResult.Created, func() interface{} {
+				return groupByUser(p.CollectionResult.RuleResults, chosen.GetNumber(), p.Collection.Dedup)
+			}).([]*Swimlane)
 			p.SelectorOptions = milestones
 			p.SelectorVar = "milestone"
 			p.Milestone = chosen
-			p.ClosedPerDay = calcClosedPerDay(p.VelocityStats)
+			if p.VelocityStats != nil {
+				p.ClosedPerDay = h.memo.get("closed-per-day:"+id, p.VelocityStats.Created, func() interface{} {
+					return calcClosedPerDay(p.VelocityStats)
+				}).(float64)
+			} else {
+				p.ClosedPerDay = calcClosedPerDay(p.VelocityStats)
+			}
 			p.CompletionETA = calcETA(p.Swimlanes, p.ClosedPerDay)
 
 			etaDate, etaOffset, countOffset := calcMilestoneETA(chosen, p.ClosedPerDay)
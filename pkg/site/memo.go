@@ -0,0 +1,69 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"sync"
+	"time"
+)
+
+type memoEntry struct {
+	created time.Time
+	value   interface{}
+}
+
+// memo caches values derived from a collection result, such as deduplicated items, until the result is replaced
+type memo struct {
+	mu      sync.Mutex
+	max     int
+	entries map[string]memoEntry
+	// keys in insertion order, for eviction
+	order []string
+}
+
+// newMemo returns a memo holding up to max values. A max of 0 disables caching.
+func newMemo(max int) *memo {
+	return &memo{max: max, entries: map[string]memoEntry{}}
+}
+
+// get returns the value for key if it was derived from a result created at the same time, otherwise it is computed.
+// Values are shared between requests, so must not be modified.
+func (m *memo) get(key string, created time.Time, compute func() interface{}) interface{} {
+	if m == nil || m.max <= 0 || created.IsZero() {
+		return compute()
+	}
+
+	m.mu.Lock()
+	e, ok := m.entries[key]
+	m.mu.Unlock()
+	if ok && e.created.Equal(created) {
+		return e.value
+	}
+
+	v := compute()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.entries[key]; !ok {
+		m.order = append(m.order, key)
+	}
+	m.entries[key] = memoEntry{created: created, value: v}
+
+	for len(m.order) > m.max {
+		delete(m.entries, m.order[0])
+		m.order = m.order[1:]
+	}
+	return v
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemo(t *testing.T) {
+	m := newMemo(2)
+	calls := 0
+	compute := func() interface{} {
+		calls++
+		return calls
+	}
+
+	t1 := time.Now()
+	t2 := t1.Add(time.Minute)
+
+	assert.Equal(t, 1, m.get("a", t1, compute))
+	assert.Equal(t, 1, m.get("a", t1, compute), "same result should be cached")
+	assert.Equal(t, 2, m.get("a", t2, compute), "newer result should be recomputed")
+	assert.Equal(t, 2, m.get("a", t2, compute))
+
+	m.get("b", t1, compute)
+	m.get("c", t1, compute)
+	assert.Equal(t, 2, len(m.entries), "oldest entries should be evicted")
+	assert.Equal(t, 5, m.get("a", t2, compute), "evicted entry should be recomputed")
+}
+
+func TestMemoDisabled(t *testing.T) {
+	m := newMemo(0)
+	calls := 0
+	compute := func() interface{} {
+		calls++
+		return calls
+	}
+
+	now := time.Now()
+	m.get("a", now, compute)
+	m.get("a", now, compute)
+	assert.Equal(t, 2, calls)
+}
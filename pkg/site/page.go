@@ -68,7 +68,9 @@ func (h *Handlers) collectionPage(ctx context.Context, id string, refresh bool)
 		total += len(o.Items)
 	}
 
-	unique := uniqueItems(result.RuleResults)
+	unique := h.memo.get("unique:"+id, result.Created, func() interface{} {
+		return uniqueItems(result.RuleResults)
+	}).([]*hubbub.Conversation)
 
 	p := &Page{
 		ID:               s.ID,
@@ -93,6 +95,11 @@ func (h *Handlers) collectionPage(ctx context.Context, id string, refresh bool)
 		p.Stale = true
 	}
 
+	if result.Stale {
+		p.Warning = template.HTML(fmt.Sprintf("This data has not been refreshed for %s, and may be out of date. GitHub may be rate limiting Triage Party, or refreshes may be failing.", humanDuration(time.Since(result.Created))))
+		p.Stale = true
+	}
+
 	if result.Collection != nil && result.Collection.Velocity != "" {
 		p.VelocityStats = h.updater.Lookup(ctx, result.Collection.Velocity, false)
 	} else {
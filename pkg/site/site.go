@@ -75,6 +75,10 @@ type Config struct {
 	WarnAge       time.Duration
 	Updater       *updater.Updater
 	Party         *triage.Party
+
+	// RenderCacheSize is how many values derived from collection results, such as deduplicated items, to cache
+	// between refreshes. 0 disables the cache.
+	RenderCacheSize int
 }
 
 func New(c *Config) *Handlers {
@@ -85,6 +89,7 @@ func New(c *Config) *Handlers {
 		siteName:  c.Name,
 		warnAge:   c.WarnAge,
 		startTime: time.Now(),
+		memo:      newMemo(c.RenderCacheSize),
 	}
 }
 
@@ -96,6 +101,7 @@ type Handlers struct {
 	siteName  string
 	warnAge   time.Duration
 	startTime time.Time
+	memo      *memo
 }
 
 // Root redirects to leaderboard.
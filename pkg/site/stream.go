@@ -0,0 +1,64 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/triage-party/pkg/triage"
+)
+
+// flushEvery is how many conversations are streamed between flushes to an HTTP client
+const flushEvery = 100
+
+// StreamConversations writes the unique conversations within a collection result to w as a JSON array. Each
+// conversation is encoded as it is written, rather than marshaling the whole list, which keeps peak memory down and
+// lets clients start reading sooner. If fields are given, each conversation is projected down to them, as with
+// SelectFields. An error part way through leaves the array unterminated, so clients will not mistake it for a
+// complete result.
+func StreamConversations(w io.Writer, r *triage.CollectionResult, fields []string) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	for i, co := range uniqueItems(r.RuleResults) {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+
+		v, err := SelectFields(co, fields)
+		if err != nil {
+			return fmt.Errorf("#%d: %w", co.ID, err)
+		}
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("encode #%d: %w", co.ID, err)
+		}
+
+		if flusher != nil && (i+1)%flushEvery == 0 {
+			flusher.Flush()
+		}
+	}
+
+	_, err := io.WriteString(w, "]\n")
+	return err
+}
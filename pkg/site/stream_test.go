@@ -0,0 +1,46 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/tag"
+	"github.com/google/triage-party/pkg/triage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamConversations(t *testing.T) {
+	one := &hubbub.Conversation{ID: 1, Title: "one", URL: "https://github.com/org/project/issues/1", Tags: map[tag.Tag]bool{}}
+	two := &hubbub.Conversation{ID: 2, Title: "two", URL: "https://github.com/org/project/issues/2", Tags: map[tag.Tag]bool{}}
+	r := &triage.CollectionResult{RuleResults: []*triage.RuleResult{
+		{Items: []*hubbub.Conversation{one, two}},
+		{Items: []*hubbub.Conversation{two}},
+	}}
+
+	w := httptest.NewRecorder()
+	if err := StreamConversations(w, r, []string{"id", "title"}); err != nil {
+		t.Fatalf("StreamConversations: %v", err)
+	}
+	assert.JSONEq(t, `[{"id": 1, "title": "one"}, {"id": 2, "title": "two"}]`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	if err := StreamConversations(w, &triage.CollectionResult{}, nil); err != nil {
+		t.Fatalf("StreamConversations: %v", err)
+	}
+	assert.JSONEq(t, `[]`, w.Body.String())
+}
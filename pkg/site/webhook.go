@@ -0,0 +1,89 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+const (
+	signature256Header = "X-Hub-Signature-256"
+	signature1Header   = "X-Hub-Signature"
+)
+
+// maxWebhookPayload is the largest request body read, matching the 25MB cap GitHub places on webhook payloads
+var maxWebhookPayload int64 = 25 << 20
+
+var (
+	errPayloadTooLarge  = errors.New("webhook payload too large")
+	errMissingSignature = errors.New("missing webhook signature")
+	errInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// VerifyWebhook reads a GitHub webhook request body, returning an error unless it was signed using secret.
+// X-Hub-Signature-256 is preferred, falling back to the legacy SHA-1 X-Hub-Signature header.
+func VerifyWebhook(r *http.Request, secret []byte) ([]byte, error) {
+	// Reads one byte past the limit, so that an oversized body is rejected rather than truncated
+	payload, err := ioutil.ReadAll(io.LimitReader(r.Body, maxWebhookPayload+1))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	if int64(len(payload)) > maxWebhookPayload {
+		return nil, errPayloadTooLarge
+	}
+
+	if sig := r.Header.Get(signature256Header); sig != "" {
+		return payload, validSignature(sha256.New, "sha256=", secret, payload, sig)
+	}
+
+	if sig := r.Header.Get(signature1Header); sig != "" {
+		return payload, validSignature(sha1.New, "sha1=", secret, payload, sig)
+	}
+
+	return payload, errMissingSignature
+}
+
+// validSignature checks a hex-encoded HMAC signature, such as "sha256=<hex>", in constant time
+func validSignature(h func() hash.Hash, prefix string, secret []byte, payload []byte, sig string) error {
+	if len(secret) == 0 {
+		return fmt.Errorf("no webhook secret configured")
+	}
+
+	if !strings.HasPrefix(sig, prefix) {
+		return errInvalidSignature
+	}
+
+	got, err := hex.DecodeString(strings.TrimPrefix(sig, prefix))
+	if err != nil {
+		return errInvalidSignature
+	}
+
+	mac := hmac.New(h, secret)
+	mac.Write(payload)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return errInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyWebhook(t *testing.T) {
+	secret := []byte("It's a Secret to Everybody")
+	payload := "Hello, World!"
+
+	tests := []struct {
+		name    string
+		header  string
+		sig     string
+		secret  []byte
+		wantErr bool
+	}{
+		{
+			name:   "sha256",
+			header: signature256Header,
+			sig:    "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17",
+			secret: secret,
+		},
+		{
+			name:   "sha1",
+			header: signature1Header,
+			sig:    "sha1=01dc10d0c83e72ed246219cdd91669667fe2ca59",
+			secret: secret,
+		},
+		{
+			name:    "wrong secret",
+			header:  signature256Header,
+			sig:     "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17",
+			secret:  []byte("wrong"),
+			wantErr: true,
+		},
+		{
+			name:    "tampered",
+			header:  signature256Header,
+			sig:     "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e18",
+			secret:  secret,
+			wantErr: true,
+		},
+		{
+			name:    "wrong algorithm prefix",
+			header:  signature256Header,
+			sig:     "sha1=01dc10d0c83e72ed246219cdd91669667fe2ca59",
+			secret:  secret,
+			wantErr: true,
+		},
+		{
+			name:    "not hex",
+			header:  signature256Header,
+			sig:     "sha256=zzz",
+			secret:  secret,
+			wantErr: true,
+		},
+		{
+			name:    "missing",
+			secret:  secret,
+			wantErr: true,
+		},
+		{
+			name:    "no secret",
+			header:  signature256Header,
+			sig:     "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/webhook", strings.NewReader(payload))
+			if tc.header != "" {
+				r.Header.Set(tc.header, tc.sig)
+			}
+
+			got, err := VerifyWebhook(r, tc.secret)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, payload, string(got))
+		})
+	}
+}
+
+func TestVerifyWebhookTooLarge(t *testing.T) {
+	defer func(n int64) { maxWebhookPayload = n }(maxWebhookPayload)
+	maxWebhookPayload = 12
+
+	r := httptest.NewRequest("POST", "/webhook", strings.NewReader("Hello, World!"))
+	r.Header.Set(signature256Header, "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17")
+	_, err := VerifyWebhook(r, []byte("It's a Secret to Everybody"))
+	assert.Equal(t, errPayloadTooLarge, err)
+
+	maxWebhookPayload = 13
+	r = httptest.NewRequest("POST", "/webhook", strings.NewReader("Hello, World!"))
+	r.Header.Set(signature256Header, "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17")
+	_, err = VerifyWebhook(r, []byte("It's a Secret to Everybody"))
+	assert.NoError(t, err)
+}
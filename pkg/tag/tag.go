@@ -14,7 +14,10 @@
 
 package tag
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 // Tag is used for automatically labelling issues
 type Tag struct {
@@ -34,6 +37,8 @@ var (
 	Similar       = Tag{ID: "similar", Desc: "Title appears similar to another PR or issue"}
 	Merged        = Tag{ID: "merged", Desc: "PR was merged"}
 	Draft         = Tag{ID: "draft", Desc: "Draft PR"}
+	Old           = Tag{ID: "old", Desc: "This item has been open for a long time"}
+	Ancient       = Tag{ID: "ancient", Desc: "This item has been open for a very long time"}
 
 	// Comment-based tags
 	Commented       = Tag{ID: "commented", Desc: "A project member has commented on this", NeedsComments: true}
@@ -42,6 +47,10 @@ var (
 	RecvQ           = Tag{ID: "recv-q", Desc: "The author has asked a question since the last project member commented", NeedsComments: true}
 	AuthorLast      = Tag{ID: "author-last", Desc: "The last commenter was the original author", NeedsComments: true}
 	AssigneeUpdated = Tag{ID: "assignee-updated", Desc: "Issue has been updated by its assignee", NeedsComments: true}
+	Bountied        = Tag{ID: "bountied", Desc: "A bounty has been posted for this item", NeedsComments: true}
+
+	FirstResponseBreached = Tag{ID: "first-response-breached", Desc: "A project member did not respond within the first response SLA", NeedsComments: true}
+	Escalated             = Tag{ID: "escalated", Desc: "Matches the configured escalation rule", NeedsComments: true}
 
 	// Timeline-based tags
 	XrefApproved            = Tag{ID: "pr-approved", Desc: "Last review was an approval", NeedsTimeline: true}
@@ -50,6 +59,7 @@ var (
 	XrefNewCommits          = Tag{ID: "pr-new-commits", Desc: "PR has commits since the last review", NeedsTimeline: true}
 	XrefPushedAfterApproval = Tag{ID: "pr-pushed-after-approval", Desc: "PR was pushed to after approval", NeedsTimeline: true}
 	XrefUnreviewed          = Tag{ID: "pr-unreviewed", Desc: "PR has never been reviewed", NeedsTimeline: true}
+	Churning                = Tag{ID: "churning", Desc: "A bot has repeatedly closed and reopened this item", NeedsTimeline: true}
 
 	// Review-based tags
 	Approved            = Tag{ID: "approved", Desc: "Last review was an approval", NeedsReviews: true}
@@ -70,12 +80,17 @@ var Tags = map[Tag]bool{
 	Similar:                 true,
 	Merged:                  true,
 	Draft:                   true,
+	Old:                     true,
+	Ancient:                 true,
 	Commented:               true,
 	Send:                    true,
 	Recv:                    true,
 	RecvQ:                   true,
 	AuthorLast:              true,
 	AssigneeUpdated:         true,
+	Bountied:                true,
+	FirstResponseBreached:   true,
+	Escalated:               true,
 	Approved:                true,
 	ReviewedWithComment:     true,
 	ChangesRequested:        true,
@@ -88,11 +103,35 @@ var Tags = map[Tag]bool{
 	XrefNewCommits:          true,
 	XrefPushedAfterApproval: true,
 	XrefUnreviewed:          true,
+	Churning:                true,
 }
 
+// RoleLastPattern describes the ID of tags generated by RoleLast, where <role> is a lower-case author association
+const RoleLastPattern = "<role>-last"
+
+// Roles are the author associations which RoleLast tags are generated for
+// https://developer.github.com/v4/enum/commentauthorassociation/
+var Roles = []string{"collaborator", "contributor", "first_time_contributor", "first_timer", "mannequin", "member", "owner"}
+
+// RoleLast returns a tag for when the last commenter had a particular author association
 func RoleLast(role string) Tag {
 	return Tag{
-		ID:   fmt.Sprintf("%s-last", role),
-		Desc: fmt.Sprintf("The last commenter was a project %s", role),
+		ID:            fmt.Sprintf("%s-last", role),
+		Desc:          fmt.Sprintf("The last commenter was a project %s", role),
+		NeedsComments: true,
 	}
 }
+
+// All returns every tag a conversation could receive, including RoleLast tags for each of Roles, sorted by ID
+func All() []Tag {
+	ts := []Tag{}
+	for t := range Tags {
+		ts = append(ts, t)
+	}
+	for _, r := range Roles {
+		ts = append(ts, RoleLast(r))
+	}
+
+	sort.Slice(ts, func(i, j int) bool { return ts[i].ID < ts[j].ID })
+	return ts
+}
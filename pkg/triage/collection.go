@@ -21,9 +21,13 @@ import (
 	"time"
 
 	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/tag"
 	"k8s.io/klog/v2"
 )
 
+// SortAttention orders the items of each rule by descending attention score, rather than the order they were found in
+const SortAttention = "attention"
+
 // Collection represents a fully loaded YAML configuration
 type Collection struct {
 	ID           string   `yaml:"id"`
@@ -33,6 +37,13 @@ type Collection struct {
 	Dedup        bool     `yaml:"dedup,omitempty"`
 	Hidden       bool     `yaml:"hidden,omitempty"`
 	UsedForStats bool     `yaml:"used_for_statistics,omitempty"`
+	DefaultState string   `yaml:"default_state,omitempty"`
+	Query        string   `yaml:"query,omitempty"`
+	Tags         []string `yaml:"tags,omitempty"`
+	StaleAfter   string   `yaml:"stale_after,omitempty"`
+	Sort         string   `yaml:"sort,omitempty"`
+	PerPage      int      `yaml:"per_page,omitempty"`
+	MaxPages     int      `yaml:"max_pages,omitempty"`
 
 	// Kanban option
 	Display  string `yaml:"display"`
@@ -62,6 +73,45 @@ type CollectionResult struct {
 	TotalAgeDays             float64
 	TotalCurrentHoldDays     float64
 	TotalAccumulatedHoldDays float64
+
+	Stats *CollectionStats
+
+	// Stale is set if the result is older than the collection's staleness threshold, such as when the updater has
+	// fallen behind due to rate limits or errors. StaleSince is when it crossed the threshold.
+	Stale      bool
+	StaleSince time.Time
+}
+
+// StaleThreshold returns how old a result may be before it is considered stale, or def if stale_after is unset
+func (c Collection) StaleThreshold(def time.Duration) time.Duration {
+	if c.StaleAfter == "" {
+		return def
+	}
+	// Validated by validateLoadedConfig
+	d, _ := parseStaleAfter(c.StaleAfter)
+	return d
+}
+
+func parseStaleAfter(s string) (time.Duration, error) {
+	d, within, over := hubbub.ParseDuration(s)
+	if d <= 0 || within || over {
+		return 0, fmt.Errorf("invalid duration: %q", s)
+	}
+	return d, nil
+}
+
+// CollectionStats are aggregate statistics for the unique items within a collection result
+type CollectionStats struct {
+	Total   int            `json:"total"`
+	ByState map[string]int `json:"by_state"`
+
+	// Items tagged as recv: the author commented more recently than a project member
+	NeedsMemberResponse int `json:"needs_member_response"`
+	// Items tagged as send: a project member commented more recently than the author
+	NeedsAuthorResponse int `json:"needs_author_response"`
+
+	// Avoiding time.Duration because it's easy to int64 overflow
+	TotalCurrentHoldDays float64 `json:"total_current_hold_days"`
 }
 
 // ExecuteCollection executes a collection.
@@ -90,14 +140,23 @@ func (p *Party) ExecuteCollection(ctx context.Context, s Collection, newerThan t
 		hidden := s.Hidden && s.UsedForStats
 
 		sp := provider.SearchParams{
-			NewerThan: newerThan,
-			Hidden:    hidden,
+			NewerThan:    newerThan,
+			Hidden:       hidden,
+			DefaultState: s.DefaultState,
+			Query:        s.Query,
+			Tags:         s.Tags,
+			PerPage:      s.PerPage,
+			MaxPages:     s.MaxPages,
 		}
 		ro, err := p.ExecuteRule(ctx, sp, t, seen)
 		if err != nil {
 			return nil, fmt.Errorf("rule %q: %w", t.Name, err)
 		}
 
+		if s.Sort == SortAttention {
+			hubbub.SortByAttention(ro.Items)
+		}
+
 		if ro.OldestInput.Before(oldest) {
 			oldest = ro.OldestInput
 		}
@@ -114,12 +173,64 @@ func (p *Party) ExecuteCollection(ctx context.Context, s Collection, newerThan t
 	return r, nil
 }
 
+// CountCollection returns the number of items in a collection. If every rule can be evaluated without
+// fetching comments or events, only the item listings are consulted. Otherwise, it falls back to ExecuteCollection.
+func (p *Party) CountCollection(ctx context.Context, s Collection, newerThan time.Time) (int, error) {
+	rules := []Rule{}
+	seenRule := map[string]bool{}
+
+	for _, tid := range s.RuleIDs {
+		if seenRule[tid] {
+			continue
+		}
+		seenRule[tid] = true
+
+		t, err := p.LookupRule(tid)
+		if err != nil {
+			return 0, err
+		}
+
+		if !hubbub.CountOnly(t.Filters) {
+			klog.Warningf("collection %q: rule %q requires enrichment, falling back to full execution", s.ID, tid)
+			r, err := p.ExecuteCollection(ctx, s, newerThan)
+			if err != nil {
+				return 0, err
+			}
+			return r.Total, nil
+		}
+		rules = append(rules, t)
+	}
+
+	total := 0
+	for _, t := range rules {
+		sp := provider.SearchParams{
+			NewerThan:    newerThan,
+			Hidden:       s.Hidden && s.UsedForStats,
+			DefaultState: s.DefaultState,
+			Query:        s.Query,
+			PerPage:      s.PerPage,
+			MaxPages:     s.MaxPages,
+		}
+
+		urls, err := p.CountRule(ctx, sp, t)
+		if err != nil {
+			return 0, fmt.Errorf("rule %q: %w", t.Name, err)
+		}
+
+		// Matches ExecuteCollection, where duplicates are marked but still counted
+		total += len(urls)
+	}
+
+	return total, nil
+}
+
 // SummarizeCollectionResult adds together statistics about collection results {
 func SummarizeCollectionResult(s *Collection, os []*RuleResult) *CollectionResult {
 	klog.V(1).Infof("Summarizing collection result with %d rules...", len(os))
 
 	r := &CollectionResult{
 		Collection: s,
+		Stats:      summarizeStats(os),
 	}
 
 	for _, oc := range os {
@@ -148,6 +259,33 @@ func SummarizeCollectionResult(s *Collection, os []*RuleResult) *CollectionResul
 	return r
 }
 
+// summarizeStats calculates statistics for the unique items within rule results
+func summarizeStats(os []*RuleResult) *CollectionStats {
+	st := &CollectionStats{ByState: map[string]int{}}
+	seen := map[string]bool{}
+
+	for _, oc := range os {
+		for _, c := range oc.Items {
+			if seen[c.URL] {
+				continue
+			}
+			seen[c.URL] = true
+
+			st.Total++
+			st.ByState[c.State]++
+			if c.Tags[tag.Recv] {
+				st.NeedsMemberResponse++
+			}
+			if c.Tags[tag.Send] {
+				st.NeedsAuthorResponse++
+			}
+			st.TotalCurrentHoldDays += c.CurrentHoldTime.Hours() / 24
+		}
+	}
+
+	return st
+}
+
 func avgDayDuration(total float64, count int) time.Duration {
 	return time.Duration(int64(total/float64(count)*24)) * time.Hour
 }
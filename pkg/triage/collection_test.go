@@ -0,0 +1,93 @@
+package triage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/constants"
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountCollection(t *testing.T) {
+	m, err := persist.NewMemory(persist.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, m.Initialize())
+
+	state := constants.OpenState
+	login := "user"
+	created := time.Now().Add(-72 * time.Hour)
+	bug := "bug"
+	issues := []*provider.Issue{}
+	for n := 1; n <= 3; n++ {
+		n := n
+		url := fmt.Sprintf("https://github.com/org/project/issues/%d", n)
+		title := fmt.Sprintf("issue %d", n)
+		i := &provider.Issue{Number: &n, State: &state, HTMLURL: &url, Title: &title, User: &provider.User{Login: &login}, CreatedAt: &created, UpdatedAt: &created}
+		if n < 3 {
+			i.Labels = []*provider.Label{{Name: &bug}}
+		}
+		issues = append(issues, i)
+	}
+	assert.NoError(t, m.Set("org-project-open-issues", &provider.Thing{Created: time.Now(), Issues: issues}))
+
+	p := New(Config{Cache: m})
+	config := `
+settings:
+  name: count
+  repos: [https://github.com/org/project]
+collections:
+  - id: bugs
+    name: Bugs
+    rules: [bugs]
+  - id: quiet
+    name: Quiet
+    rules: [quiet]
+rules:
+  bugs:
+    name: Bugs
+    type: issue
+    filters:
+      - label: bug
+  quiet:
+    name: Quiet
+    type: issue
+    filters:
+      - label: bug
+      - responded: +1d
+`
+	assert.NoError(t, p.Load(strings.NewReader(config)))
+	ctx := context.Background()
+
+	bugs, err := p.LookupRule("bugs")
+	assert.NoError(t, err)
+	assert.True(t, hubbub.CountOnly(bugs.Filters))
+	urls, err := p.CountRule(ctx, provider.SearchParams{}, bugs)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{
+		"https://github.com/org/project/issues/1": true,
+		"https://github.com/org/project/issues/2": true,
+	}, urls)
+
+	// Responded requires comments, so the quiet collection falls back to a full execution
+	quiet, err := p.LookupRule("quiet")
+	assert.NoError(t, err)
+	assert.False(t, hubbub.CountOnly(quiet.Filters))
+
+	for _, id := range []string{"bugs", "quiet"} {
+		s, err := p.LookupCollection(id)
+		assert.NoError(t, err)
+
+		r, err := p.ExecuteCollection(ctx, s, time.Time{})
+		assert.NoError(t, err)
+		n, err := p.CountCollection(ctx, s, time.Time{})
+		assert.NoError(t, err)
+		assert.Equal(t, r.Total, n, "collection %q", id)
+		assert.Equal(t, 2, n, "collection %q", id)
+	}
+}
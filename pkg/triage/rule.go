@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"github.com/google/triage-party/pkg/provider"
 	"net/url"
+	"path"
 	"strings"
 	"time"
 
@@ -59,7 +60,8 @@ type RuleResult struct {
 	Created time.Time
 }
 
-// SummarizeRuleResult adds together statistics about a pool of conversations
+// SummarizeRuleResult adds together statistics about a pool of conversations. seen maps the URL of each item to the
+// first rule in the collection which matched it, as numbers are only unique within a repository.
 func SummarizeRuleResult(t Rule, cs []*hubbub.Conversation, seen map[string]*Rule) *RuleResult {
 
 	r := &RuleResult{
@@ -148,12 +150,55 @@ func (p *Party) ExecuteRule(ctx context.Context, sp provider.SearchParams, t Rul
 		}
 	}
 
+	// Transferred issues may appear in the stale results of their previous repository, and aliased repositories
+	// return the same items twice
+	if len(t.Repos) > 1 {
+		rcs = hubbub.DedupByURL(hubbub.MergeTransferred(rcs))
+	}
+
 	klog.V(1).Infof("rule %q matched %d items", t.ID, len(rcs))
 	rr := SummarizeRuleResult(t, rcs, seen)
 	rr.OldestInput = oldest
 	return rr, nil
 }
 
+// CountRule returns the URLs of items matching a rule, without fetching comments or events.
+// The rule filters must be compatible with hubbub.CountOnly.
+func (p *Party) CountRule(ctx context.Context, sp provider.SearchParams, t Rule) (map[string]bool, error) {
+	matched := map[string]bool{}
+
+	for _, repoUrl := range t.Repos {
+		r, err := parseRepo(repoUrl)
+		if err != nil {
+			return nil, err
+		}
+
+		var urls map[string]bool
+		sp.Repo = r
+		sp.Filters = t.Filters
+
+		switch t.Type {
+		case hubbub.Issue:
+			urls, _, err = p.engine.CountIssues(ctx, sp)
+		case hubbub.PullRequest:
+			urls, _, err = p.engine.CountPullRequests(ctx, sp)
+		default:
+			urls, _, err = p.engine.CountAny(ctx, sp)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for url := range urls {
+			matched[url] = true
+		}
+	}
+
+	klog.V(1).Infof("rule %q counted %d items", t.ID, len(matched))
+	return matched, nil
+}
+
 // Return a fully resolved rule
 func (p *Party) LookupRule(id string) (Rule, error) {
 	t, ok := p.rules[id]
@@ -168,6 +213,8 @@ func (p *Party) LookupRule(id string) (Rule, error) {
 	if len(t.Repos) == 0 {
 		t.Repos = p.settings.Repos
 	}
+
+	t.Repos = filterRepos(t.Repos, p.settings.RepoInclude, p.settings.RepoExclude)
 	return t, nil
 }
 
@@ -184,6 +231,47 @@ func (p *Party) ListRules() ([]Rule, error) {
 	return ts, nil
 }
 
+// filterRepos applies include and exclude globs, matched against "org/project" style repository paths
+func filterRepos(repos []string, include []string, exclude []string) []string {
+	if len(include) == 0 && len(exclude) == 0 {
+		return repos
+	}
+
+	matches := func(name string, globs []string) bool {
+		for _, g := range globs {
+			if ok, err := path.Match(g, name); err != nil {
+				klog.Errorf("bad repo glob %q: %v", g, err)
+			} else if ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	filtered := []string{}
+	for _, r := range repos {
+		u, err := url.Parse(r)
+		if err != nil {
+			klog.Errorf("unable to parse repo %q: %v", r, err)
+			continue
+		}
+		name := strings.Trim(u.Path, "/")
+
+		if len(include) > 0 && !matches(name, include) {
+			klog.V(1).Infof("skipping %s: not in repo_include", r)
+			continue
+		}
+
+		if matches(name, exclude) {
+			klog.V(1).Infof("skipping %s: in repo_exclude", r)
+			continue
+		}
+
+		filtered = append(filtered, r)
+	}
+	return filtered
+}
+
 // parseRepo returns provider, organization and project for a URL
 // rawURL should be a valid url with host like https://github.com/org/repo
 // or https://gitlab.com/org/repo
@@ -1,10 +1,25 @@
 package triage
 
 import (
+	"github.com/google/triage-party/pkg/hubbub"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
 
+func TestFilterRepos(t *testing.T) {
+	repos := []string{
+		"https://github.com/org/api",
+		"https://github.com/org/web",
+		"https://github.com/org/sandbox-1",
+		"https://github.com/other/api",
+	}
+
+	assert.Equal(t, repos, filterRepos(repos, nil, nil))
+	assert.Equal(t, repos[:3], filterRepos(repos, []string{"org/*"}, nil))
+	assert.Equal(t, []string{repos[0], repos[1], repos[3]}, filterRepos(repos, nil, []string{"*/sandbox-*"}))
+	assert.Equal(t, []string{repos[0]}, filterRepos(repos, []string{"org/*"}, []string{"org/web", "org/sandbox-*"}))
+}
+
 func TestParseRepo(t *testing.T) {
 	host := "github.com"
 	org := "org"
@@ -32,3 +47,16 @@ func TestParseRepo(t *testing.T) {
 	assert.Equal(t, repo, r.Project)
 	assert.Equal(t, group, r.Group)
 }
+
+func TestSummarizeRuleResultSameNumber(t *testing.T) {
+	api := &hubbub.Conversation{ID: 12, URL: "https://github.com/org/api/issues/12"}
+	web := &hubbub.Conversation{ID: 12, URL: "https://github.com/org/web/issues/12"}
+	seen := map[string]*Rule{}
+
+	r := SummarizeRuleResult(Rule{ID: "first"}, []*hubbub.Conversation{api}, seen)
+	assert.Len(t, r.Items, 1)
+
+	r = SummarizeRuleResult(Rule{ID: "second"}, []*hubbub.Conversation{web, api}, seen)
+	assert.Len(t, r.Items, 2)
+	assert.Equal(t, map[string]bool{api.URL: true}, r.Duplicates)
+}
@@ -19,10 +19,15 @@ import (
 	"github.com/google/triage-party/pkg/provider"
 	"io"
 	"io/ioutil"
+	"regexp"
+	"sort"
 	"time"
 
+	"github.com/google/triage-party/pkg/calendar"
+	"github.com/google/triage-party/pkg/constants"
 	"github.com/google/triage-party/pkg/hubbub"
 	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/reply"
 	"gopkg.in/yaml.v2"
 	"k8s.io/klog/v2"
 )
@@ -36,6 +41,7 @@ type Config struct {
 
 type Party struct {
 	engine        *hubbub.Engine
+	replies       *reply.Renderer
 	settings      Settings
 	collections   []Collection
 	cache         persist.Cacher
@@ -62,11 +68,205 @@ func New(cfg Config) *Party {
 }
 
 type Settings struct {
-	Name          string   `yaml:"name"`
-	Repos         []string `yaml:"repos"`
-	MinSimilarity float64  `yaml:"min_similarity"`
-	MemberRoles   []string `yaml:"member-roles"`
-	Members       []string `yaml:"members"`
+	Name              string   `yaml:"name"`
+	Repos             []string `yaml:"repos"`
+	MinSimilarity     float64  `yaml:"min_similarity"`
+	MemberRoles       []string `yaml:"member-roles"`
+	Members           []string `yaml:"members"`
+	Bots              []string `yaml:"bots"`
+	MaxTimelineEvents int      `yaml:"max_timeline_events"`
+	CommentLimit      int      `yaml:"comment_limit"`
+	MaxRelatedRefs    int      `yaml:"max_related_refs"`
+	RepoInclude       []string `yaml:"repo_include"`
+	RepoExclude       []string `yaml:"repo_exclude"`
+	BountyRegex       string   `yaml:"bounty_regex"`
+	BountyAuthor      string   `yaml:"bounty_author"`
+
+	// Exclude lists items to drop from every collection, such as pinned discussion issues
+	Exclude []string `yaml:"exclude"`
+
+	// RepoAliases maps the previous names of renamed or moved repositories to their current ones, as org/project
+	RepoAliases map[string]string `yaml:"repo_aliases"`
+
+	// HotCollections are collection IDs to load before serving, most important first
+	HotCollections []string `yaml:"hot_collections"`
+
+	// ExclusiveLabels maps a group name to a regex of labels, of which an item should have at most one
+	ExclusiveLabels map[string]string `yaml:"exclusive_labels"`
+
+	HoldFromReadyForReview bool `yaml:"hold_from_ready_for_review"`
+	CountPendingReviews    bool `yaml:"count_pending_reviews"`
+	PreferMemberList       bool `yaml:"prefer_member_list"`
+	IssuesIncludePRs       bool `yaml:"issues_include_prs"`
+
+	CollaboratorsAreMembers *bool `yaml:"collaborators_are_members"`
+
+	BusinessHours BusinessHours `yaml:"business_hours"`
+	AgeTags       AgeTags       `yaml:"age_tags"`
+	FirstResponse FirstResponse `yaml:"first_response"`
+	RecvQ         RecvQ         `yaml:"recv_q"`
+	Replies       Replies       `yaml:"replies"`
+	Attention     Attention     `yaml:"attention"`
+	Escalation    Escalation    `yaml:"escalation"`
+
+	// Projects are the project boards which project filters may refer to
+	Projects []Project `yaml:"projects"`
+
+	// LinkCheck limits how external links are checked by the dead-links filter
+	LinkCheck LinkCheck `yaml:"link_check"`
+}
+
+// LinkCheck limits the cost of checking external links, which is only done for rules with a dead-links filter
+type LinkCheck struct {
+	Timeout   string  `yaml:"timeout"`
+	MaxLinks  int     `yaml:"max_links"`
+	PerSecond float64 `yaml:"per_second"`
+}
+
+// timeout returns the parsed per-link timeout, where 0 (the default) uses hubbub.DefaultLinkTimeout
+func (l LinkCheck) timeout() (time.Duration, error) {
+	if l.MaxLinks < 0 {
+		return 0, fmt.Errorf("max_links must not be negative: %d", l.MaxLinks)
+	}
+	if l.PerSecond < 0 {
+		return 0, fmt.Errorf("per_second must not be negative: %v", l.PerSecond)
+	}
+	return parseAge(l.Timeout, "0")
+}
+
+// Project is a GitHub project board (Projects v2), referred to by filters such as "project: Roadmap=In Progress"
+type Project struct {
+	Name   string `yaml:"name"`
+	Owner  string `yaml:"owner"`
+	Number int    `yaml:"number"`
+	Field  string `yaml:"field"`
+}
+
+// Escalation is a rule for tagging items as escalated, made of conditions such as "reactions >= 10"
+type Escalation struct {
+	// All must hold for an item to be escalated
+	All []string `yaml:"all"`
+	// Any of these must also hold, if set
+	Any []string `yaml:"any"`
+}
+
+func (e Escalation) rule() (*hubbub.EscalationRule, error) {
+	return hubbub.ParseEscalationRule(e.All, e.Any)
+}
+
+// Attention weighs the inputs to each item's attention score, which collections may be sorted by
+type Attention struct {
+	Hold      *float64 `yaml:"hold"`
+	Reactions *float64 `yaml:"reactions"`
+	Recency   *float64 `yaml:"recency"`
+	RecvQ     *float64 `yaml:"recv_q"`
+}
+
+// weights returns the configured weights, using hubbub.DefaultAttentionWeights for any which are unset
+func (a Attention) weights() (hubbub.AttentionWeights, error) {
+	w := hubbub.DefaultAttentionWeights
+	for name, v := range map[string]struct {
+		in  *float64
+		out *float64
+	}{
+		"hold":      {a.Hold, &w.Hold},
+		"reactions": {a.Reactions, &w.Reactions},
+		"recency":   {a.Recency, &w.Recency},
+		"recv_q":    {a.RecvQ, &w.RecvQ},
+	} {
+		if v.in == nil {
+			continue
+		}
+		if *v.in < 0 {
+			return w, fmt.Errorf("%s must not be negative: %v", name, *v.in)
+		}
+		*v.out = *v.in
+	}
+	return w, nil
+}
+
+// Replies are canned responses, such as asking the author for more information
+type Replies struct {
+	// Links may be referred to by every template, such as {{.Links.contributing}}
+	Links     map[string]string         `yaml:"links"`
+	Templates map[string]reply.Template `yaml:"templates"`
+}
+
+func (r Replies) renderer() (*reply.Renderer, error) {
+	return reply.New(r.Templates, r.Links)
+}
+
+// FirstResponse is the service level for how quickly a member first responds to an item
+type FirstResponse struct {
+	SLA                 string `yaml:"sla"`
+	IgnoreSelfInflicted bool   `yaml:"ignore_self_inflicted"`
+}
+
+// sla returns the parsed threshold, where 0 (the default) disables the first-response-breached tag
+func (f FirstResponse) sla() (time.Duration, error) {
+	return parseAge(f.SLA, "0")
+}
+
+// RecvQ limits which questions tag an item as recv-q, so that old questions in long threads are ignored
+type RecvQ struct {
+	Comments int    `yaml:"comments"`
+	Within   string `yaml:"within"`
+}
+
+// within returns the parsed recency window, where 0 (the default) considers questions of any age
+func (r RecvQ) within() (time.Duration, error) {
+	if r.Comments < 0 {
+		return 0, fmt.Errorf("comments must not be negative: %d", r.Comments)
+	}
+	return parseAge(r.Within, "0")
+}
+
+// AgeTags are how long an item must be open to be tagged as old or ancient. Neither tag is applied unless set.
+type AgeTags struct {
+	Old     string `yaml:"old"`
+	Ancient string `yaml:"ancient"`
+}
+
+// durations returns the parsed age tag thresholds, where 0 (the default) disables a tag
+func (a AgeTags) durations() (time.Duration, time.Duration, error) {
+	old, err := parseAge(a.Old, "0")
+	if err != nil {
+		return 0, 0, fmt.Errorf("old: %w", err)
+	}
+	ancient, err := parseAge(a.Ancient, "0")
+	if err != nil {
+		return 0, 0, fmt.Errorf("ancient: %w", err)
+	}
+	if old > 0 && ancient > 0 && ancient <= old {
+		return 0, 0, fmt.Errorf("ancient (%s) must be longer than old (%s)", ancient, old)
+	}
+	return old, ancient, nil
+}
+
+func parseAge(s string, def string) (time.Duration, error) {
+	if s == "" {
+		s = def
+	}
+	if s == "0" {
+		return 0, nil
+	}
+	d, within, over := hubbub.ParseDuration(s)
+	if d <= 0 || within || over {
+		return 0, fmt.Errorf("invalid duration: %q", s)
+	}
+	return d, nil
+}
+
+// BusinessHours is the calendar used by filters which only count business time
+type BusinessHours struct {
+	Timezone string   `yaml:"timezone"`
+	Days     []string `yaml:"days"`
+	Start    string   `yaml:"start"`
+	End      string   `yaml:"end"`
+}
+
+func (b BusinessHours) calendar() (*calendar.Calendar, error) {
+	return calendar.New(b.Timezone, b.Days, b.Start, b.End)
 }
 
 // diskConfig is the on-disk configuration
@@ -97,16 +297,90 @@ func (p *Party) newEngine() *hubbub.Engine {
 		}
 	}
 
+	// Collections may default to including closed items for rules without a state filter
+	for _, c := range p.collections {
+		if c.DefaultState == "" {
+			continue
+		}
+		for _, tid := range c.RuleIDs {
+			r, ok := p.rules[tid]
+			if !ok {
+				continue
+			}
+			ca := closedAge(withDefaultState(r.Filters, c.DefaultState))
+			if ca > maxClosedUpdateAge {
+				maxClosedUpdateAge = ca
+			}
+		}
+	}
+
 	hc := hubbub.Config{
 		Cache:              p.cache,
 		Repos:              p.reposOverride,
 		DebugNumbers:       p.debug,
+		Exclude:            p.settings.Exclude,
 		MaxClosedUpdateAge: maxClosedUpdateAge,
 		MinSimilarity:      p.settings.MinSimilarity,
 		MemberRoles:        roles,
 		Members:            p.settings.Members,
+		Bots:               p.settings.Bots,
+		MaxTimelineEvents:  p.settings.MaxTimelineEvents,
+		CommentLimit:       p.settings.CommentLimit,
+		MaxRelatedRefs:     p.settings.MaxRelatedRefs,
+
+		HoldFromReadyForReview: p.settings.HoldFromReadyForReview,
+		CountPendingReviews:    p.settings.CountPendingReviews,
+		PreferMemberList:       p.settings.PreferMemberList,
+		IssuesIncludePRs:       p.settings.IssuesIncludePRs,
+		BountyAuthor:           p.settings.BountyAuthor,
+
+		CollaboratorsAreMembers: p.settings.CollaboratorsAreMembers,
+		RepoAliases:             p.settings.RepoAliases,
 	}
 
+	// Validated by validateLoadedConfig
+	if p.settings.BountyRegex != "" {
+		hc.BountyRegex = regexp.MustCompile(p.settings.BountyRegex)
+	}
+
+	// Validated by validateLoadedConfig
+	if len(p.settings.ExclusiveLabels) > 0 {
+		hc.ExclusiveLabels = map[string]*regexp.Regexp{}
+		for name, re := range p.settings.ExclusiveLabels {
+			hc.ExclusiveLabels[name] = regexp.MustCompile(re)
+		}
+	}
+
+	// Validated by validateLoadedConfig
+	hc.Calendar, _ = p.settings.BusinessHours.calendar()
+
+	// Validated by validateLoadedConfig
+	hc.OldAge, hc.AncientAge, _ = p.settings.AgeTags.durations()
+
+	// Validated by validateLoadedConfig
+	hc.FirstResponseSLA, _ = p.settings.FirstResponse.sla()
+	hc.FirstResponseIgnoreSelfInflicted = p.settings.FirstResponse.IgnoreSelfInflicted
+
+	// Validated by validateLoadedConfig
+	hc.RecvQWithin, _ = p.settings.RecvQ.within()
+	hc.RecvQComments = p.settings.RecvQ.Comments
+
+	// Validated by validateLoadedConfig
+	attention, _ := p.settings.Attention.weights()
+	hc.Attention = &attention
+
+	// Validated by validateLoadedConfig
+	hc.Escalation, _ = p.settings.Escalation.rule()
+
+	for _, pr := range p.settings.Projects {
+		hc.Projects = append(hc.Projects, provider.Board{Name: pr.Name, Owner: pr.Owner, Number: pr.Number, Field: pr.Field})
+	}
+
+	// Validated by validateLoadedConfig
+	hc.LinkTimeout, _ = p.settings.LinkCheck.timeout()
+	hc.MaxLinks = p.settings.LinkCheck.MaxLinks
+	hc.LinksPerSecond = p.settings.LinkCheck.PerSecond
+
 	klog.Infof("New hubbub with config: %+v", hc)
 	return hubbub.New(hc)
 }
@@ -148,9 +422,22 @@ func (p *Party) Load(r io.Reader) error {
 		return fmt.Errorf("validate config: %w", err)
 	}
 	p.engine = p.newEngine()
+
+	// Validated by validateLoadedConfig
+	p.replies, _ = p.settings.Replies.renderer()
 	return nil
 }
 
+// withDefaultState returns filters with a state filter appended, unless one is already present
+func withDefaultState(fs []provider.Filter, state string) []provider.Filter {
+	for _, f := range fs {
+		if f.State != "" {
+			return fs
+		}
+	}
+	return append(append([]provider.Filter{}, fs...), provider.Filter{State: state})
+}
+
 // closedAge returns how old we need to look back for a set of filters
 func closedAge(fs []provider.Filter) time.Duration {
 	oldest := time.Duration(0)
@@ -159,6 +446,13 @@ func closedAge(fs []provider.Filter) time.Duration {
 	}
 
 	for _, f := range fs {
+		if f.ClosedWithin != "" {
+			d, _, _ := hubbub.ParseDuration(f.ClosedWithin)
+			if d > oldest {
+				oldest = d
+			}
+		}
+
 		for _, fd := range []string{f.Created, f.Updated, f.Closed, f.Responded} {
 			if fd == "" {
 				continue
@@ -187,6 +481,74 @@ func (p *Party) validateLoadedConfig() error {
 	if len(p.collections) == 0 {
 		return fmt.Errorf("no 'collections' defined")
 	}
+	if p.settings.BountyRegex != "" {
+		re, err := regexp.Compile(p.settings.BountyRegex)
+		if err != nil {
+			return fmt.Errorf("bounty_regex: %w", err)
+		}
+		if re.NumSubexp() < 1 {
+			return fmt.Errorf("bounty_regex must capture the amount: %q", p.settings.BountyRegex)
+		}
+	}
+	for _, e := range p.settings.Exclude {
+		if _, err := hubbub.ParseExclude(e); err != nil {
+			return fmt.Errorf("exclude: %w", err)
+		}
+	}
+	if _, err := hubbub.ParseRepoAliases(p.settings.RepoAliases); err != nil {
+		return fmt.Errorf("repo_aliases: %w", err)
+	}
+	for name, re := range p.settings.ExclusiveLabels {
+		if _, err := regexp.Compile(re); err != nil {
+			return fmt.Errorf("exclusive_labels %q: %w", name, err)
+		}
+	}
+	if _, err := p.settings.BusinessHours.calendar(); err != nil {
+		return fmt.Errorf("business_hours: %w", err)
+	}
+	if _, _, err := p.settings.AgeTags.durations(); err != nil {
+		return fmt.Errorf("age_tags: %w", err)
+	}
+	if _, err := p.settings.FirstResponse.sla(); err != nil {
+		return fmt.Errorf("first_response: %w", err)
+	}
+	if _, err := p.settings.RecvQ.within(); err != nil {
+		return fmt.Errorf("recv_q: %w", err)
+	}
+	if _, err := p.settings.Attention.weights(); err != nil {
+		return fmt.Errorf("attention: %w", err)
+	}
+	if _, err := p.settings.Escalation.rule(); err != nil {
+		return fmt.Errorf("escalation: %w", err)
+	}
+	boards := map[string]bool{}
+	for _, pr := range p.settings.Projects {
+		if pr.Name == "" || pr.Owner == "" || pr.Number <= 0 {
+			return fmt.Errorf("projects: %+v needs a name, owner, and number", pr)
+		}
+		if boards[pr.Name] {
+			return fmt.Errorf("projects: %q is defined twice", pr.Name)
+		}
+		boards[pr.Name] = true
+	}
+	for id, r := range p.rules {
+		for _, f := range r.Filters {
+			if f.Project == "" {
+				continue
+			}
+			// Syntax is checked by processRules
+			name, _, _, _ := hubbub.ParseProjectFilter(f.Project)
+			if !boards[name] {
+				return fmt.Errorf("%q project: %q is not in projects", id, name)
+			}
+		}
+	}
+	if _, err := p.settings.LinkCheck.timeout(); err != nil {
+		return fmt.Errorf("link_check: %w", err)
+	}
+	if _, err := p.settings.Replies.renderer(); err != nil {
+		return fmt.Errorf("replies: %w", err)
+	}
 	if len(p.rules) == 0 {
 		return fmt.Errorf("no 'rules' defined")
 	}
@@ -196,8 +558,45 @@ func (p *Party) validateLoadedConfig() error {
 		return fmt.Errorf("list collections: %w", err)
 	}
 
+	seenHot := map[string]bool{}
+	for _, id := range p.settings.HotCollections {
+		if seenHot[id] {
+			return fmt.Errorf("hot_collections has a duplicate: %q", id)
+		}
+		seenHot[id] = true
+
+		if _, err := p.LookupCollection(id); err != nil {
+			return fmt.Errorf("hot_collections: %w", err)
+		}
+	}
+
 	filters := 0
 	for _, c := range cols {
+		switch c.DefaultState {
+		case "", constants.OpenState, constants.ClosedState, constants.AllState:
+		default:
+			return fmt.Errorf("%q has an invalid default_state: %q (want open, closed, or all)", c.ID, c.DefaultState)
+		}
+
+		if c.StaleAfter != "" {
+			if _, err := parseStaleAfter(c.StaleAfter); err != nil {
+				return fmt.Errorf("%q stale_after: %w", c.ID, err)
+			}
+		}
+
+		if c.PerPage < 0 || c.PerPage > hubbub.MaxPerPage {
+			return fmt.Errorf("%q has an invalid per_page: %d (want 1-%d)", c.ID, c.PerPage, hubbub.MaxPerPage)
+		}
+		if c.MaxPages < 0 {
+			return fmt.Errorf("%q has an invalid max_pages: %d", c.ID, c.MaxPages)
+		}
+
+		switch c.Sort {
+		case "", SortAttention:
+		default:
+			return fmt.Errorf("%q has an invalid sort: %q (want %s)", c.ID, c.Sort, SortAttention)
+		}
+
 		seenRule := map[string]*Rule{}
 
 		for _, tid := range c.RuleIDs {
@@ -219,6 +618,25 @@ func (p *Party) validateLoadedConfig() error {
 		return fmt.Errorf("No 'filters' found in the configuration")
 	}
 
+	rules, err := p.ListRules()
+	if err != nil {
+		return fmt.Errorf("list rules: %w", err)
+	}
+
+	repos := map[string]bool{}
+	for _, r := range rules {
+		for _, repo := range r.Repos {
+			repos[repo] = true
+		}
+	}
+
+	resolved := []string{}
+	for r := range repos {
+		resolved = append(resolved, r)
+	}
+	sort.Strings(resolved)
+	klog.Infof("resolved repositories to scan: %v", resolved)
+
 	klog.Infof("configuration defines %d filters - looking good!", filters)
 	return nil
 }
@@ -280,6 +698,52 @@ func processRules(raw map[string]Rule) (map[string]Rule, error) {
 				}
 			}
 
+			if f.RawAuthor != "" {
+				err := f.LoadAuthorRegex()
+				if err != nil {
+					return rules, fmt.Errorf("%q author: %w", id, err)
+				}
+			}
+
+			if f.RawIssueType != "" {
+				err := f.LoadIssueTypeRegex()
+				if err != nil {
+					return rules, fmt.Errorf("%q issue type: %w", id, err)
+				}
+			}
+
+			if f.RawAttachment != "" {
+				err := f.LoadAttachmentRegex()
+				if err != nil {
+					return rules, fmt.Errorf("%q attachment regex: %w", id, err)
+				}
+			}
+
+			if f.RawLastComment != "" {
+				err := f.LoadLastCommentRegex()
+				if err != nil {
+					return rules, fmt.Errorf("%q last comment: %w", id, err)
+				}
+			}
+
+			if f.LabeledWithin != "" {
+				if _, _, err := hubbub.ParseLabeledWithin(f.LabeledWithin); err != nil {
+					return rules, fmt.Errorf("%q labeled-within: %w", id, err)
+				}
+			}
+
+			if f.BallIn != "" {
+				if err := hubbub.ValidBallIn(f.BallIn); err != nil {
+					return rules, fmt.Errorf("%q ball-in: %w", id, err)
+				}
+			}
+
+			if f.Project != "" {
+				if _, _, _, err := hubbub.ParseProjectFilter(f.Project); err != nil {
+					return rules, fmt.Errorf("%q project: %w", id, err)
+				}
+			}
+
 			newfs = append(newfs, f)
 		}
 
@@ -300,3 +764,53 @@ func processRules(raw map[string]Rule) (map[string]Rule, error) {
 func (p *Party) ConversationsTotal() int {
 	return p.engine.ConversationsTotal()
 }
+
+// UpdateSimilar incrementally updates the similarity index from refreshed conversations
+func (p *Party) UpdateSimilar(cs []*hubbub.Conversation, gone []string) {
+	p.engine.UpdateSimilar(cs, gone)
+}
+
+// SimilarityStatus returns the size of the similarity index, and when it was last updated
+func (p *Party) SimilarityStatus() (int, time.Time) {
+	// Status may be requested before the configuration is loaded
+	if p.engine == nil {
+		return -1, time.Time{}
+	}
+	return p.engine.SimilarityStatus()
+}
+
+// HotCollections returns the IDs of collections to load before serving, most important first
+func (p *Party) HotCollections() []string {
+	return p.settings.HotCollections
+}
+
+// ReplyNames returns the names of the configured canned responses
+func (p *Party) ReplyNames() []string {
+	if p.replies == nil {
+		return nil
+	}
+	return p.replies.Names()
+}
+
+// RenderReply renders a canned response to a conversation, given the item's description. Posting it is up to the caller.
+func (p *Party) RenderReply(name string, co *hubbub.Conversation, body string) (string, error) {
+	if p.replies == nil {
+		return "", fmt.Errorf("configuration not loaded")
+	}
+	return p.replies.Render(name, co, body)
+}
+
+// SetExclude replaces the items dropped from every collection, without reloading the configuration. Results
+// reflect the new list once collections are next refreshed.
+func (p *Party) SetExclude(entries []string) error {
+	if err := p.engine.SetExclude(entries); err != nil {
+		return err
+	}
+	p.settings.Exclude = entries
+	return nil
+}
+
+// InvalidateItem clears cached data for a single issue or PR, such as after acting on it
+func (p *Party) InvalidateItem(org string, project string, num int) {
+	p.engine.InvalidateItem(org, project, num)
+}
@@ -0,0 +1,205 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package updater
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/logu"
+	"github.com/google/triage-party/pkg/tag"
+	"github.com/google/triage-party/pkg/triage"
+	"k8s.io/klog/v2"
+)
+
+// Diff describes how a collection result changed between two refreshes
+type Diff struct {
+	// Previous and Current are the creation times of the compared results
+	Previous time.Time
+	Current  time.Time
+
+	// Each list is sorted by URL
+	Added   []*hubbub.Conversation
+	Removed []*hubbub.Conversation
+	// TagsChanged are items present in both results, but with a different set of tags
+	TagsChanged []*hubbub.Conversation
+}
+
+// ChangeFunc is notified after a collection is refreshed, with how its result changed, such as to alert when a new
+// item appears. Filter by s.ID for per-collection handling.
+type ChangeFunc func(s triage.Collection, d *Diff)
+
+// Empty returns whether nothing changed
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.TagsChanged) == 0
+}
+
+// Diff returns the changes between the previous and current result for a collection. It is not used by the server,
+// which is notified of changes via OnChange, but is available to library callers. It does not wait for a refresh
+// in progress.
+func (u *Updater) Diff(id string) *Diff {
+	u.cacheMu.RLock()
+	prev, cur := u.previous[id], u.cache[id]
+	u.cacheMu.RUnlock()
+
+	if cur == nil {
+		return nil
+	}
+	return diffResults(prev, cur)
+}
+
+// diffResults compares two collection results, keyed by URL
+func diffResults(prev *triage.CollectionResult, cur *triage.CollectionResult) *Diff {
+	d := &Diff{Current: cur.Created}
+
+	before := map[string]*hubbub.Conversation{}
+	if prev != nil {
+		d.Previous = prev.Created
+		before = itemsByURL(prev)
+	}
+	after := itemsByURL(cur)
+
+	for url, co := range after {
+		old, ok := before[url]
+		if !ok {
+			d.Added = append(d.Added, co)
+			continue
+		}
+		if !sameTags(old.Tags, co.Tags) {
+			d.TagsChanged = append(d.TagsChanged, co)
+		}
+	}
+
+	for url, co := range before {
+		if _, ok := after[url]; !ok {
+			d.Removed = append(d.Removed, co)
+		}
+	}
+
+	// Map iteration order is random
+	for _, cs := range [][]*hubbub.Conversation{d.Added, d.Removed, d.TagsChanged} {
+		sortByURL(cs)
+	}
+	return d
+}
+
+func sortByURL(cs []*hubbub.Conversation) {
+	sort.Slice(cs, func(i, j int) bool { return cs[i].URL < cs[j].URL })
+}
+
+func itemsByURL(r *triage.CollectionResult) map[string]*hubbub.Conversation {
+	items := map[string]*hubbub.Conversation{}
+	for _, rr := range r.RuleResults {
+		for _, co := range rr.Items {
+			items[co.URL] = co
+		}
+	}
+	return items
+}
+
+func sameTags(a map[tag.Tag]bool, b map[tag.Tag]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for t := range a {
+		if !b[t] {
+			return false
+		}
+	}
+	return true
+}
+
+// changes returns the changes between a collection's previous and refreshed results, or nil if there was no previous
+// result, as every item would otherwise be reported as added after a restart, or if nothing changed.
+func changes(prev *triage.CollectionResult, cur *triage.CollectionResult) *Diff {
+	if prev == nil {
+		return nil
+	}
+	d := diffResults(prev, cur)
+	if d.Empty() {
+		return nil
+	}
+	return d
+}
+
+// notification is a diff waiting to be delivered to the change hooks
+type notification struct {
+	s    triage.Collection
+	d    *Diff
+	done chan struct{}
+}
+
+// deliver queues a diff for the change hooks of its collection. In synchronous mode, it waits up to notifyTimeout
+// for them to return.
+func (u *Updater) deliver(s triage.Collection, d *Diff) {
+	n := &notification{s: s, d: d, done: make(chan struct{})}
+	q := u.notifyQueue(s.ID)
+
+	if u.notifyTimeout <= 0 {
+		select {
+		case q <- n:
+		default:
+			klog.Warningf("%s notification queue is full, dropping diff of %s", s.ID, logu.STime(d.Current))
+		}
+		return
+	}
+
+	timer := time.NewTimer(u.notifyTimeout)
+	defer timer.Stop()
+
+	select {
+	case q <- n:
+	case <-timer.C:
+		klog.Warningf("%s notification queue is still full after %s, dropping diff of %s", s.ID, u.notifyTimeout, logu.STime(d.Current))
+		return
+	}
+
+	select {
+	case <-n.done:
+	case <-timer.C:
+		klog.Warningf("%s change hooks did not return within %s, continuing", s.ID, u.notifyTimeout)
+	}
+}
+
+// notifyQueue returns the delivery queue for a collection, starting its worker if necessary. A single worker per
+// collection is what keeps its diffs in order, and bounds the number of hooks running at once.
+func (u *Updater) notifyQueue(id string) chan *notification {
+	u.notifyMu.Lock()
+	defer u.notifyMu.Unlock()
+
+	q := u.notifyQueues[id]
+	if q != nil {
+		return q
+	}
+
+	q = make(chan *notification, u.notifyQueueSize)
+	u.notifyQueues[id] = q
+	go func() {
+		for n := range q {
+			u.notify(n.s, n.d)
+			close(n.done)
+		}
+	}()
+	return q
+}
+
+// notify calls each change hook in order
+func (u *Updater) notify(s triage.Collection, d *Diff) {
+	klog.Infof("%s changed: %d added, %d removed, %d with changed tags", s.ID, len(d.Added), len(d.Removed), len(d.TagsChanged))
+	for _, f := range u.onChange {
+		f(s, d)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package updater
+
+import (
+	"math"
+	"time"
+)
+
+// Default number of accesses to each collection remembered for weighing its popularity
+const defaultAccessHistory = 2
+
+// DecayFunc weighs an access to a collection by how long ago it was, where 1 is full weight
+type DecayFunc func(age time.Duration) float64
+
+// NoDecay weighs every remembered access equally
+func NoDecay(time.Duration) float64 {
+	return 1
+}
+
+// ExponentialDecay halves the weight of an access for every halfLife since it happened
+func ExponentialDecay(halfLife time.Duration) DecayFunc {
+	return func(age time.Duration) float64 {
+		return math.Exp2(-float64(age) / float64(halfLife))
+	}
+}
+
+// accessInterval estimates how often a collection is viewed, as the decay-weighted mean of the intervals between
+// the accesses in its history (oldest first) and since the latest. If the history holds fewer than size accesses,
+// start stands in for the ones before it. With a size of 2 and no decay, this is the mean of the time since the
+// latest access and the time between the last two.
+func accessInterval(history []time.Time, size int, now time.Time, start time.Time, decay DecayFunc) time.Duration {
+	points := history
+	if len(points) < size {
+		points = append([]time.Time{start}, points...)
+	}
+	points = append(points[:len(points):len(points)], now)
+
+	var sum, weights float64
+	for i := 1; i < len(points); i++ {
+		// An interval is as old as the access which ended it
+		w := decay(now.Sub(points[i]))
+		sum += w * float64(points[i].Sub(points[i-1]))
+		weights += w
+	}
+	if weights == 0 {
+		return 0
+	}
+	return time.Duration(sum / weights)
+}
+
+// recordAccess records stats on collection accesses
+func (u *Updater) recordAccess(id string) {
+	u.accessMu.Lock()
+	defer u.accessMu.Unlock()
+
+	history := u.accessHistory(id)
+	if len(history) >= u.historySize {
+		history = history[len(history)-u.historySize+1:]
+	}
+
+	// Copied, as readers may hold the previous slice
+	h := make([]time.Time, len(history), len(history)+1)
+	copy(h, history)
+	u.requests.Store(id, append(h, time.Now()))
+}
+
+// accessHistory returns the most recent accesses to a collection, oldest first
+func (u *Updater) accessHistory(id string) []time.Time {
+	x, ok := u.requests.Load(id)
+	if !ok {
+		return nil
+	}
+
+	h, ok := x.([]time.Time)
+	if !ok {
+		return nil
+	}
+
+	return h
+}
+
+// lastRequested is the last time someone requested to view a collection
+func (u *Updater) lastRequested(id string) time.Time {
+	h := u.accessHistory(id)
+	if len(h) == 0 {
+		return time.Time{}
+	}
+	return h[len(h)-1]
+}
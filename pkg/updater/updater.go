@@ -23,6 +23,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/triage-party/pkg/hubbub"
 	"github.com/google/triage-party/pkg/logu"
 	"github.com/google/triage-party/pkg/triage"
 
@@ -32,6 +33,25 @@ import (
 // Minimum age to flush to avoid bad behavior
 const minFlushAge = 5 * time.Second
 
+// Upper bound on the loop delay after consecutive failures
+const maxBackoff = 5 * time.Minute
+
+// Default fraction of MaxRefresh used to fuzz the persist cutoff
+const defaultPersistFuzz = 1.0
+
+const (
+	// Default number of times a failed persist is retried before giving up until the next persist
+	defaultPersistRetries = 3
+	// Default delay before the first retry of a failed persist, doubling for each retry after
+	defaultPersistRetryDelay = 2 * time.Second
+	// Default number of consecutive failed persists which open the circuit breaker
+	defaultBreakerThreshold = 3
+	// Default time the circuit breaker stays open before the backend is tried again
+	defaultBreakerCooldown = 10 * time.Minute
+	// Default number of diffs per collection which may wait for delivery to the change hooks
+	defaultNotifyQueue = 16
+)
+
 type PFunc = func() error
 
 type Config struct {
@@ -39,21 +59,117 @@ type Config struct {
 	MinRefresh  time.Duration
 	MaxRefresh  time.Duration
 	PersistFunc PFunc
+
+	// PersistFuzz is the fraction of MaxRefresh to randomly add to the persist cutoff (default: 1.0)
+	PersistFuzz float64
+	// DisablePersistFuzz persists at the cutoff, without a random delay
+	DisablePersistFuzz bool
+
+	// PersistRetries is how many times a failed persist is retried, with exponential backoff (default: 3, -1 for none)
+	PersistRetries int
+	// PersistRetryDelay is the delay before the first retry, doubling for each retry after (default: 2s)
+	PersistRetryDelay time.Duration
+	// BreakerThreshold is how many consecutive failed persists open the circuit breaker (default: 3). While open,
+	// the backend is not tried, and PersistFallback is called instead.
+	BreakerThreshold int
+	// BreakerCooldown is how long the circuit breaker stays open before the backend is tried again (default: 10m)
+	BreakerCooldown time.Duration
+	// PersistFallback is called in place of PersistFunc while the circuit breaker is open, such as to save a
+	// snapshot to local disk (optional)
+	PersistFallback PFunc
+	// AccessHistory is how many of the latest accesses to each collection are remembered for weighing how
+	// popular it is, and so how often it is refreshed (default: 2)
+	AccessHistory int
+	// Decay weighs each remembered access by how long ago it was, so that recent and frequent views count for
+	// more than a burst of views long ago (default: NoDecay)
+	Decay DecayFunc
+
+	// Rand is the random source used for fuzzing and backoff jitter (default: seeded by the current time)
+	Rand *rand.Rand
+
+	// OnChange are called in order when a refreshed collection result differs from the previous one. The first
+	// result after startup is not reported. Each collection has its own delivery queue: its diffs are delivered one
+	// at a time, in the order that the refreshes happened, and at most once.
+	OnChange []ChangeFunc
+	// NotifyTimeout makes delivery synchronous: each refresh waits up to this long for OnChange to return before
+	// continuing, after which the hooks finish in the background. If unset, refreshes do not wait.
+	NotifyTimeout time.Duration
+	// NotifyQueue is how many diffs per collection may wait for delivery. Once full, new diffs are dropped with a
+	// warning (default: 16).
+	NotifyQueue int
 }
 
 func New(cfg Config) *Updater {
+	fuzz := cfg.PersistFuzz
+	if fuzz <= 0 {
+		fuzz = defaultPersistFuzz
+	}
+	if cfg.DisablePersistFuzz {
+		fuzz = 0
+	}
+
+	rnd := cfg.Rand
+	if rnd == nil {
+		rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	retries := cfg.PersistRetries
+	if retries == 0 {
+		retries = defaultPersistRetries
+	}
+	if retries < 0 {
+		retries = 0
+	}
+	retryDelay := cfg.PersistRetryDelay
+	if retryDelay <= 0 {
+		retryDelay = defaultPersistRetryDelay
+	}
+	threshold := cfg.BreakerThreshold
+	if threshold <= 0 {
+		threshold = defaultBreakerThreshold
+	}
+	cooldown := cfg.BreakerCooldown
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+	historySize := cfg.AccessHistory
+	if historySize <= 0 {
+		historySize = defaultAccessHistory
+	}
+	decay := cfg.Decay
+	if decay == nil {
+		decay = NoDecay
+	}
+	notifyQueue := cfg.NotifyQueue
+	if notifyQueue <= 0 {
+		notifyQueue = defaultNotifyQueue
+	}
+
 	return &Updater{
 		party:             cfg.Party,
 		maxRefresh:        cfg.MaxRefresh,
 		minRefresh:        cfg.MinRefresh,
 		idleDuration:      5 * time.Minute,
 		cache:             map[string]*triage.CollectionResult{},
-		lastRequest:       sync.Map{},
-		secondLastRequest: sync.Map{},
+		previous:          map[string]*triage.CollectionResult{},
+		requests:          sync.Map{},
+		historySize:       historySize,
+		decay:             decay,
 		loopEvery:         250 * time.Millisecond,
 		mutex:             &sync.Mutex{},
 		persistFunc:       cfg.PersistFunc,
+		persistFuzz:       fuzz,
+		persistRetries:    retries,
+		persistRetryDelay: retryDelay,
+		breakerThreshold:  threshold,
+		breakerCooldown:   cooldown,
+		persistFallback:   cfg.PersistFallback,
+		rand:              rnd,
 		startTime:         time.Time{},
+		onChange:          cfg.OnChange,
+		notifyTimeout:     cfg.NotifyTimeout,
+		notifyQueueSize:   notifyQueue,
+		notifyQueues:      map[string]chan *notification{},
 	}
 }
 
@@ -63,41 +179,69 @@ type Updater struct {
 	minRefresh        time.Duration
 	idleDuration      time.Duration
 	cache             map[string]*triage.CollectionResult
-	lastRequest       sync.Map
-	secondLastRequest sync.Map
+	previous          map[string]*triage.CollectionResult
+	cacheMu           sync.RWMutex
+	requests          sync.Map
+	historySize       int
+	decay             DecayFunc
+	accessMu          sync.Mutex
 	lastPersist       time.Time
 	lastRun           time.Time
 	startTime         time.Time
 	loopEvery         time.Duration
 	mutex             *sync.Mutex
 	persistFunc       PFunc
+	persistFuzz       float64
 	persistStart      time.Time
+	persistRetries    int
+	persistRetryDelay time.Duration
+	breakerThreshold  int
+	breakerCooldown   time.Duration
+	persistFallback   PFunc
+	persistMu         sync.Mutex
+	rand              *rand.Rand
 	updateCycles      int
+	failures          int
+	retryAfter        time.Time
+	onChange          []ChangeFunc
+	notifyTimeout     time.Duration
+	notifyQueueSize   int
+	notifyQueues      map[string]chan *notification
+	notifyMu          sync.Mutex
+
+	// Consecutive failed persists, when the circuit breaker opened (zero if closed), and the latest error. Guarded
+	// by persistMu, as are persistStart and lastPersist.
+	persistFailures int
+	breakerOpened   time.Time
+	persistErr      error
 
 	state string
 }
 
-// recordAccess records stats on collection accesses
-func (u *Updater) recordAccess(id string) {
-	last := u.lastRequested(id)
-	if !last.IsZero() {
-		u.secondLastRequest.Store(id, last)
-	}
-	u.lastRequest.Store(id, time.Now())
-}
-
 // State returns a basic state
 func (u *Updater) Status() string {
-	if !u.persistStart.IsZero() {
-		return fmt.Sprintf("%s - persisting since %s (%d cycles, %s uptime)", u.state, u.persistStart, u.updateCycles, time.Since(u.startTime))
+	similar := ""
+	if n, updated := u.party.SimilarityStatus(); n >= 0 {
+		similar = fmt.Sprintf(", similarity index: %d items", n)
+		if !updated.IsZero() {
+			similar += fmt.Sprintf(" updated %s ago", time.Since(updated).Round(time.Second))
+		}
+	}
+
+	state := u.state
+	if since, _ := u.persistTimes(); !since.IsZero() {
+		state = fmt.Sprintf("%s - persisting since %s", state, since)
 	}
-	return fmt.Sprintf("%s (%d cycles, %s uptime)", u.state, u.updateCycles, time.Since(u.startTime))
+	if opened, failures, err := u.breakerState(); !opened.IsZero() {
+		state = fmt.Sprintf("%s - persist circuit open since %s after %d consecutive failures: %v", state, opened, failures, err)
+	}
+	return fmt.Sprintf("%s (%d cycles, %s uptime%s)", state, u.updateCycles, time.Since(u.startTime), similar)
 }
 
 // Lookup results for a given metric
 func (u *Updater) Lookup(ctx context.Context, id string, blocking bool) *triage.CollectionResult {
 	defer u.recordAccess(id)
-	r := u.cache[id]
+	r := u.cached(id)
 	if r == nil {
 		if blocking {
 			klog.Warningf("%s is not available in the cache, blocking page load!", id)
@@ -108,15 +252,42 @@ func (u *Updater) Lookup(ctx context.Context, id string, blocking bool) *triage.
 			klog.Warningf("%s unavailable, but not blocking: happily returning nil", id)
 		}
 	}
-	r = u.cache[id]
-	return r
+	return u.withStaleness(u.cached(id))
+}
+
+// cached returns the latest result for a collection, or nil if there is none
+func (u *Updater) cached(id string) *triage.CollectionResult {
+	u.cacheMu.RLock()
+	defer u.cacheMu.RUnlock()
+	return u.cache[id]
+}
+
+// withStaleness returns a copy of a result, marked as stale if it is older than the collection allows. Cached
+// results are shared between requests, so they are not modified.
+func (u *Updater) withStaleness(r *triage.CollectionResult) *triage.CollectionResult {
+	if r == nil || r.Collection == nil {
+		return r
+	}
+
+	// By default, allow for one missed refresh, as refreshing takes time
+	def := 2 * u.maxRefresh
+	if r.Collection.UsedForStats {
+		def *= 3
+	}
+
+	c := *r
+	threshold := r.Collection.StaleThreshold(def)
+	if threshold > 0 && time.Since(r.Created) > threshold {
+		c.Stale = true
+		c.StaleSince = r.Created.Add(threshold)
+	}
+	return &c
 }
 
 func (u *Updater) ForceRefresh(ctx context.Context, id string) *triage.CollectionResult {
 	defer u.recordAccess(id)
 
-	_, ok := u.lastRequest.Load(id)
-	if !ok {
+	if u.lastRequested(id).IsZero() {
 		klog.Warningf("ignoring refresh request, %s has never been requested", id)
 		return u.Lookup(ctx, id, true)
 	}
@@ -131,7 +302,7 @@ func (u *Updater) ForceRefresh(ctx context.Context, id string) *triage.Collectio
 		klog.Errorf("update failed: %v", err)
 	}
 	klog.Infof("refresh complete for %s after %s", id, time.Since(start))
-	return u.cache[id]
+	return u.withStaleness(u.cached(id))
 }
 
 // shouldUpdate returns an error if a collection needs an update
@@ -141,8 +312,8 @@ func (u *Updater) shouldUpdate(id string, usedForStats bool, force bool) error {
 		return fmt.Errorf("cycle count is only %d", u.updateCycles)
 	}
 
-	result, ok := u.cache[id]
-	if !ok {
+	result := u.cached(id)
+	if result == nil {
 		return fmt.Errorf("results are not cached")
 	}
 
@@ -173,10 +344,8 @@ func (u *Updater) shouldUpdate(id string, usedForStats bool, force bool) error {
 		return nil
 	}
 
-	// Back-off based on average of time since last two requests
-	requestAge := time.Since(u.lastRequested(id))
-	secondRequestDiff := u.lastRequested(id).Sub(u.secondLastRequested(id))
-	needAge := ((requestAge + secondRequestDiff) / 2) + u.minRefresh
+	// Back-off based on how often the collection has been requested recently
+	needAge := accessInterval(u.accessHistory(id), u.historySize, time.Now(), u.startTime, u.decay) + u.minRefresh
 	if resultAge > needAge && !usedForStats {
 		return fmt.Errorf("result age (%s) too old based on popularity", resultAge)
 	}
@@ -185,36 +354,6 @@ func (u *Updater) shouldUpdate(id string, usedForStats bool, force bool) error {
 	return nil
 }
 
-// lastRequested is the last time someone requested to view a collection
-func (u *Updater) lastRequested(id string) time.Time {
-	x, ok := u.lastRequest.Load(id)
-	if !ok {
-		return time.Time{}
-	}
-
-	lr, ok := x.(time.Time)
-	if !ok {
-		return time.Time{}
-	}
-
-	return lr
-}
-
-// secondLastRequested is the second last time someone requested to view a collection
-func (u *Updater) secondLastRequested(id string) time.Time {
-	x, ok := u.secondLastRequest.Load(id)
-	if !ok {
-		return u.startTime
-	}
-
-	lr, ok := x.(time.Time)
-	if !ok {
-		return u.startTime
-	}
-
-	return lr
-}
-
 func (u *Updater) update(ctx context.Context, s triage.Collection, newerThan time.Time) error {
 	start := time.Now()
 	u.state = fmt.Sprintf("updating %s to %s", s.ID, logu.STime(newerThan))
@@ -224,11 +363,64 @@ func (u *Updater) update(ctx context.Context, s triage.Collection, newerThan tim
 	if err != nil {
 		return err
 	}
+
+	prev := u.cached(s.ID)
+	u.party.UpdateSimilar(similarityChanges(prev, r))
+
+	if len(u.onChange) > 0 {
+		if d := changes(prev, r); d != nil {
+			u.deliver(s, d)
+		}
+	}
+
+	u.cacheMu.Lock()
+	// Retained for diffing
+	if prev != nil {
+		u.previous[s.ID] = prev
+	}
 	u.cache[s.ID] = r
+	u.cacheMu.Unlock()
 	klog.Infof("<<< updated %q to %s (oldest input: %s, duration: %s) <<<", s.ID, logu.STime(r.Created), logu.STime(r.OldestInput), time.Since(start))
 	return nil
 }
 
+// similarityChanges returns the conversations in a result which are new or have changed since the previous result,
+// and the URLs of conversations which are no longer present
+func similarityChanges(prev *triage.CollectionResult, cur *triage.CollectionResult) ([]*hubbub.Conversation, []string) {
+	before := map[string]*hubbub.Conversation{}
+	if prev != nil {
+		for _, rr := range prev.RuleResults {
+			for _, co := range rr.Items {
+				before[co.URL] = co
+			}
+		}
+	}
+
+	changed := []*hubbub.Conversation{}
+	present := map[string]bool{}
+	for _, rr := range cur.RuleResults {
+		for _, co := range rr.Items {
+			if present[co.URL] {
+				continue
+			}
+			present[co.URL] = true
+
+			old := before[co.URL]
+			if old == nil || old.Title != co.Title || old.State != co.State || !old.Updated.Equal(co.Updated) {
+				changed = append(changed, co)
+			}
+		}
+	}
+
+	gone := []string{}
+	for url := range before {
+		if !present[url] {
+			gone = append(gone, url)
+		}
+	}
+	return changed, gone
+}
+
 // Run a single collection, optionally forcing an update
 func (u *Updater) RefreshCollection(ctx context.Context, id string, newerThan time.Time, force bool) (bool, error) {
 	klog.V(5).Infof("RefreshCollection: %s newer than %s, force=%v (locking mutex)", id, newerThan, force)
@@ -252,30 +444,121 @@ func (u *Updater) RefreshCollection(ctx context.Context, id string, newerThan ti
 
 // Persist saves results to the persistence layer
 func (u *Updater) Persist() error {
+	// advisory lock: the update loop and the signal handler may both ask to persist
+	u.persistMu.Lock()
 	if !u.persistStart.IsZero() {
+		u.persistMu.Unlock()
 		return errors.New("already persisting")
 	}
+	start := time.Now()
+	u.persistStart = start
+	u.persistMu.Unlock()
 
-	// advisory lock
-	u.persistStart = time.Now()
 	klog.Infof("*** Started to persist ...")
 
 	defer func() {
-		klog.Infof("*** Persist complete! Took %s", time.Since(u.persistStart))
+		klog.Infof("*** Persist complete! Took %s", time.Since(start))
+		u.persistMu.Lock()
 		u.persistStart = time.Time{}
 		u.lastPersist = time.Now()
+		u.persistMu.Unlock()
 	}()
 
-	if err := u.persistFunc(); err != nil {
+	if u.breakerOpen() {
+		klog.Warningf("persist circuit is open: skipping the backend")
+		return u.fallback(errors.New("persist circuit open"))
+	}
+
+	err := u.persistWithRetries()
+
+	u.persistMu.Lock()
+	if err == nil {
+		if !u.breakerOpened.IsZero() {
+			klog.Infof("persist succeeded: closing circuit after %d consecutive failures", u.persistFailures)
+		}
+		u.persistFailures = 0
+		u.breakerOpened = time.Time{}
+		u.persistErr = nil
+		u.persistMu.Unlock()
+		return nil
+	}
+
+	u.persistFailures++
+	u.persistErr = err
+	opened := u.persistFailures >= u.breakerThreshold
+	if opened {
+		if u.breakerOpened.IsZero() {
+			klog.Errorf("persist failed %d consecutive times: opening circuit for %s", u.persistFailures, u.breakerCooldown)
+		}
+		// A failed attempt after the cooldown keeps the circuit open for another cooldown
+		u.breakerOpened = time.Now()
+	}
+	u.persistMu.Unlock()
+
+	if opened {
+		return u.fallback(err)
+	}
+	return err
+}
+
+// persistWithRetries calls persistFunc, retrying failures with exponential backoff
+func (u *Updater) persistWithRetries() error {
+	delay := u.persistRetryDelay
+	var err error
+	for i := 0; i <= u.persistRetries; i++ {
+		if i > 0 {
+			klog.Warningf("persist failed: %v (retry %d of %d in %s)", err, i, u.persistRetries, delay)
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		if err = u.persistFunc(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// breakerOpen returns whether the circuit breaker is open, skipping the backend
+func (u *Updater) breakerOpen() bool {
+	u.persistMu.Lock()
+	defer u.persistMu.Unlock()
+	return !u.breakerOpened.IsZero() && time.Since(u.breakerOpened) < u.breakerCooldown
+}
+
+// breakerState returns when the circuit breaker opened, or zero if it is closed, the number of consecutive failed
+// persists, and the latest error
+func (u *Updater) breakerState() (time.Time, int, error) {
+	u.persistMu.Lock()
+	defer u.persistMu.Unlock()
+	return u.breakerOpened, u.persistFailures, u.persistErr
+}
+
+// fallback calls persistFallback, if set, in place of the backend. The backend error is returned either way, as the
+// backend did not have the data saved.
+func (u *Updater) fallback(err error) error {
+	if u.persistFallback == nil {
 		return err
 	}
+	if ferr := u.persistFallback(); ferr != nil {
+		return fmt.Errorf("%v (fallback failed: %v)", err, ferr)
+	}
+	klog.Infof("persist fallback succeeded")
+	return err
+}
 
-	return nil
+// persistTimes returns when the running persist started, or zero if none is running, and when the last one finished
+func (u *Updater) persistTimes() (time.Time, time.Time) {
+	u.persistMu.Lock()
+	defer u.persistMu.Unlock()
+	return u.persistStart, u.lastPersist
 }
 
 func (u *Updater) shouldPersist(updated bool) bool {
+	running, last := u.persistTimes()
+
 	// Already running
-	if !u.persistStart.IsZero() {
+	if !running.IsZero() {
 		return false
 	}
 
@@ -285,10 +568,9 @@ func (u *Updater) shouldPersist(updated bool) bool {
 	}
 
 	// Avoid write contention by fuzzing
-	fuzz := time.Duration(rand.Intn(int(u.maxRefresh.Seconds()))) * time.Second
-	cutoff := u.maxRefresh + fuzz
+	cutoff := u.maxRefresh + u.fuzz()
 
-	sinceSave := time.Since(u.lastPersist)
+	sinceSave := time.Since(last)
 	if sinceSave > cutoff {
 		klog.Infof("Should persist: we have new data, and it's been %s since the last run", sinceSave)
 		return true
@@ -297,6 +579,65 @@ func (u *Updater) shouldPersist(updated bool) bool {
 	return false
 }
 
+// fuzz returns a random duration within the persist fuzz window
+func (u *Updater) fuzz() time.Duration {
+	window := time.Duration(float64(u.maxRefresh) * u.persistFuzz)
+	// rand.Int63n panics on non-positive input
+	if window < time.Second {
+		return 0
+	}
+	return time.Duration(u.rand.Int63n(int64(window/time.Second))) * time.Second
+}
+
+// backoff returns how long to wait before the next run, given the number of consecutive failures
+func (u *Updater) backoff() time.Duration {
+	if u.failures == 0 {
+		return u.loopEvery
+	}
+
+	d := u.loopEvery
+	for i := 0; i < u.failures && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+
+	// Add up to 50% jitter so that replicas sharing a backend don't retry in lockstep
+	if half := int64(d / 2); half > 0 {
+		d += time.Duration(u.rand.Int63n(half))
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
+
+// Warm loads the configured hot collections in priority order, so that the first page load for each does not block.
+// Cached results are accepted, however old, as the update loop refreshes them afterwards.
+func (u *Updater) Warm(ctx context.Context) error {
+	ids := u.party.HotCollections()
+	if len(ids) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	var failed []string
+	for i, id := range ids {
+		u.state = fmt.Sprintf("warming %s (%d of %d)", id, i+1, len(ids))
+		if _, err := u.RefreshCollection(ctx, id, time.Time{}, true); err != nil {
+			klog.Errorf("%s failed to warm: %v", id, err)
+			failed = append(failed, id)
+		}
+	}
+
+	klog.Infof("warmed %d hot collections in %s", len(ids)-len(failed), time.Since(start))
+	if len(failed) > 0 {
+		return fmt.Errorf("collections failed to warm: %v", failed)
+	}
+	return nil
+}
+
 // Run once, optionally forcing an update
 func (u *Updater) RunOnce(ctx context.Context, force bool) (bool, error) {
 	updated := false
@@ -362,12 +703,22 @@ func (u *Updater) Loop(ctx context.Context) error {
 	ticker := time.NewTicker(u.loopEvery)
 	defer ticker.Stop()
 	for range ticker.C {
+		if time.Now().Before(u.retryAfter) {
+			continue
+		}
+
 		updated, err := u.RunOnce(ctx, false)
 		if err != nil {
-			klog.Errorf("err: %v", err)
+			u.failures++
+			wait := u.backoff()
+			u.retryAfter = time.Now().Add(wait)
+			klog.Errorf("err: %v (%d consecutive failures, retrying in %s)", err, u.failures, wait)
+			u.state = fmt.Sprintf("backing off %s after %d consecutive failures", wait, u.failures)
+		} else {
+			u.failures = 0
+			u.state = fmt.Sprintf("idle, waiting %s", u.loopEvery)
 		}
 
-		u.state = fmt.Sprintf("idle, waiting %s", u.loopEvery)
 		u.lastRun = time.Now()
 
 		if u.shouldPersist(updated) {
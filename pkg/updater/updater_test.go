@@ -0,0 +1,417 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/hubbub"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/triage-party/pkg/tag"
+	"github.com/google/triage-party/pkg/triage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzz(t *testing.T) {
+	u := New(Config{MaxRefresh: time.Minute, Rand: rand.New(rand.NewSource(1))})
+	for i := 0; i < 100; i++ {
+		f := u.fuzz()
+		assert.True(t, f >= 0 && f < time.Minute, "fuzz out of range: %s", f)
+	}
+
+	// Disabled
+	u = New(Config{MaxRefresh: time.Hour, PersistFuzz: 2, DisablePersistFuzz: true, Rand: rand.New(rand.NewSource(1))})
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, time.Duration(0), u.fuzz())
+	}
+
+	u = New(Config{MaxRefresh: time.Minute, PersistFuzz: 2, Rand: rand.New(rand.NewSource(1))})
+	for i := 0; i < 100; i++ {
+		f := u.fuzz()
+		assert.True(t, f >= 0 && f < 2*time.Minute, "fuzz out of range: %s", f)
+	}
+
+	// Same seed, same sequence
+	a := New(Config{MaxRefresh: time.Hour, Rand: rand.New(rand.NewSource(42))})
+	b := New(Config{MaxRefresh: time.Hour, Rand: rand.New(rand.NewSource(42))})
+	assert.Equal(t, a.fuzz(), b.fuzz())
+}
+
+func TestFuzzSmallMaxRefresh(t *testing.T) {
+	for _, d := range []time.Duration{0, 500 * time.Millisecond} {
+		u := New(Config{MaxRefresh: d})
+		assert.Equal(t, time.Duration(0), u.fuzz())
+		assert.True(t, u.shouldPersist(true))
+	}
+}
+
+func TestPersistOverlap(t *testing.T) {
+	started := make(chan bool)
+	release := make(chan bool)
+	u := New(Config{PersistFunc: func() error {
+		started <- true
+		<-release
+		return nil
+	}})
+
+	done := make(chan error)
+	go func() { done <- u.Persist() }()
+	<-started
+
+	assert.Error(t, u.Persist(), "second persist should not overlap the first")
+	assert.False(t, u.shouldPersist(true))
+
+	release <- true
+	assert.NoError(t, <-done)
+
+	running, last := u.persistTimes()
+	assert.True(t, running.IsZero())
+	assert.False(t, last.IsZero())
+}
+
+func TestPersistRetry(t *testing.T) {
+	calls := 0
+	u := New(Config{PersistRetries: 2, PersistRetryDelay: time.Millisecond, PersistFunc: func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}})
+
+	assert.NoError(t, u.Persist())
+	assert.Equal(t, 3, calls)
+	opened, failures, _ := u.breakerState()
+	assert.True(t, opened.IsZero())
+	assert.Equal(t, 0, failures)
+}
+
+func TestPersistBreaker(t *testing.T) {
+	calls := 0
+	fallbacks := 0
+	fail := true
+	u := New(Config{
+		PersistRetries:   -1,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Hour,
+		PersistFunc: func() error {
+			calls++
+			if fail {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+		PersistFallback: func() error {
+			fallbacks++
+			return nil
+		},
+	})
+
+	assert.Error(t, u.Persist())
+	assert.Equal(t, 0, fallbacks, "fallback should wait for the circuit to open")
+
+	// The second consecutive failure opens the circuit
+	assert.Error(t, u.Persist())
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 1, fallbacks)
+	opened, failures, err := u.breakerState()
+	assert.False(t, opened.IsZero())
+	assert.Equal(t, 2, failures)
+	assert.EqualError(t, err, "connection refused")
+
+	// While open, the backend is skipped
+	assert.Error(t, u.Persist())
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 2, fallbacks)
+
+	// After the cooldown, a success closes the circuit
+	u.persistMu.Lock()
+	u.breakerOpened = time.Now().Add(-2 * time.Hour)
+	u.persistMu.Unlock()
+	fail = false
+	assert.NoError(t, u.Persist())
+	opened, failures, _ = u.breakerState()
+	assert.True(t, opened.IsZero())
+	assert.Equal(t, 0, failures)
+}
+
+func TestBackoff(t *testing.T) {
+	u := New(Config{Rand: rand.New(rand.NewSource(1))})
+	assert.Equal(t, u.loopEvery, u.backoff())
+
+	prev := time.Duration(0)
+	for i := 1; i <= 6; i++ {
+		u.failures = i
+		base := u.loopEvery << uint(i)
+		d := u.backoff()
+		assert.True(t, d >= base && d < base+base/2, "failures=%d: backoff %s not in [%s, %s)", i, d, base, base+base/2)
+		assert.True(t, d > prev, "failures=%d: backoff %s did not grow past %s", i, d, prev)
+		prev = d
+	}
+
+	u.failures = 1000
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, maxBackoff, u.backoff())
+	}
+}
+
+func TestDiffResults(t *testing.T) {
+	a := &hubbub.Conversation{URL: "a", Tags: map[tag.Tag]bool{tag.Recv: true}}
+	b := &hubbub.Conversation{URL: "b", Tags: map[tag.Tag]bool{}}
+	b2 := &hubbub.Conversation{URL: "b", Tags: map[tag.Tag]bool{tag.Send: true}}
+	c := &hubbub.Conversation{URL: "c", Tags: map[tag.Tag]bool{}}
+
+	prev := &triage.CollectionResult{RuleResults: []*triage.RuleResult{{Items: []*hubbub.Conversation{a, b}}}}
+	cur := &triage.CollectionResult{RuleResults: []*triage.RuleResult{{Items: []*hubbub.Conversation{b2, c}}}}
+
+	d := diffResults(prev, cur)
+	assert.Equal(t, []*hubbub.Conversation{c}, d.Added)
+	assert.Equal(t, []*hubbub.Conversation{a}, d.Removed)
+	assert.Equal(t, []*hubbub.Conversation{b2}, d.TagsChanged)
+
+	d = diffResults(nil, cur)
+	assert.Len(t, d.Added, 2)
+	assert.Empty(t, d.Removed)
+
+	// Sorted by URL, whatever the map order
+	var items []*hubbub.Conversation
+	for _, url := range []string{"e", "b", "d", "a", "c"} {
+		items = append(items, &hubbub.Conversation{URL: url})
+	}
+	for i := 0; i < 10; i++ {
+		d = diffResults(nil, &triage.CollectionResult{RuleResults: []*triage.RuleResult{{Items: items}}})
+		assert.Equal(t, []*hubbub.Conversation{items[3], items[1], items[4], items[2], items[0]}, d.Added)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	a := &hubbub.Conversation{URL: "a", Tags: map[tag.Tag]bool{}}
+	b := &hubbub.Conversation{URL: "b", Tags: map[tag.Tag]bool{}}
+
+	prev := &triage.CollectionResult{RuleResults: []*triage.RuleResult{{Items: []*hubbub.Conversation{a}}}}
+	cur := &triage.CollectionResult{RuleResults: []*triage.RuleResult{{Items: []*hubbub.Conversation{a, b}}}}
+
+	assert.Nil(t, changes(nil, cur), "first result")
+	assert.Nil(t, changes(prev, prev), "unchanged")
+
+	d := changes(prev, cur)
+	if assert.NotNil(t, d) {
+		assert.Equal(t, []*hubbub.Conversation{b}, d.Added)
+	}
+
+	var got []string
+	u := New(Config{OnChange: []ChangeFunc{
+		func(s triage.Collection, d *Diff) { got = append(got, "first:"+s.ID) },
+		func(s triage.Collection, d *Diff) { got = append(got, "second:"+s.ID) },
+	}})
+	u.notify(triage.Collection{ID: "p0"}, d)
+	assert.Equal(t, []string{"first:p0", "second:p0"}, got)
+}
+
+func TestDiffDuringRefresh(t *testing.T) {
+	a := &hubbub.Conversation{URL: "a", Tags: map[tag.Tag]bool{}}
+	b := &hubbub.Conversation{URL: "b", Tags: map[tag.Tag]bool{}}
+
+	u := New(Config{})
+	assert.Nil(t, u.Diff("c"))
+	u.previous["c"] = &triage.CollectionResult{RuleResults: []*triage.RuleResult{{Items: []*hubbub.Conversation{a}}}}
+	u.cache["c"] = &triage.CollectionResult{RuleResults: []*triage.RuleResult{{Items: []*hubbub.Conversation{a, b}}}}
+
+	// As held by RefreshCollection for the length of a refresh
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	done := make(chan *Diff)
+	go func() { done <- u.Diff("c") }()
+	select {
+	case d := <-done:
+		assert.Equal(t, []*hubbub.Conversation{b}, d.Added)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Diff waited for a refresh")
+	}
+}
+
+func TestDeliverOrder(t *testing.T) {
+	var mu sync.Mutex
+	var got []time.Time
+	release := make(chan struct{})
+	u := New(Config{OnChange: []ChangeFunc{
+		func(s triage.Collection, d *Diff) {
+			<-release
+			mu.Lock()
+			got = append(got, d.Current)
+			mu.Unlock()
+		},
+	}, NotifyQueue: 3})
+
+	start := time.Now()
+	var want []time.Time
+	for i := 0; i < 5; i++ {
+		d := &Diff{Current: start.Add(time.Duration(i) * time.Second)}
+		if i < 4 {
+			want = append(want, d.Current)
+		}
+		// The first is held by the worker and three are queued, so the fifth is dropped rather than blocking
+		u.deliver(triage.Collection{ID: "p0"}, d)
+		if i == 0 {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	close(release)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(got) == len(want)
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, want, got)
+}
+
+func TestDeliverTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	var delivered []string
+	u := New(Config{OnChange: []ChangeFunc{
+		func(s triage.Collection, d *Diff) {
+			if s.ID == "slow" {
+				<-release
+			}
+			delivered = append(delivered, s.ID)
+		},
+	}, NotifyTimeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	u.deliver(triage.Collection{ID: "fast"}, &Diff{})
+	assert.Equal(t, []string{"fast"}, delivered)
+
+	u.deliver(triage.Collection{ID: "slow"}, &Diff{})
+	assert.True(t, time.Since(start) >= 50*time.Millisecond, "returned before the timeout")
+	assert.True(t, time.Since(start) < time.Second, "waited past the timeout")
+}
+
+func TestSimilarityChanges(t *testing.T) {
+	result := func(cos ...*hubbub.Conversation) *triage.CollectionResult {
+		return &triage.CollectionResult{RuleResults: []*triage.RuleResult{{Items: cos}}}
+	}
+
+	now := time.Now()
+	same := &hubbub.Conversation{URL: "u1", Title: "same", Updated: now}
+	retitled := &hubbub.Conversation{URL: "u2", Title: "old", Updated: now}
+	gone := &hubbub.Conversation{URL: "u3", Title: "gone", Updated: now}
+	added := &hubbub.Conversation{URL: "u4", Title: "new", Updated: now}
+
+	changed, removed := similarityChanges(result(same, retitled, gone), result(same, &hubbub.Conversation{URL: "u2", Title: "new", Updated: now.Add(time.Minute)}, added))
+	urls := []string{}
+	for _, co := range changed {
+		urls = append(urls, co.URL)
+	}
+	assert.Equal(t, []string{"u2", "u4"}, urls)
+	assert.Equal(t, []string{"u3"}, removed)
+
+	// Everything is new on the first run
+	changed, removed = similarityChanges(nil, result(same))
+	assert.Len(t, changed, 1)
+	assert.Empty(t, removed)
+}
+
+func TestWithStaleness(t *testing.T) {
+	u := New(Config{MaxRefresh: time.Hour})
+	created := time.Now().Add(-3 * time.Hour)
+
+	r := &triage.CollectionResult{Collection: &triage.Collection{ID: "c"}, Created: created}
+	got := u.withStaleness(r)
+	assert.True(t, got.Stale)
+	assert.Equal(t, created.Add(2*time.Hour), got.StaleSince)
+	assert.False(t, r.Stale, "cached result was modified")
+
+	r.Collection.StaleAfter = "4h"
+	assert.False(t, u.withStaleness(r).Stale)
+
+	r.Collection = &triage.Collection{ID: "stats", UsedForStats: true}
+	assert.False(t, u.withStaleness(r).Stale)
+
+	assert.Nil(t, u.withStaleness(nil))
+}
+
+func TestAccessInterval(t *testing.T) {
+	now := time.Now()
+	start := now.Add(-10 * time.Hour)
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+
+	// Matches the mean of the time since the latest access, and between the last two
+	assert.Equal(t, 2*time.Minute+30*time.Second, accessInterval([]time.Time{ago(5 * time.Minute), ago(time.Minute)}, 2, now, start, NoDecay))
+	assert.Equal(t, 5*time.Hour, accessInterval([]time.Time{ago(0)}, 2, now, start, NoDecay))
+
+	// A burst of views an hour ago, then nothing
+	burst := []time.Time{ago(64 * time.Minute), ago(63 * time.Minute), ago(62 * time.Minute), ago(61 * time.Minute), ago(60 * time.Minute)}
+	// Views every minute, up to now
+	steady := []time.Time{ago(4 * time.Minute), ago(3 * time.Minute), ago(2 * time.Minute), ago(time.Minute), ago(0)}
+
+	assert.Equal(t, 48*time.Second, accessInterval(steady, 5, now, start, NoDecay))
+	assert.Equal(t, 12*time.Minute+48*time.Second, accessInterval(burst, 5, now, start, NoDecay))
+
+	decay := ExponentialDecay(10 * time.Minute)
+	assert.True(t, accessInterval(steady, 5, now, start, decay) < time.Minute, "steady views should stay frequent")
+	assert.True(t, accessInterval(burst, 5, now, start, decay) > 50*time.Minute, "burst should be dominated by the time since")
+}
+
+func TestRecordAccess(t *testing.T) {
+	u := New(Config{AccessHistory: 3})
+	assert.True(t, u.lastRequested("c").IsZero())
+
+	for i := 0; i < 5; i++ {
+		u.recordAccess("c")
+	}
+	h := u.accessHistory("c")
+	assert.Len(t, h, 3)
+	assert.Equal(t, h[2], u.lastRequested("c"))
+}
+
+func TestWarm(t *testing.T) {
+	m, err := persist.NewMemory(persist.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, m.Initialize())
+
+	p := triage.New(triage.Config{Cache: m})
+	config := `
+settings:
+  name: warm
+  repos: [https://github.com/org/project]
+  hot_collections: [second, first]
+collections:
+  - id: first
+    name: First
+    rules: []
+  - id: second
+    name: Second
+    rules: []
+  - id: cold
+    name: Cold
+    rules: [bugs]
+rules:
+  bugs:
+    name: Bugs
+    filters:
+      - label: bug
+`
+	assert.NoError(t, p.Load(strings.NewReader(config)))
+
+	u := New(Config{Party: p})
+	assert.NoError(t, u.Warm(context.Background()))
+
+	// Hot collections are loaded in the configured order, and others are left cold
+	first, second := u.cache["first"], u.cache["second"]
+	if assert.NotNil(t, first) && assert.NotNil(t, second) {
+		assert.False(t, first.Created.Before(second.Created))
+	}
+	assert.Nil(t, u.cache["cold"])
+
+	// Already fresh collections are refreshed again, as warming is forced
+	before := u.cache["second"]
+	assert.NoError(t, u.Warm(context.Background()))
+	assert.True(t, before != u.cache["second"], "second was not refreshed")
+}